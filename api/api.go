@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
@@ -9,6 +8,7 @@ import (
 	"github.com/ONSdigital/dp-graph/v2/graph/driver"
 	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
 	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/graphqlapi"
 	"github.com/ONSdigital/dp-net/v2/links"
 
 	"github.com/ONSdigital/dp-hierarchy-api/models"
@@ -22,53 +22,85 @@ type API struct {
 	codeListAPIURL     *url.URL
 	r                  *mux.Router
 	enableURLRewriting bool
+	cacheControl       string
+	writer             datastore.Writer
+	writeToken         string
 }
 
-func New(r *mux.Router, db datastore.Storer, hierarchyAPIURL, codeListAPIURL *url.URL, enableURLRewriting bool) *API {
+func New(r *mux.Router, db datastore.Storer, hierarchyAPIURL, codeListAPIURL *url.URL, enableURLRewriting bool, cacheControl string) *API {
 	api := &API{
 		store:              db,
 		host:               hierarchyAPIURL,
 		codeListAPIURL:     codeListAPIURL,
 		r:                  r,
 		enableURLRewriting: enableURLRewriting,
-	}
-
-	api.r.Path("/hierarchies/{instance}/{dimension}").HandlerFunc(api.hierarchiesHandler).Name("hierarchy_url")
-	api.r.Path("/hierarchies/{instance}/{dimension}/{code}").HandlerFunc(api.codesHandler)
+		cacheControl:       cacheControl,
+	}
+
+	api.handle("/hierarchies/{instance}", "hierarchies_url", api.instanceHierarchiesHandler)
+	// registered first, as the hierarchy_url route matches every method
+	api.handle("/hierarchies/{instance}/{dimension}", "put_hierarchy_url", api.putHierarchyHandler).Methods(http.MethodPut)
+	api.handle("/hierarchies/{instance}/{dimension}", "hierarchy_url", api.hierarchiesHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/_export", "export_url", api.exportHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/_search", "search_url", api.searchHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/codes", "codes_url", api.batchCodesHandler).Methods(http.MethodPost)
+	api.handle("/hierarchies/{instance}/{dimension}/_diff/{other}", "diff_url", api.diffHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/_validate", "validate_url", api.validateHandler).Methods(http.MethodGet)
+	api.handle("/hierarchies/{instance}/{dimension}/{code}", "code_url", api.codesHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/{code}/descendants", "descendants_url", api.descendantsHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/{code}/ancestors", "ancestors_url", api.ancestorsHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/{code}/siblings", "siblings_url", api.siblingsHandler)
+	api.handle("/hierarchies/{instance}/{dimension}/{code}/leaves", "leaves_url", api.leavesHandler)
+	api.handle("/graphql", "graphql_url", graphqlapi.NewHandler(db, codeListAPIURL, enableURLRewriting).ServeHTTP).Methods(http.MethodPost)
 
 	return api
 }
 
+// handle registers a traced handler for the route with the given path and name
+func (api *API) handle(path, name string, handler http.HandlerFunc) *mux.Route {
+	return api.r.Path(path).HandlerFunc(traced(name, handler)).Name(name)
+}
+
 func (api *API) hierarchiesHandler(w http.ResponseWriter, req *http.Request) {
 	instance := mux.Vars(req)["instance"]
 	dimension := mux.Vars(req)["dimension"]
 	logData := log.Data{"instance_id": instance, "dimension": dimension}
 	ctx := req.Context()
 
-	log.Info(ctx, "attempting to get hierarchy root", logData)
+	mediaType := negotiate(req.Header.Get("Accept"), nodeMediaTypes)
+	if mediaType == "" {
+		logData["accept"] = req.Header.Get("Accept")
+		log.Error(ctx, "no acceptable media type requested", errNotAcceptable, logData)
+		writeError(ctx, w, req, http.StatusNotAcceptable, errCodeNotAcceptable, errNotAcceptable.Error())
+		return
+	}
+	logData["media_type"] = mediaType
 
-	var err error
-	var codelistID string
-	if codelistID, err = api.store.GetHierarchyCodelist(ctx, instance, dimension); err != nil && err != driver.ErrNotFound {
-		log.Error(ctx, "error getting hierarchy code list", err, logData)
-		w.WriteHeader(http.StatusInternalServerError)
+	opts, err := getChildOptions(req)
+	if err != nil {
+		log.Error(ctx, "invalid query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
 		return
 	}
+	logData["children"] = opts
 
-	if err == driver.ErrNotFound || codelistID == "" {
-		log.Error(ctx, "hierarchy not found", err, logData)
-		w.WriteHeader(http.StatusNotFound)
+	log.Info(ctx, "attempting to get hierarchy root", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
 		return
 	}
 
 	var dbRes *dbmodels.HierarchyResponse
-	if dbRes, err = api.store.GetHierarchyRoot(ctx, instance, dimension); err != nil {
+	var total int
+	if dbRes, total, err = api.store.GetHierarchyRoot(ctx, instance, dimension, opts); err != nil {
 		log.Error(ctx, "error getting hierarchy root", err, logData)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(ctx, w, req)
 		return
 	}
 
-	res := mapHierarchyResponse(dbRes)
+	res := models.MapHierarchyResponse(dbRes)
+	res.TotalCount = total
 
 	if api.enableURLRewriting {
 		hierarchyLinksBuilder := links.FromHeadersOrDefault(&req.Header, req, api.host)
@@ -78,16 +110,22 @@ func (api *API) hierarchiesHandler(w http.ResponseWriter, req *http.Request) {
 		res.AddLinks(api.host.String(), instance, dimension, codelistID, true)
 	}
 
-	b, err := json.Marshal(res)
+	b, err := renderResponse(&res, mediaType)
 	if err != nil {
-		log.Error(ctx, "error marshalling json response", err, logData)
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(ctx, "error rendering response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept")
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchy root not modified", logData)
 		return
 	}
 
 	log.Info(ctx, "get hierarchy root successful", logData)
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", mediaType)
 	if _, err = w.Write(b); err != nil {
 		log.Error(ctx, "hierarchiesHandler endpoint: error writing bytes to response", err, logData)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -101,37 +139,47 @@ func (api *API) codesHandler(w http.ResponseWriter, req *http.Request) {
 	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code}
 	ctx := req.Context()
 
-	log.Info(ctx, "attempting to get hierarchy node for code", logData)
+	mediaType := negotiate(req.Header.Get("Accept"), nodeMediaTypes)
+	if mediaType == "" {
+		logData["accept"] = req.Header.Get("Accept")
+		log.Error(ctx, "no acceptable media type requested", errNotAcceptable, logData)
+		writeError(ctx, w, req, http.StatusNotAcceptable, errCodeNotAcceptable, errNotAcceptable.Error())
+		return
+	}
+	logData["media_type"] = mediaType
 
-	var err error
-	var codelistID string
-	if codelistID, err = api.store.GetHierarchyCodelist(ctx, instance, dimension); err != nil && err != driver.ErrNotFound {
-		log.Error(ctx, "error getting hierarchy code list", err, logData)
-		w.WriteHeader(http.StatusInternalServerError)
+	opts, err := getChildOptions(req)
+	if err != nil {
+		log.Error(ctx, "invalid query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
 		return
 	}
+	logData["children"] = opts
+
+	log.Info(ctx, "attempting to get hierarchy node for code", logData)
 
-	if err == driver.ErrNotFound || codelistID == "" {
-		log.Error(ctx, "hierarchy not found", err, logData)
-		w.WriteHeader(http.StatusNotFound)
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
 		return
 	}
 
 	var dbRes *dbmodels.HierarchyResponse
-	if dbRes, err = api.store.GetHierarchyElement(ctx, instance, dimension, code); err != nil && err != driver.ErrNotFound {
+	var total int
+	if dbRes, total, err = api.store.GetHierarchyElement(ctx, instance, dimension, code, opts); err != nil && err != driver.ErrNotFound {
 		log.Error(ctx, "error getting hierarchy element", err, logData)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(ctx, w, req)
 		return
 	}
 
 	if err == driver.ErrNotFound || dbRes.Label == "" {
 		err = errors.New("incorrect code")
 		log.Error(ctx, "code not found", err, logData)
-		w.WriteHeader(http.StatusNotFound)
+		writeCodeNotFound(ctx, w, req)
 		return
 	}
 
-	res := mapHierarchyResponse(dbRes)
+	res := models.MapHierarchyResponse(dbRes)
+	res.TotalCount = total
 
 	if api.enableURLRewriting {
 		hierarchyLinksBuilder := links.FromHeadersOrDefault(&req.Header, req, api.host)
@@ -141,51 +189,24 @@ func (api *API) codesHandler(w http.ResponseWriter, req *http.Request) {
 		res.AddLinks(api.host.String(), instance, dimension, codelistID, false)
 	}
 
-	b, err := json.Marshal(res)
+	b, err := renderResponse(&res, mediaType)
 	if err != nil {
-		log.Error(ctx, "error marshalling json response", err, logData)
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(ctx, "error rendering response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept")
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchy node for code not modified", logData)
 		return
 	}
 
 	log.Info(ctx, "get hierarchy node for code successful", logData)
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", mediaType)
 	if _, err = w.Write(b); err != nil {
 		log.Error(ctx, "codesHandler endpoint: error writing bytes to response", err, logData)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
-
-func mapHierarchyResponse(dbResponse *dbmodels.HierarchyResponse) models.Response {
-	response := models.Response{
-		ID:           dbResponse.ID,
-		Label:        dbResponse.Label,
-		Children:     mapHierarchyElements(dbResponse.Children),
-		NoOfChildren: dbResponse.NoOfChildren,
-		HasData:      dbResponse.HasData,
-		Breadcrumbs:  mapHierarchyElements(dbResponse.Breadcrumbs),
-		Order:        dbResponse.Order,
-	}
-
-	return response
-}
-
-func mapHierarchyElements(dbElements []*dbmodels.HierarchyElement) []*models.Element {
-	//nolint:prealloc // Causes unit tests to fail []*models.Element(nil){} is not equal to []*models.Element{}
-	var elements []*models.Element
-
-	for _, dbElement := range dbElements {
-		element := &models.Element{
-			ID:           dbElement.ID,
-			Label:        dbElement.Label,
-			NoOfChildren: dbElement.NoOfChildren,
-			HasData:      dbElement.HasData,
-			Order:        dbElement.Order,
-		}
-
-		elements = append(elements, element)
-	}
-
-	return elements
-}
@@ -5,10 +5,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/ONSdigital/dp-graph/v2/graph/driver"
 	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
 	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
 	"github.com/ONSdigital/dp-hierarchy-api/models"
 
@@ -26,15 +28,15 @@ func TestAPIResponseStatuses(t *testing.T) {
 	t.Parallel()
 
 	validMockDatastore := &datastoretest.StorerMock{
-		GetHierarchyRootFunc: func(_ context.Context, _, _ string) (*dbmodels.HierarchyResponse, error) {
+		GetHierarchyRootFunc: func(_ context.Context, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
 			return &dbmodels.HierarchyResponse{
 				Label: "validlabel",
-			}, nil
+			}, 0, nil
 		},
-		GetHierarchyElementFunc: func(_ context.Context, _, _, _ string) (*dbmodels.HierarchyResponse, error) {
+		GetHierarchyElementFunc: func(_ context.Context, _, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
 			return &dbmodels.HierarchyResponse{
 				Label: "validlabel",
-			}, nil
+			}, 0, nil
 		},
 		GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
 			return "codelistID", nil
@@ -42,11 +44,11 @@ func TestAPIResponseStatuses(t *testing.T) {
 	}
 
 	notFoundMockDatastore := &datastoretest.StorerMock{
-		GetHierarchyRootFunc: func(_ context.Context, _, _ string) (*dbmodels.HierarchyResponse, error) {
-			return nil, driver.ErrNotFound
+		GetHierarchyRootFunc: func(_ context.Context, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+			return nil, 0, driver.ErrNotFound
 		},
-		GetHierarchyElementFunc: func(_ context.Context, _, _, _ string) (*dbmodels.HierarchyResponse, error) {
-			return nil, driver.ErrNotFound
+		GetHierarchyElementFunc: func(_ context.Context, _, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+			return nil, 0, driver.ErrNotFound
 		},
 		GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
 			return "", driver.ErrNotFound
@@ -57,7 +59,7 @@ func TestAPIResponseStatuses(t *testing.T) {
 		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34", http.NoBody)
 		w := httptest.NewRecorder()
 
-		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false)
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
 
 		api.hierarchiesHandler(w, r)
 		So(w.Code, ShouldEqual, http.StatusOK)
@@ -68,7 +70,7 @@ func TestAPIResponseStatuses(t *testing.T) {
 		addExternalHeaders(r)
 		w := httptest.NewRecorder()
 
-		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true)
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true, "")
 
 		api.hierarchiesHandler(w, r)
 		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/code-lists/codelistID/codes"`)
@@ -80,7 +82,7 @@ func TestAPIResponseStatuses(t *testing.T) {
 		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34", http.NoBody)
 		w := httptest.NewRecorder()
 
-		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true)
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true, "")
 
 		api.hierarchiesHandler(w, r)
 		So(w.Body.String(), ShouldContainSubstring, `"http://localhost:22400/code-lists/codelistID/codes"`)
@@ -92,7 +94,7 @@ func TestAPIResponseStatuses(t *testing.T) {
 		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codeN", http.NoBody)
 		w := httptest.NewRecorder()
 
-		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false)
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
 
 		api.codesHandler(w, r)
 		So(w.Code, ShouldEqual, http.StatusOK)
@@ -103,7 +105,7 @@ func TestAPIResponseStatuses(t *testing.T) {
 		addExternalHeaders(r)
 		w := httptest.NewRecorder()
 
-		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true)
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true, "")
 
 		api.codesHandler(w, r)
 		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/code-lists/codelistID/codes"`)
@@ -115,7 +117,7 @@ func TestAPIResponseStatuses(t *testing.T) {
 		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codeN", http.NoBody)
 		w := httptest.NewRecorder()
 
-		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true)
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, true, "")
 
 		api.codesHandler(w, r)
 		So(w.Body.String(), ShouldContainSubstring, `"http://localhost:22400/code-lists/codelistID/codes"`)
@@ -123,34 +125,121 @@ func TestAPIResponseStatuses(t *testing.T) {
 		So(w.Code, ShouldEqual, http.StatusOK)
 	})
 
+	Convey("When asking for a hierarchy node as CSV, we get a CSV response", t, func() {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codeN", http.NoBody)
+		r.Header.Set("Accept", "text/csv")
+		w := httptest.NewRecorder()
+
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		api.codesHandler(w, r)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "text/csv")
+		So(w.Header().Get("Vary"), ShouldEqual, "Accept")
+		So(w.Body.String(), ShouldStartWith, "relation,code,label,order,has_data,no_of_children\n")
+	})
+
+	Convey("When asking for a hierarchy node as Turtle, we get a SKOS response", t, func() {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codeN", http.NoBody)
+		r.Header.Set("Accept", "text/turtle")
+		w := httptest.NewRecorder()
+
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		api.codesHandler(w, r)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "text/turtle")
+		So(w.Body.String(), ShouldStartWith, turtlePrefixes)
+		So(w.Body.String(), ShouldContainSubstring, `skos:prefLabel "validlabel"`)
+	})
+
+	Convey("When asking for a hierarchy as NDJSON, we get an NDJSON response", t, func() {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34", http.NoBody)
+		r.Header.Set("Accept", "application/x-ndjson")
+		w := httptest.NewRecorder()
+
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		api.hierarchiesHandler(w, r)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/x-ndjson")
+	})
+
+	Convey("When asking for a hierarchy in an unsupported media type, we get a 406 response", t, func() {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34", http.NoBody)
+		r.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+
+		api := New(router, validMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		api.hierarchiesHandler(w, r)
+		So(w.Code, ShouldEqual, http.StatusNotAcceptable)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "not_acceptable")
+	})
+
 	Convey("When asking for a non-existant hierarchy, we get a 404 response", t, func() {
 		r := httptest.NewRequest("GET", "/hierarchies/none/dim34", http.NoBody)
 		w := httptest.NewRecorder()
 
-		api := New(router, notFoundMockDatastore, hierarchyAPIURL, codeListAPIURL, false)
+		api := New(router, notFoundMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
 
 		api.hierarchiesHandler(w, r)
 		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/problem+json")
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "hierarchy_not_found")
 	})
 
 	Convey("When asking for a non-existant hierarchy node, we get a 404 response", t, func() {
 		r := httptest.NewRequest("GET", "/hierarchies/none/dim34/codeN", http.NoBody)
 		w := httptest.NewRecorder()
 
-		api := New(router, notFoundMockDatastore, hierarchyAPIURL, codeListAPIURL, false)
+		api := New(router, notFoundMockDatastore, hierarchyAPIURL, codeListAPIURL, false, "")
 
 		api.codesHandler(w, r)
 		So(w.Code, ShouldEqual, http.StatusNotFound)
 	})
 }
 
+func TestGraphQLRoute(t *testing.T) {
+	t.Parallel()
+
+	Convey("When posting a GraphQL query through the API's router, it is resolved against the datastore", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyRootFunc: func(_ context.Context, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{Label: "validlabel"}, 0, nil
+			},
+		}
+		r := mux.NewRouter()
+		New(r, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ hierarchy(instanceId: \"hier12\", dimension: \"dim34\") { label } }"}`)))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"data":{"hierarchy":{"label":"validlabel"}}}`)
+	})
+
+	Convey("When getting a GraphQL query, it is not allowed", t, func() {
+		r := mux.NewRouter()
+		New(r, &datastoretest.StorerMock{}, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/graphql", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+	})
+}
+
 func TestMapHierarchyResponse(t *testing.T) {
 	t.Parallel()
 
 	Convey("An empty DB response is mapped to an empty API response", t, func() {
 		dbResponse := &dbmodels.HierarchyResponse{}
 		expected := models.Response{}
-		So(mapHierarchyResponse(dbResponse), ShouldResemble, expected)
+		So(models.MapHierarchyResponse(dbResponse), ShouldResemble, expected)
 	})
 
 	Convey("A populated DB response without children or breadcrumbs is mapped to the corresponding API response", t, func() {
@@ -167,7 +256,7 @@ func TestMapHierarchyResponse(t *testing.T) {
 			Order:   &order,
 			HasData: true,
 		}
-		So(mapHierarchyResponse(dbResponse), ShouldResemble, expected)
+		So(models.MapHierarchyResponse(dbResponse), ShouldResemble, expected)
 	})
 
 	Convey("A DB response with children is mapped to the corresponding API response", t, func() {
@@ -194,7 +283,7 @@ func TestMapHierarchyResponse(t *testing.T) {
 			},
 			NoOfChildren: 1,
 		}
-		So(mapHierarchyResponse(dbResponse), ShouldResemble, expected)
+		So(models.MapHierarchyResponse(dbResponse), ShouldResemble, expected)
 	})
 
 	Convey("A DB response with breadcrumbs is mapped to the corresponding API response", t, func() {
@@ -219,7 +308,7 @@ func TestMapHierarchyResponse(t *testing.T) {
 				},
 			},
 		}
-		So(mapHierarchyResponse(dbResponse), ShouldResemble, expected)
+		So(models.MapHierarchyResponse(dbResponse), ShouldResemble, expected)
 	})
 }
 
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// maxBatchBodyBytes is the largest batch request body accepted
+const maxBatchBodyBytes = 1 << 20
+
+func (api *API) batchCodesHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension}
+	ctx := req.Context()
+
+	codes, err := readCodes(w, req)
+	if err != nil {
+		log.Error(ctx, "invalid batch codes request", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidBody, err.Error())
+		return
+	}
+	logData["num_codes"] = len(codes)
+
+	log.Info(ctx, "attempting to get hierarchy nodes for codes", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
+		return
+	}
+
+	var dbRes map[string]*dbmodels.HierarchyResponse
+	if dbRes, err = api.store.GetHierarchyElements(ctx, instance, dimension, codes); err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "error getting hierarchy elements", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "hierarchy not found", err, logData)
+		writeHierarchyNotFound(ctx, w, req)
+		return
+	}
+
+	var hierarchyHost, codeListHost string
+	if api.enableURLRewriting {
+		hierarchyHost = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		codeListHost = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+
+	res := models.CodesResponse{
+		Count: len(codes),
+		Items: make(map[string]*models.CodeResult, len(codes)),
+	}
+
+	for _, code := range codes {
+		dbItem, found := dbRes[code]
+		if !found {
+			res.Items[code] = &models.CodeResult{ErrorCode: models.ErrorCodeCodeNotFound}
+			continue
+		}
+
+		item := models.MapHierarchyResponse(dbItem)
+		isRoot := len(item.Breadcrumbs) == 0
+		if api.enableURLRewriting {
+			item.AddLinksWithRewriting(hierarchyHost, codeListHost, instance, dimension, codelistID, isRoot)
+		} else {
+			item.AddLinks(api.host.String(), instance, dimension, codelistID, isRoot)
+		}
+		res.Items[code] = &models.CodeResult{Node: &item}
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	logData["num_found"] = len(dbRes)
+	log.Info(ctx, "get hierarchy nodes for codes successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "batchCodesHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// readCodes returns the codes in a batch request body, without duplicates
+func readCodes(w http.ResponseWriter, req *http.Request) ([]string, error) {
+	var body models.CodesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBatchBodyBytes)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+
+	return models.BatchCodes(body.Codes)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBatchCodesHandler(t *testing.T) {
+	t.Parallel()
+
+	newMockDatastore := func(elementsErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyElementsFunc: func(_ context.Context, _, _ string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+				if elementsErr != nil {
+					return nil, elementsErr
+				}
+				return map[string]*dbmodels.HierarchyResponse{
+					"root":  {ID: "root", Label: "United Kingdom"},
+					"child": {ID: "child", Label: "England", Breadcrumbs: []*dbmodels.HierarchyElement{{ID: "root", Label: "United Kingdom"}}},
+				}, nil
+			},
+		}
+	}
+
+	newRequest := func(body string) *http.Request {
+		r := httptest.NewRequest("POST", "/hierarchies/hier12/dim34/codes", strings.NewReader(body))
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	Convey("When looking up a batch of codes, each code is mapped to its node or a not found entry", t, func() {
+		store := newMockDatastore(nil)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.batchCodesHandler(w, newRequest(`{"codes": ["child", "missing", "root", "child"]}`))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+		So(store.GetHierarchyElementsCalls()[0].Codes, ShouldResemble, []string{"child", "missing", "root"})
+
+		var res models.CodesResponse
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		So(res.Count, ShouldEqual, 3)
+		So(res.Items, ShouldHaveLength, 3)
+		So(res.Items["child"].Node.Label, ShouldEqual, "England")
+		So(res.Items["child"].Node.Breadcrumbs, ShouldHaveLength, 1)
+		So(res.Items["child"].Node.Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34/child")
+		So(res.Items["root"].Node.Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34")
+		So(res.Items["missing"].Node, ShouldBeNil)
+		So(res.Items["missing"].ErrorCode, ShouldEqual, "code_not_found")
+	})
+
+	Convey("When the request body is invalid, we get a 400 response", t, func() {
+		codes := make([]string, models.MaxBatchCodes+1)
+		for i := range codes {
+			codes[i] = strconv.Itoa(i)
+		}
+		tooMany, _ := json.Marshal(models.CodesRequest{Codes: codes})
+
+		for _, body := range []string{`not json`, `{}`, `{"codes": []}`, `{"codes": ["a", ""]}`, string(tooMany)} {
+			store := newMockDatastore(nil)
+			w := httptest.NewRecorder()
+
+			api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+			api.batchCodesHandler(w, newRequest(body))
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(decodeProblem(w).ErrorCode, ShouldEqual, "invalid_body")
+			So(store.GetHierarchyElementsCalls(), ShouldBeEmpty)
+		}
+	})
+
+	Convey("When the hierarchy does not exist, we get a 404 response", t, func() {
+		store := newMockDatastore(nil)
+		store.GetHierarchyCodelistFunc = func(_ context.Context, _, _ string) (string, error) {
+			return "", driver.ErrNotFound
+		}
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.batchCodesHandler(w, newRequest(`{"codes": ["root"]}`))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "hierarchy_not_found")
+	})
+
+	Convey("When the datastore fails to get the nodes, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.batchCodesHandler(w, newRequest(`{"codes": ["root"]}`))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+
+	Convey("Given the API's router", t, func() {
+		store := newMockDatastore(nil)
+		store.GetHierarchyElementFunc = func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+			return &dbmodels.HierarchyResponse{ID: code, Label: "A code called codes"}, 0, nil
+		}
+		router := mux.NewRouter()
+		New(router, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		Convey("When codes are posted, the batch is looked up", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/hierarchies/hier12/dim34/codes", strings.NewReader(`{"codes": ["root"]}`)))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(store.GetHierarchyElementsCalls(), ShouldHaveLength, 1)
+		})
+
+		Convey("When a code named codes is requested, the single node is returned", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codes", http.NoBody))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldContainSubstring, "A code called codes")
+			So(store.GetHierarchyElementsCalls(), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+const maxChildrenLimit = 1000
+
+var errInvalidSort = errors.New("sort must be one of order, label or code")
+
+// getChildOptions returns the selection of a node's children asked for by the has_data, sort, offset and
+// limit query parameters. Every child is selected, in the order of the hierarchy, when none are provided.
+func getChildOptions(req *http.Request) (datastore.ChildOptions, error) {
+	var opts datastore.ChildOptions
+	var err error
+
+	if opts.HasData, err = getHasData(req); err != nil {
+		return opts, err
+	}
+
+	switch sort := datastore.ChildSort(req.URL.Query().Get("sort")); sort {
+	case "", datastore.SortByOrder, datastore.SortByLabel, datastore.SortByCode:
+		opts.Sort = sort
+	default:
+		return opts, errInvalidSort
+	}
+
+	if opts.Offset, err = getOffset(req); err != nil {
+		return opts, err
+	}
+
+	if opts.Limit, err = getLimit(req, 0, maxChildrenLimit); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
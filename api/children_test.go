@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetChildOptions(t *testing.T) {
+	t.Parallel()
+
+	getOptions := func(query string) (datastore.ChildOptions, error) {
+		return getChildOptions(httptest.NewRequest("GET", "/hierarchies/hier12/dim34?"+query, http.NoBody))
+	}
+
+	Convey("When no query parameters are given, every child is selected in hierarchy order", t, func() {
+		opts, err := getOptions("")
+		So(err, ShouldBeNil)
+		So(opts, ShouldResemble, datastore.ChildOptions{})
+	})
+
+	Convey("When every query parameter is given, each is read into the options", t, func() {
+		opts, err := getOptions("has_data=false&sort=label&offset=20&limit=10")
+		So(err, ShouldBeNil)
+		So(*opts.HasData, ShouldBeFalse)
+		So(opts.Sort, ShouldEqual, datastore.SortByLabel)
+		So(opts.Offset, ShouldEqual, 20)
+		So(opts.Limit, ShouldEqual, 10)
+	})
+
+	Convey("When a query parameter is invalid, an error is returned", t, func() {
+		for _, query := range []string{"has_data=yes", "sort=size", "offset=-1", "limit=0", "limit=1001"} {
+			_, err := getOptions(query)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestChildSelection(t *testing.T) {
+	t.Parallel()
+
+	newMockDatastore := func() *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyRootFunc: func(_ context.Context, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: "root", Label: "Root", NoOfChildren: 3, Children: []*dbmodels.HierarchyElement{{ID: "b", Label: "B"}}}, 2, nil
+			},
+			GetHierarchyElementFunc: func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: code, Label: "Node", NoOfChildren: 3, Children: []*dbmodels.HierarchyElement{{ID: "c", Label: "C"}}}, 3, nil
+			},
+		}
+	}
+
+	Convey("Given the API's router", t, func() {
+		store := newMockDatastore()
+		router := mux.NewRouter()
+		New(router, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		Convey("When the root is requested with child options, they are passed to the datastore and the total is returned", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34?has_data=true&sort=code&offset=1&limit=1", http.NoBody))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(store.GetHierarchyRootCalls(), ShouldHaveLength, 1)
+			opts := store.GetHierarchyRootCalls()[0].Opts
+			So(*opts.HasData, ShouldBeTrue)
+			So(opts.Sort, ShouldEqual, datastore.SortByCode)
+			So(opts.Offset, ShouldEqual, 1)
+			So(opts.Limit, ShouldEqual, 1)
+
+			var res models.Response
+			So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+			So(res.TotalCount, ShouldEqual, 2)
+			So(res.NoOfChildren, ShouldEqual, 3)
+			So(res.Children, ShouldHaveLength, 1)
+		})
+
+		Convey("When a code is requested with child options, they are passed to the datastore and the total is returned", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34/code1?limit=1", http.NoBody))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(store.GetHierarchyElementCalls(), ShouldHaveLength, 1)
+			So(store.GetHierarchyElementCalls()[0].Opts, ShouldResemble, datastore.ChildOptions{Limit: 1})
+			So(w.Body.String(), ShouldContainSubstring, `"total_count":3`)
+		})
+
+		Convey("When a child option is invalid, we get a 400 response without calling the datastore", func() {
+			for _, target := range []string{"/hierarchies/hier12/dim34?sort=size", "/hierarchies/hier12/dim34/code1?offset=x"} {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest("GET", target, http.NoBody))
+
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(decodeProblem(w).ErrorCode, ShouldEqual, errCodeInvalidParameter)
+			}
+			So(store.GetHierarchyRootCalls(), ShouldBeEmpty)
+			So(store.GetHierarchyElementCalls(), ShouldBeEmpty)
+		})
+	})
+}
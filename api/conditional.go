@@ -0,0 +1,44 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// etag returns a strong entity tag for a response body
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeNotModified sets the ETag and Cache-Control headers for a response body. If the request's
+// If-None-Match header shows that the client already holds the body, 304 Not Modified is written
+// and true is returned.
+func (api *API) writeNotModified(w http.ResponseWriter, req *http.Request, body []byte) bool {
+	tag := etag(body)
+	w.Header().Set("ETag", tag)
+	if api.cacheControl != "" {
+		w.Header().Set("Cache-Control", api.cacheControl)
+	}
+
+	if !matchesETag(req.Header.Get("If-None-Match"), tag) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// matchesETag reports whether an If-None-Match header matches the given entity tag. As required
+// for If-None-Match, tags are compared weakly, ignoring any W/ prefix.
+func matchesETag(ifNoneMatch, tag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
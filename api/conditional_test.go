@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	Convey("The same body always has the same strong entity tag", t, func() {
+		So(etag([]byte("body")), ShouldEqual, etag([]byte("body")))
+		So(etag([]byte("body")), ShouldStartWith, `"`)
+		So(etag([]byte("body")), ShouldNotEqual, etag([]byte("other body")))
+	})
+}
+
+func TestMatchesETag(t *testing.T) {
+	t.Parallel()
+
+	const tag = `"abc"`
+
+	Convey("If-None-Match headers are compared against the entity tag", t, func() {
+		So(matchesETag(`"abc"`, tag), ShouldBeTrue)
+		So(matchesETag(`W/"abc"`, tag), ShouldBeTrue)
+		So(matchesETag(`"xyz", "abc"`, tag), ShouldBeTrue)
+		So(matchesETag(`*`, tag), ShouldBeTrue)
+		So(matchesETag(`"xyz"`, tag), ShouldBeFalse)
+		So(matchesETag(`abc`, tag), ShouldBeFalse)
+		So(matchesETag(``, tag), ShouldBeFalse)
+	})
+}
+
+func TestConditionalGet(t *testing.T) {
+	t.Parallel()
+
+	store := &datastoretest.StorerMock{
+		GetHierarchyElementFunc: func(_ context.Context, _, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+			return &dbmodels.HierarchyResponse{Label: "validlabel"}, 0, nil
+		},
+		GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+			return "codelistID", nil
+		},
+	}
+
+	newRequest := func(accept, ifNoneMatch string) *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codeN", http.NoBody)
+		r.Header.Set("Accept", accept)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34", "code": "codeN"})
+	}
+
+	Convey("Given a node that has already been fetched", t, func() {
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "public, max-age=60")
+
+		first := httptest.NewRecorder()
+		api.codesHandler(first, newRequest("application/json", ""))
+		tag := first.Header().Get("ETag")
+
+		So(first.Code, ShouldEqual, http.StatusOK)
+		So(tag, ShouldNotBeEmpty)
+		So(first.Header().Get("Cache-Control"), ShouldEqual, "public, max-age=60")
+
+		Convey("When it is requested again with its entity tag, we get a 304 response without a body", func() {
+			w := httptest.NewRecorder()
+			api.codesHandler(w, newRequest("application/json", tag))
+
+			So(w.Code, ShouldEqual, http.StatusNotModified)
+			So(w.Body.Len(), ShouldEqual, 0)
+			So(w.Header().Get("ETag"), ShouldEqual, tag)
+			So(w.Header().Get("Cache-Control"), ShouldEqual, "public, max-age=60")
+		})
+
+		Convey("When it is requested again with a different entity tag, we get the whole document", func() {
+			w := httptest.NewRecorder()
+			api.codesHandler(w, newRequest("application/json", `"stale"`))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, first.Body.String())
+		})
+
+		Convey("When it is requested in another media type, the entity tag does not match", func() {
+			w := httptest.NewRecorder()
+			api.codesHandler(w, newRequest("text/csv", tag))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("ETag"), ShouldNotEqual, tag)
+		})
+	})
+
+	Convey("When no Cache-Control value is configured, the header is omitted", t, func() {
+		w := httptest.NewRecorder()
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.codesHandler(w, newRequest("application/json", ""))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("ETag"), ShouldNotBeEmpty)
+		So(w.Header(), ShouldNotContainKey, "Cache-Control")
+	})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+var errInvalidDepth = errors.New("depth must be a positive integer")
+
+func (api *API) descendantsHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	code := mux.Vars(req)["code"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code}
+	ctx := req.Context()
+
+	depth, err := getDepth(req)
+	if err != nil {
+		log.Error(ctx, "invalid depth query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
+		return
+	}
+	logData["depth"] = depth
+
+	log.Info(ctx, "attempting to get hierarchy descendants for code", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
+		return
+	}
+
+	var dbRes *datastore.HierarchyNode
+	if dbRes, err = api.store.GetHierarchyDescendants(ctx, instance, dimension, code, depth); err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "error getting hierarchy descendants", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "code not found", err, logData)
+		writeCodeNotFound(ctx, w, req)
+		return
+	}
+
+	res := models.MapHierarchyNode(dbRes)
+
+	if api.enableURLRewriting {
+		hierarchyLinksBuilder := links.FromHeadersOrDefault(&req.Header, req, api.host)
+		codeListLinksBuilder := links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL)
+		res.AddRewrittenLinks(hierarchyLinksBuilder.URL.String(), codeListLinksBuilder.URL.String(), instance, dimension, codelistID)
+	} else {
+		res.AddLinks(api.host.String(), instance, dimension, codelistID)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchy descendants for code not modified", logData)
+		return
+	}
+
+	log.Info(ctx, "get hierarchy descendants for code successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "descendantsHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getDepth returns the depth query parameter, or 0 for the whole subtree when it is not provided
+func getDepth(req *http.Request) (int, error) {
+	value := req.URL.Query().Get("depth")
+	if value == "" {
+		return 0, nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 {
+		return 0, errInvalidDepth
+	}
+
+	return depth, nil
+}
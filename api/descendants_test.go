@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDescendantsHandler(t *testing.T) {
+	t.Parallel()
+
+	tree := &datastore.HierarchyNode{
+		HierarchyElement: dbmodels.HierarchyElement{ID: "parent", Label: "Parent", NoOfChildren: 1},
+		Children: []*datastore.HierarchyNode{
+			{
+				HierarchyElement: dbmodels.HierarchyElement{ID: "child", Label: "Child", NoOfChildren: 1},
+				Children: []*datastore.HierarchyNode{
+					{HierarchyElement: dbmodels.HierarchyElement{ID: "grandchild", Label: "Grandchild", HasData: true}},
+				},
+			},
+		},
+	}
+
+	newMockDatastore := func(descendantsErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyDescendantsFunc: func(_ context.Context, _, _, _ string, _ int) (*datastore.HierarchyNode, error) {
+				return tree, descendantsErr
+			},
+		}
+	}
+
+	newRequest := func(target string) *http.Request {
+		r := httptest.NewRequest("GET", target, http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34", "code": "parent"})
+	}
+
+	Convey("When asking for the descendants of a node, we get the nested subtree with links", t, func() {
+		store := newMockDatastore(nil)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.descendantsHandler(w, newRequest("/hierarchies/hier12/dim34/parent/descendants?depth=2"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+		So(w.Body.String(), ShouldContainSubstring, `"label":"Grandchild"`)
+		So(w.Body.String(), ShouldContainSubstring, `"http://localhost:22600/hierarchies/hier12/dim34/grandchild"`)
+		So(w.Body.String(), ShouldContainSubstring, `/code-lists/codelistID/codes/child"`)
+		So(store.GetHierarchyDescendantsCalls(), ShouldHaveLength, 1)
+		So(store.GetHierarchyDescendantsCalls()[0].Code, ShouldEqual, "parent")
+		So(store.GetHierarchyDescendantsCalls()[0].Depth, ShouldEqual, 2)
+	})
+
+	Convey("When asking for the descendants of a node with URL rewriting enabled from an external host, the links are rewritten", t, func() {
+		r := newRequest("/hierarchies/hier12/dim34/parent/descendants")
+		addExternalHeaders(r)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, true, "")
+		api.descendantsHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/hierarchies/hier12/dim34/grandchild"`)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/code-lists/codelistID/codes/child"`)
+	})
+
+	Convey("When asking for descendants with an invalid depth, we get a 400 response", t, func() {
+		for _, depth := range []string{"0", "-1", "abc"} {
+			store := newMockDatastore(nil)
+			w := httptest.NewRecorder()
+
+			api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+			api.descendantsHandler(w, newRequest("/hierarchies/hier12/dim34/parent/descendants?depth="+depth))
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(decodeProblem(w).ErrorCode, ShouldEqual, "invalid_parameter")
+			So(store.GetHierarchyDescendantsCalls(), ShouldBeEmpty)
+		}
+	})
+
+	Convey("When asking for the descendants of a code that does not exist, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(driver.ErrNotFound), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.descendantsHandler(w, newRequest("/hierarchies/hier12/dim34/parent/descendants"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		problem := decodeProblem(w)
+		So(problem.ErrorCode, ShouldEqual, "code_not_found")
+		So(problem.Code, ShouldEqual, "parent")
+	})
+
+	Convey("When the datastore fails to get the descendants, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.descendantsHandler(w, newRequest("/hierarchies/hier12/dim34/parent/descendants"))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "internal_error")
+	})
+}
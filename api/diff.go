@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/diff"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// diffHandler returns the changes to a hierarchy from the other instance, e.g. the previous edition of a
+// dataset, to the instance in the path
+func (api *API) diffHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	other := mux.Vars(req)["other"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "other_instance_id": other}
+	ctx := req.Context()
+
+	log.Info(ctx, "attempting to compare hierarchies", logData)
+
+	if _, ok := api.getCodelistID(w, req, logData); !ok {
+		return
+	}
+
+	if _, err := api.store.GetHierarchyCodelist(ctx, other, dimension); err != nil {
+		if err == driver.ErrNotFound {
+			log.Error(ctx, "hierarchy to compare with not found", err, logData)
+			detail := fmt.Sprintf("no hierarchy found for dimension %q of instance %q", dimension, other)
+			writeError(ctx, w, req, http.StatusNotFound, errCodeHierarchyNotFound, detail)
+			return
+		}
+		log.Error(ctx, "error getting codelist for hierarchy to compare with", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	changes, err := diff.Compare(ctx, api.store, dimension, other, instance)
+	if err != nil {
+		log.Error(ctx, "error comparing hierarchies", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	res := mapChangeSet(changes)
+	res.InstanceID = instance
+	res.OtherInstanceID = other
+	res.Dimension = dimension
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchy comparison not modified", logData)
+		return
+	}
+
+	logData["changes"] = res.Count
+	log.Info(ctx, "compare hierarchies successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "diffHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func mapChangeSet(changes *diff.ChangeSet) *models.HierarchyDiff {
+	res := &models.HierarchyDiff{
+		Added:      mapExportNodes(changes.Added),
+		Removed:    mapExportNodes(changes.Removed),
+		Relabelled: make([]*models.LabelChange, 0, len(changes.Relabelled)),
+		Moved:      make([]*models.ParentChange, 0, len(changes.Moved)),
+		Reordered:  make([]*models.OrderChange, 0, len(changes.Reordered)),
+	}
+
+	for _, c := range changes.Relabelled {
+		res.Relabelled = append(res.Relabelled, &models.LabelChange{Code: c.After.ID, From: c.Before.Label, To: c.After.Label})
+	}
+	for _, c := range changes.Moved {
+		res.Moved = append(res.Moved, &models.ParentChange{Code: c.After.ID, Label: c.After.Label, From: c.Before.ParentID, To: c.After.ParentID})
+	}
+	for _, c := range changes.Reordered {
+		res.Reordered = append(res.Reordered, &models.OrderChange{Code: c.After.ID, Label: c.After.Label, From: c.Before.Order, To: c.After.Order})
+	}
+
+	res.Count = len(res.Added) + len(res.Removed) + len(res.Relabelled) + len(res.Moved) + len(res.Reordered)
+	return res
+}
+
+func mapExportNodes(nodes []*datastore.WalkedNode) []*models.ExportNode {
+	exported := make([]*models.ExportNode, 0, len(nodes))
+	for _, node := range nodes {
+		exported = append(exported, mapExportNode(node))
+	}
+	return exported
+}
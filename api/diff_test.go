@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffHandler(t *testing.T) {
+	t.Parallel()
+
+	walked := func(code, label, parent string) *datastore.WalkedNode {
+		return &datastore.WalkedNode{HierarchyElement: dbmodels.HierarchyElement{ID: code, Label: label}, ParentID: parent}
+	}
+
+	hierarchies := map[string][]*datastore.WalkedNode{
+		"previous": {
+			walked("root", "United Kingdom", ""),
+			walked("a", "England", "root"),
+			walked("b", "Wales", "root"),
+		},
+		"current": {
+			walked("root", "United Kingdom", ""),
+			walked("a", "England", "root"),
+			walked("c", "Scotland", "root"),
+			walked("b", "Wales / Cymru", "a"),
+		},
+	}
+
+	newMockDatastore := func(walkErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, instanceID, _ string) (string, error) {
+				if _, ok := hierarchies[instanceID]; !ok {
+					return "", driver.ErrNotFound
+				}
+				return "codelistID", nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, instanceID, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+				if walkErr != nil {
+					return walkErr
+				}
+				for _, node := range hierarchies[instanceID] {
+					if err := fn(node); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+	}
+
+	newRequest := func(instance, other string) *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/"+instance+"/geography/_diff/"+other, http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": instance, "dimension": "geography", "other": other})
+	}
+
+	Convey("When comparing an instance with a previous one, we get the changes from the previous one", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.diffHandler(w, newRequest("current", "previous"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("ETag"), ShouldNotBeEmpty)
+
+		var res models.HierarchyDiff
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		So(res.InstanceID, ShouldEqual, "current")
+		So(res.OtherInstanceID, ShouldEqual, "previous")
+		So(res.Count, ShouldEqual, 3)
+		So(res.Added, ShouldHaveLength, 1)
+		So(res.Added[0].Code, ShouldEqual, "c")
+		So(res.Added[0].ParentCode, ShouldEqual, "root")
+		So(res.Removed, ShouldBeEmpty)
+		So(res.Relabelled, ShouldResemble, []*models.LabelChange{{Code: "b", From: "Wales", To: "Wales / Cymru"}})
+		So(res.Moved, ShouldResemble, []*models.ParentChange{{Code: "b", Label: "Wales / Cymru", From: "root", To: "a"}})
+		So(res.Reordered, ShouldBeEmpty)
+	})
+
+	Convey("When the diff route is requested through the router, the diff handler is used", t, func() {
+		store := newMockDatastore(nil)
+		router := mux.NewRouter()
+		New(router, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/previous/geography/_diff/current", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"removed":[{"code":"c"`)
+	})
+
+	Convey("When the instance to compare with has no hierarchy, we get a 404 response naming it", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.diffHandler(w, newRequest("current", "missing"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		problem := decodeProblem(w)
+		So(problem.ErrorCode, ShouldEqual, errCodeHierarchyNotFound)
+		So(problem.Detail, ShouldContainSubstring, `"missing"`)
+	})
+
+	Convey("When the instance itself has no hierarchy, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.diffHandler(w, newRequest("missing", "previous"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("When walking a hierarchy fails, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.diffHandler(w, newRequest("current", "previous"))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
+
+func TestDiffRoute(t *testing.T) {
+	t.Parallel()
+
+	Convey("When asking for the ancestors of a code named diff, they are returned rather than a comparison", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyAncestorsFunc: func(_ context.Context, _, _, code string) ([]*dbmodels.HierarchyElement, error) {
+				return []*dbmodels.HierarchyElement{{Label: "parent of " + code}}, nil
+			},
+		}
+		r := mux.NewRouter()
+		New(r, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34/diff/ancestors", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"label":"parent of diff"`)
+		So(store.WalkHierarchyCalls(), ShouldBeEmpty)
+	})
+}
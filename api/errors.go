@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// mediaTypeProblem is the media type of error responses
+const mediaTypeProblem = "application/problem+json"
+
+// Error codes identifying each kind of failure in a problem document. These are part of the API
+// contract and must not change.
+const (
+	errCodeHierarchyNotFound    = "hierarchy_not_found"
+	errCodeCodeNotFound         = models.ErrorCodeCodeNotFound
+	errCodeInvalidParameter     = "invalid_parameter"
+	errCodeInvalidBody          = "invalid_body"
+	errCodeNotAcceptable        = "not_acceptable"
+	errCodeInternal             = "internal_error"
+	errCodeInvalidHierarchy     = "invalid_hierarchy"
+	errCodeUnauthorised         = "unauthorised"
+	errCodeMethodNotAllowed     = "method_not_allowed"
+	errCodeUnsupportedMediaType = "unsupported_media_type"
+)
+
+// writeError writes a problem document for a failed request. The instance, dimension and code
+// involved are taken from the request's path variables.
+func writeError(ctx context.Context, w http.ResponseWriter, req *http.Request, status int, errorCode, detail string) {
+	vars := mux.Vars(req)
+
+	requestID := req.Header.Get(request.RequestHeaderKey)
+	if requestID == "" {
+		requestID = request.GetRequestId(ctx)
+	}
+
+	problem := models.Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     detail,
+		Instance:   req.URL.Path,
+		ErrorCode:  errorCode,
+		InstanceID: vars["instance"],
+		Dimension:  vars["dimension"],
+		Code:       vars["code"],
+		RequestID:  requestID,
+	}
+
+	b, err := json.Marshal(problem)
+	if err != nil {
+		log.Error(ctx, "error marshalling problem response", err, log.Data{"problem": problem})
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeProblem)
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "error writing problem response", err, log.Data{"problem": problem})
+	}
+}
+
+// writeInternalError writes a problem document for an unexpected failure, without exposing its cause
+func writeInternalError(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+	writeError(ctx, w, req, http.StatusInternalServerError, errCodeInternal, "failed to process the request due to an internal error")
+}
+
+// writeHierarchyNotFound writes a problem document for an instance and dimension without a hierarchy
+func writeHierarchyNotFound(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	detail := fmt.Sprintf("no hierarchy found for dimension %q of instance %q", vars["dimension"], vars["instance"])
+	writeError(ctx, w, req, http.StatusNotFound, errCodeHierarchyNotFound, detail)
+}
+
+// writeCodeNotFound writes a problem document for a code that is not in a hierarchy
+func writeCodeNotFound(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+	detail := fmt.Sprintf("code %q not found in the hierarchy", mux.Vars(req)["code"])
+	writeError(ctx, w, req, http.StatusNotFound, errCodeCodeNotFound, detail)
+}
+
+// getCodelistID returns the code list of the hierarchy for the request's instance and dimension.
+// If the hierarchy cannot be found, an error response is written and false is returned.
+func (api *API) getCodelistID(w http.ResponseWriter, req *http.Request, logData log.Data) (string, bool) {
+	ctx := req.Context()
+	vars := mux.Vars(req)
+
+	codelistID, err := api.store.GetHierarchyCodelist(ctx, vars["instance"], vars["dimension"])
+	if err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "error getting hierarchy code list", err, logData)
+		writeInternalError(ctx, w, req)
+		return "", false
+	}
+
+	if err == driver.ErrNotFound || codelistID == "" {
+		log.Error(ctx, "hierarchy not found", err, logData)
+		writeHierarchyNotFound(ctx, w, req)
+		return "", false
+	}
+
+	return codelistID, true
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/codeN", http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34", "code": "codeN"})
+	}
+
+	Convey("When writing an error, a problem document describing the request is returned", t, func() {
+		r := newRequest()
+		r.Header.Set(request.RequestHeaderKey, "request-123")
+		w := httptest.NewRecorder()
+
+		writeError(r.Context(), w, r, http.StatusBadRequest, errCodeInvalidParameter, "depth must be a positive integer")
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/problem+json")
+
+		problem := decodeProblem(w)
+		So(problem, ShouldResemble, &models.Problem{
+			Type:       "about:blank",
+			Title:      "Bad Request",
+			Status:     http.StatusBadRequest,
+			Detail:     "depth must be a positive integer",
+			Instance:   "/hierarchies/hier12/dim34/codeN",
+			ErrorCode:  "invalid_parameter",
+			InstanceID: "hier12",
+			Dimension:  "dim34",
+			Code:       "codeN",
+			RequestID:  "request-123",
+		})
+	})
+
+	Convey("When a code is not found, the problem names the code", t, func() {
+		r := newRequest()
+		w := httptest.NewRecorder()
+
+		writeCodeNotFound(r.Context(), w, r)
+
+		problem := decodeProblem(w)
+		So(problem.Status, ShouldEqual, http.StatusNotFound)
+		So(problem.ErrorCode, ShouldEqual, errCodeCodeNotFound)
+		So(problem.Detail, ShouldContainSubstring, `"codeN"`)
+		So(problem.RequestID, ShouldBeEmpty)
+	})
+
+	Convey("When an internal error occurs, the problem does not expose its cause", t, func() {
+		r := newRequest()
+		w := httptest.NewRecorder()
+
+		writeInternalError(r.Context(), w, r)
+
+		problem := decodeProblem(w)
+		So(problem.Status, ShouldEqual, http.StatusInternalServerError)
+		So(problem.ErrorCode, ShouldEqual, errCodeInternal)
+		So(problem.Detail, ShouldNotBeEmpty)
+	})
+}
+
+func decodeProblem(w *httptest.ResponseRecorder) *models.Problem {
+	var problem models.Problem
+	So(json.Unmarshal(w.Body.Bytes(), &problem), ShouldBeNil)
+	return &problem
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// exportFlushInterval is the number of nodes written between flushes of a streamed export
+const exportFlushInterval = 100
+
+// exportMediaTypes are the representations offered for a hierarchy export, in order of preference
+var exportMediaTypes = []string{
+	mediaTypeJSON, mediaTypeCSV, mediaTypeNDJSON, mediaTypeTurtle, mediaTypeJSONLD,
+	mediaTypeSDMXML, mediaTypeSDMXJSON, mediaTypeDOT, mediaTypeGraphML,
+}
+
+// exportFormats are the values of the format query parameter, which chooses the representation of an
+// export in place of the Accept header, for linking to from tools that cannot set it
+var exportFormats = map[string]string{"dot": mediaTypeDOT, "graphml": mediaTypeGraphML}
+
+var errInvalidFormat = errors.New("format must be one of dot or graphml")
+
+// exportCSVHeader is the header row of the CSV representation of an export
+var exportCSVHeader = []string{"code", "label", "parent_code", "order", "has_data", "no_of_children"}
+
+func (api *API) exportHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	code := req.URL.Query().Get("code")
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code}
+	ctx := req.Context()
+
+	depth, err := getDepth(req)
+	if err != nil {
+		log.Error(ctx, "invalid depth query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
+		return
+	}
+	logData["depth"] = depth
+
+	mediaType := negotiate(req.Header.Get("Accept"), exportMediaTypes)
+	if format := req.URL.Query().Get("format"); format != "" {
+		var ok bool
+		if mediaType, ok = exportFormats[format]; !ok {
+			logData["format"] = format
+			log.Error(ctx, "invalid format query parameter", errInvalidFormat, logData)
+			writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, errInvalidFormat.Error())
+			return
+		}
+	}
+	if mediaType == "" {
+		logData["accept"] = req.Header.Get("Accept")
+		log.Error(ctx, "no acceptable media type requested", errNotAcceptable, logData)
+		writeError(ctx, w, req, http.StatusNotAcceptable, errCodeNotAcceptable, errNotAcceptable.Error())
+		return
+	}
+	logData["media_type"] = mediaType
+
+	log.Info(ctx, "attempting to export hierarchy", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
+		return
+	}
+
+	linker := &skosLinker{
+		host:        api.host.String(),
+		codeListURL: models.CodelistURL,
+		instanceID:  instance,
+		dimension:   dimension,
+		codelistID:  codelistID,
+	}
+	if api.enableURLRewriting {
+		linker.host = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		linker.codeListURL = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+
+	// an export of a large hierarchy can take longer than the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Warn(ctx, "unable to remove write deadline for hierarchy export", log.FormatErrors([]error{err}), logData)
+	}
+
+	stream := newExportStream(w, rc, mediaType, linker)
+	err = api.store.WalkHierarchy(ctx, instance, dimension, datastore.WalkOptions{Code: code, Depth: depth}, func(node *datastore.WalkedNode) error {
+		return stream.write(mapExportNode(node))
+	})
+
+	if err != nil {
+		if stream.started {
+			log.Error(ctx, "error exporting hierarchy, the response is incomplete", err, logData)
+			return
+		}
+		if err == driver.ErrNotFound && code != "" {
+			log.Error(ctx, "code not found", err, logData)
+			writeError(ctx, w, req, http.StatusNotFound, errCodeCodeNotFound, fmt.Sprintf("code %q not found in the hierarchy", code))
+			return
+		}
+		if err == driver.ErrNotFound {
+			log.Error(ctx, "hierarchy not found", err, logData)
+			writeHierarchyNotFound(ctx, w, req)
+			return
+		}
+		log.Error(ctx, "error exporting hierarchy", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if err = stream.close(); err != nil {
+		log.Error(ctx, "exportHandler endpoint: error writing bytes to response", err, logData)
+		return
+	}
+
+	logData["nodes"] = stream.count
+	log.Info(ctx, "export hierarchy successful", logData)
+}
+
+// exportStream writes the nodes of an export to a response one at a time, so that the
+// whole hierarchy is never held in memory
+type exportStream struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	mediaType string
+	encoder   exportEncoder
+	count     int
+	// started is set once the response has begun, after which errors can no longer be reported
+	started bool
+}
+
+// exportEncoder encodes the nodes of an export in a particular media type
+type exportEncoder interface {
+	// encode returns the bytes for a node, including anything that must precede the first node. An
+	// encoder that cannot write nodes as they arrive returns nothing until the export ends.
+	encode(node *models.ExportNode, first bool) ([]byte, error)
+	// end returns the bytes that complete an export of count nodes
+	end(count int) []byte
+}
+
+// newExportStream creates a stream of an export in the given media type. The linker, which describes
+// the hierarchy being exported, is only used by the SKOS, SDMX and graph representations.
+func newExportStream(w http.ResponseWriter, rc *http.ResponseController, mediaType string, linker *skosLinker) *exportStream {
+	var encoder exportEncoder
+	switch mediaType {
+	case mediaTypeCSV:
+		encoder = &csvExportEncoder{}
+	case mediaTypeNDJSON:
+		encoder = ndjsonExportEncoder{}
+	case mediaTypeTurtle:
+		encoder = &turtleExportEncoder{linker: linker}
+	case mediaTypeJSONLD:
+		encoder = &jsonldExportEncoder{linker: linker}
+	case mediaTypeSDMXML, mediaTypeSDMXJSON:
+		encoder = newSDMXExportEncoder(mediaType, linker)
+	case mediaTypeDOT:
+		encoder = &dotExportEncoder{linker: linker}
+	case mediaTypeGraphML:
+		encoder = &graphMLExportEncoder{linker: linker}
+	default:
+		encoder = jsonExportEncoder{}
+	}
+
+	return &exportStream{w: w, rc: rc, mediaType: mediaType, encoder: encoder}
+}
+
+func (s *exportStream) write(node *models.ExportNode) error {
+	b, err := s.encoder.encode(node, s.count == 0)
+	if err != nil {
+		return err
+	}
+
+	s.count++
+	if len(b) == 0 {
+		return nil
+	}
+
+	if !s.started {
+		s.setHeaders()
+	}
+
+	if _, err = s.w.Write(b); err != nil {
+		return err
+	}
+
+	if s.count%exportFlushInterval == 0 {
+		if err = s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *exportStream) close() error {
+	if !s.started {
+		s.setHeaders()
+	}
+
+	_, err := s.w.Write(s.encoder.end(s.count))
+	return err
+}
+
+func (s *exportStream) setHeaders() {
+	contentType := s.mediaType
+	if version, ok := sdmxVersions[s.mediaType]; ok {
+		contentType = mime.FormatMediaType(s.mediaType, map[string]string{"version": version})
+	}
+
+	s.w.Header().Set("Content-Type", contentType)
+	s.w.Header().Set("Vary", "Accept")
+	s.started = true
+}
+
+// jsonExportEncoder encodes an export as a JSON array
+type jsonExportEncoder struct{}
+
+func (jsonExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	b, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+
+	if first {
+		return append([]byte("["), b...), nil
+	}
+	return append([]byte(","), b...), nil
+}
+
+func (jsonExportEncoder) end(count int) []byte {
+	if count == 0 {
+		return []byte("[]")
+	}
+	return []byte("]")
+}
+
+// ndjsonExportEncoder encodes an export as one JSON object per line
+type ndjsonExportEncoder struct{}
+
+func (ndjsonExportEncoder) encode(node *models.ExportNode, _ bool) ([]byte, error) {
+	b, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func (ndjsonExportEncoder) end(int) []byte {
+	return nil
+}
+
+// csvExportEncoder encodes an export as CSV with a header row
+type csvExportEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *csvExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	e.buf.Reset()
+	w := csv.NewWriter(&e.buf)
+
+	if first {
+		if err := w.Write(exportCSVHeader); err != nil {
+			return nil, err
+		}
+	}
+
+	record := []string{
+		node.Code,
+		node.Label,
+		node.ParentCode,
+		formatOrder(node.Order),
+		strconv.FormatBool(node.HasData),
+		strconv.FormatInt(node.NoOfChildren, 10),
+	}
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	return e.buf.Bytes(), w.Error()
+}
+
+func (e *csvExportEncoder) end(count int) []byte {
+	if count == 0 {
+		return []byte(strings.Join(exportCSVHeader, ",") + "\n")
+	}
+	return nil
+}
+
+func mapExportNode(node *datastore.WalkedNode) *models.ExportNode {
+	return &models.ExportNode{
+		Code:         node.ID,
+		Label:        node.Label,
+		ParentCode:   node.ParentID,
+		Order:        node.Order,
+		HasData:      node.HasData,
+		NoOfChildren: node.NoOfChildren,
+	}
+}
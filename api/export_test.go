@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExportHandler(t *testing.T) {
+	t.Parallel()
+
+	var order int64 = 1
+	walkedNodes := []*datastore.WalkedNode{
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "root", Label: "Root", NoOfChildren: 2, HasData: true}},
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "a", Label: "A", Order: &order}, ParentID: "root", Depth: 1},
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "b", Label: "B", HasData: true}, ParentID: "root", Depth: 1},
+	}
+
+	newMockDatastore := func(walkErr error, failAfter int) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+				for i, node := range walkedNodes {
+					if i == failAfter {
+						return walkErr
+					}
+					if err := fn(node); err != nil {
+						return err
+					}
+				}
+				return walkErr
+			},
+		}
+	}
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/_export", http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	Convey("When exporting a hierarchy, every node is streamed with its parent code", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+		var nodes []*models.ExportNode
+		So(json.Unmarshal(w.Body.Bytes(), &nodes), ShouldBeNil)
+		So(nodes, ShouldResemble, []*models.ExportNode{
+			{Code: "root", Label: "Root", NoOfChildren: 2, HasData: true},
+			{Code: "a", Label: "A", ParentCode: "root", Order: &order},
+			{Code: "b", Label: "B", ParentCode: "root", HasData: true},
+		})
+	})
+
+	Convey("When exporting a hierarchy as CSV, every node is streamed as a row after the header", t, func() {
+		r := newRequest()
+		r.Header.Set("Accept", "text/csv")
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "text/csv")
+		So(w.Body.String(), ShouldEqual, "code,label,parent_code,order,has_data,no_of_children\n"+
+			"root,Root,,,true,2\n"+
+			"a,A,root,1,false,0\n"+
+			"b,B,root,,true,0\n")
+	})
+
+	Convey("When exporting a hierarchy as NDJSON, every node is streamed on its own line", t, func() {
+		r := newRequest()
+		r.Header.Set("Accept", "application/x-ndjson")
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/x-ndjson")
+		So(w.Body.String(), ShouldEqual, `{"code":"root","label":"Root","has_data":true,"no_of_children":2}`+"\n"+
+			`{"code":"a","label":"A","parent_code":"root","order":1,"has_data":false}`+"\n"+
+			`{"code":"b","label":"B","parent_code":"root","has_data":true}`+"\n")
+	})
+
+	Convey("When exporting a hierarchy in an unsupported media type, we get a 406 response", t, func() {
+		r := newRequest()
+		r.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotAcceptable)
+	})
+
+	Convey("When exporting a hierarchy that does not exist, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+		store := newMockDatastore(nil, -1)
+		store.GetHierarchyCodelistFunc = func(_ context.Context, _, _ string) (string, error) {
+			return "", driver.ErrNotFound
+		}
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(store.WalkHierarchyCalls(), ShouldBeEmpty)
+	})
+
+	Convey("When the hierarchy has no root, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(driver.ErrNotFound, 0), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("When the walk fails before any node is written, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error"), 0), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+
+	Convey("When the walk fails part way through, the response is left incomplete", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error"), 2), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		var nodes []*models.ExportNode
+		So(json.Unmarshal(w.Body.Bytes(), &nodes), ShouldNotBeNil)
+	})
+
+	newQueryRequest := func(query string) *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/_export?"+query, http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	Convey("When exporting a hierarchy with a format, it is chosen in place of the Accept header", t, func() {
+		r := newQueryRequest("format=dot")
+		r.Header.Set("Accept", "text/csv")
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "text/vnd.graphviz")
+		So(w.Body.String(), ShouldStartWith, "digraph ")
+	})
+
+	Convey("When exporting a hierarchy with an unknown format, we get a 400 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newQueryRequest("format=pdf"))
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "invalid_parameter")
+	})
+
+	Convey("When exporting a hierarchy with an invalid depth, we get a 400 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil, -1), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newQueryRequest("depth=0"))
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "invalid_parameter")
+	})
+
+	Convey("When exporting a hierarchy to a depth, the walk is limited to that depth", t, func() {
+		store := newMockDatastore(nil, -1)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newQueryRequest("depth=1"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(store.WalkHierarchyCalls()[0].Opts, ShouldResemble, datastore.WalkOptions{Depth: 1})
+	})
+
+	Convey("When exporting the subtree below a code, it is walked from that code and its root keeps its parent", t, func() {
+		store := newMockDatastore(nil, -1)
+		store.WalkHierarchyFunc = func(_ context.Context, _, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+			for _, node := range []*datastore.WalkedNode{
+				{HierarchyElement: dbmodels.HierarchyElement{ID: "a", Label: "A", NoOfChildren: 1}, ParentID: "root"},
+				{HierarchyElement: dbmodels.HierarchyElement{ID: "a1", Label: "A1"}, ParentID: "a", Depth: 1},
+			} {
+				if err := fn(node); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newQueryRequest("code=a&depth=2"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		var nodes []*models.ExportNode
+		So(json.Unmarshal(w.Body.Bytes(), &nodes), ShouldBeNil)
+		So(nodes, ShouldResemble, []*models.ExportNode{
+			{Code: "a", Label: "A", ParentCode: "root", NoOfChildren: 1},
+			{Code: "a1", Label: "A1", ParentCode: "a"},
+		})
+		So(store.WalkHierarchyCalls()[0].Opts, ShouldResemble, datastore.WalkOptions{Code: "a", Depth: 2})
+	})
+
+	Convey("When exporting the subtree below a code that is not in the hierarchy, we get a 404 response", t, func() {
+		store := newMockDatastore(nil, -1)
+		store.WalkHierarchyFunc = func(_ context.Context, _, _ string, _ datastore.WalkOptions, _ datastore.WalkFunc) error {
+			return driver.ErrNotFound
+		}
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newQueryRequest("code=none"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "code_not_found")
+		So(decodeProblem(w).Detail, ShouldEqual, `code "none" not found in the hierarchy`)
+	})
+}
+
+func TestExportRoute(t *testing.T) {
+	t.Parallel()
+
+	Convey("When asking for a hierarchy node whose code is export, the node is returned rather than an export", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyElementFunc: func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: code, Label: "node " + code}, 0, nil
+			},
+		}
+		r := mux.NewRouter()
+		New(r, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34/export", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"label":"node export"`)
+		So(store.WalkHierarchyCalls(), ShouldBeEmpty)
+	})
+}
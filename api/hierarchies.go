@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// instanceHierarchiesHandler lists the dimensions of an instance that have a hierarchy
+func (api *API) instanceHierarchiesHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	logData := log.Data{"instance_id": instance}
+	ctx := req.Context()
+
+	log.Info(ctx, "attempting to list hierarchies for instance", logData)
+
+	dbRes, err := api.store.GetHierarchies(ctx, instance)
+	if err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "error listing hierarchies", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "no hierarchies found for instance", err, logData)
+		writeError(ctx, w, req, http.StatusNotFound, errCodeHierarchyNotFound, fmt.Sprintf("no hierarchies found for instance %q", instance))
+		return
+	}
+
+	hierarchyHost, codeListHost := api.host.String(), models.CodelistURL
+	if api.enableURLRewriting {
+		hierarchyHost = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		codeListHost = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+
+	res := models.Hierarchies{
+		Count: len(dbRes),
+		Items: make([]*models.HierarchySummary, 0, len(dbRes)),
+	}
+	for _, summary := range dbRes {
+		item := mapHierarchySummary(summary)
+		item.AddLinks(hierarchyHost, codeListHost, instance)
+		res.Items = append(res.Items, item)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchies for instance not modified", logData)
+		return
+	}
+
+	logData["count"] = res.Count
+	log.Info(ctx, "list hierarchies for instance successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "instanceHierarchiesHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func mapHierarchySummary(summary *datastore.HierarchySummary) *models.HierarchySummary {
+	return &models.HierarchySummary{
+		Dimension:  summary.Dimension,
+		CodelistID: summary.CodelistID,
+		NoOfNodes:  summary.NoOfNodes,
+		Depth:      summary.Depth,
+	}
+}
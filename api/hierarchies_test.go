@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstanceHierarchiesHandler(t *testing.T) {
+	t.Parallel()
+
+	newMockDatastore := func(err error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchiesFunc: func(_ context.Context, _ string) ([]*datastore.HierarchySummary, error) {
+				if err != nil {
+					return nil, err
+				}
+				return []*datastore.HierarchySummary{
+					{Dimension: "aggregate", CodelistID: "cpih1dim1aggid", NoOfNodes: 120, Depth: 3},
+					{Dimension: "geography", CodelistID: "admin-geography", NoOfNodes: 10, Depth: 3},
+				}, nil
+			},
+		}
+	}
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12", http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12"})
+	}
+
+	Convey("Given the API's router, when listing the hierarchies of an instance, we get each with links to its root", t, func() {
+		store := newMockDatastore(nil)
+		router := mux.NewRouter()
+		New(router, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(store.GetHierarchiesCalls(), ShouldHaveLength, 1)
+		So(store.GetHierarchiesCalls()[0].InstanceID, ShouldEqual, "hier12")
+
+		var res models.Hierarchies
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		So(res.Count, ShouldEqual, 2)
+		So(res.Items[0].Dimension, ShouldEqual, "aggregate")
+		So(res.Items[0].CodelistID, ShouldEqual, "cpih1dim1aggid")
+		So(res.Items[0].NoOfNodes, ShouldEqual, 120)
+		So(res.Items[0].Depth, ShouldEqual, 3)
+		So(res.Items[0].Links["root"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/aggregate")
+		So(res.Items[1].Links["code_list"].ID, ShouldEqual, "admin-geography")
+	})
+
+	Convey("When listing hierarchies with URL rewriting enabled from an external host, the links are rewritten", t, func() {
+		r := newRequest()
+		addExternalHeaders(r)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, true, "")
+		api.instanceHierarchiesHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/hierarchies/hier12/geography"`)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/code-lists/admin-geography"`)
+	})
+
+	Convey("When the instance has no hierarchies, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(driver.ErrNotFound), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.instanceHierarchiesHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, errCodeHierarchyNotFound)
+	})
+
+	Convey("When the datastore fails, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.instanceHierarchiesHandler(w, newRequest())
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultLeavesLimit = 100
+	maxLeavesLimit     = 1000
+)
+
+var (
+	errInvalidOffset  = errors.New("offset must be a non-negative integer")
+	errInvalidHasData = errors.New("has_data must be true or false")
+)
+
+func (api *API) leavesHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	code := mux.Vars(req)["code"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code}
+	ctx := req.Context()
+
+	hasData, err := getHasData(req)
+	if err != nil {
+		log.Error(ctx, "invalid has_data query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
+		return
+	}
+
+	offset, err := getOffset(req)
+	if err != nil {
+		log.Error(ctx, "invalid offset query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
+		return
+	}
+
+	limit, err := getLimit(req, defaultLeavesLimit, maxLeavesLimit)
+	if err != nil {
+		log.Error(ctx, "invalid limit query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
+		return
+	}
+	logData["has_data"] = hasData
+	logData["offset"] = offset
+	logData["limit"] = limit
+
+	log.Info(ctx, "attempting to get hierarchy leaves for code", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
+		return
+	}
+
+	leaves, total, err := api.store.GetHierarchyLeaves(ctx, instance, dimension, code, datastore.LeafOptions{HasData: hasData, Offset: offset, Limit: limit})
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "code not found", err, logData)
+		writeCodeNotFound(ctx, w, req)
+		return
+	}
+
+	if err != nil {
+		log.Error(ctx, "error getting hierarchy leaves", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	res := models.Leaves{
+		TotalCount: total,
+		Offset:     offset,
+		Limit:      limit,
+		Items:      models.MapHierarchyElements(leaves),
+	}
+	if res.Items == nil {
+		res.Items = []*models.Element{}
+	}
+	res.Count = len(res.Items)
+
+	var hierarchyHost, codeListHost string
+	if api.enableURLRewriting {
+		hierarchyHost = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		codeListHost = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+
+	for _, item := range res.Items {
+		if api.enableURLRewriting {
+			item.AddRewrittenLinks(hierarchyHost, codeListHost, instance, dimension, codelistID, true)
+		} else {
+			item.AddLinks(api.host.String(), instance, dimension, codelistID, true)
+		}
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchy leaves for code not modified", logData)
+		return
+	}
+
+	logData["count"] = res.Count
+	logData["total_count"] = res.TotalCount
+	log.Info(ctx, "get hierarchy leaves for code successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "leavesHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getHasData returns the has_data query parameter, or nil to include all leaves when it is not provided
+func getHasData(req *http.Request) (*bool, error) {
+	value := req.URL.Query().Get("has_data")
+	if value == "" {
+		return nil, nil
+	}
+
+	hasData, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errInvalidHasData
+	}
+
+	return &hasData, nil
+}
+
+// getOffset returns the offset query parameter, or 0 when it is not provided
+func getOffset(req *http.Request) (int, error) {
+	value := req.URL.Query().Get("offset")
+	if value == "" {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, errInvalidOffset
+	}
+
+	return offset, nil
+}
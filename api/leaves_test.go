@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLeavesHandler(t *testing.T) {
+	t.Parallel()
+
+	// the leaves below England: City of London, Westminster and North East, where North East has no data
+	leaves := []*dbmodels.HierarchyElement{
+		{ID: "E09000001", Label: "City of London", HasData: true},
+		{ID: "E09000033", Label: "Westminster", HasData: true},
+		{ID: "E12000001", Label: "North East"},
+	}
+
+	newMockDatastore := func(leavesErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyLeavesFunc: func(_ context.Context, _, _, _ string, opts datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+				if leavesErr != nil {
+					return nil, 0, leavesErr
+				}
+				page, total := opts.Page(leaves)
+				return page, total, nil
+			},
+		}
+	}
+
+	newRequest := func(target string) *http.Request {
+		r := httptest.NewRequest("GET", target, http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34", "code": "E92000001"})
+	}
+
+	getLeaves := func(store *datastoretest.StorerMock, target string) (*httptest.ResponseRecorder, models.Leaves) {
+		w := httptest.NewRecorder()
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.leavesHandler(w, newRequest(target))
+
+		var res models.Leaves
+		if w.Code == http.StatusOK {
+			So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		}
+		return w, res
+	}
+
+	codes := func(res models.Leaves) []string {
+		ids := []string{}
+		for _, item := range res.Items {
+			ids = append(ids, item.Links["self"].ID)
+		}
+		return ids
+	}
+
+	Convey("When asking for the leaves of a node, we get the first page of leaves in the whole subtree", t, func() {
+		store := newMockDatastore(nil)
+
+		w, res := getLeaves(store, "/hierarchies/hier12/dim34/E92000001/leaves")
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(store.GetHierarchyLeavesCalls(), ShouldHaveLength, 1)
+		So(store.GetHierarchyLeavesCalls()[0].Code, ShouldEqual, "E92000001")
+		So(store.GetHierarchyLeavesCalls()[0].Opts, ShouldResemble, datastore.LeafOptions{Limit: defaultLeavesLimit})
+		So(codes(res), ShouldResemble, []string{"E09000001", "E09000033", "E12000001"})
+		So(res.Count, ShouldEqual, 3)
+		So(res.TotalCount, ShouldEqual, 3)
+		So(res.Limit, ShouldEqual, defaultLeavesLimit)
+		So(res.Items[0].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34/E09000001")
+	})
+
+	Convey("When filtering and paging the leaves, the selection is made by the datastore", t, func() {
+		store := newMockDatastore(nil)
+
+		_, res := getLeaves(store, "/hierarchies/hier12/dim34/E92000001/leaves?has_data=true&offset=1&limit=1")
+
+		hasData := true
+		So(store.GetHierarchyLeavesCalls()[0].Opts, ShouldResemble, datastore.LeafOptions{HasData: &hasData, Offset: 1, Limit: 1})
+		So(codes(res), ShouldResemble, []string{"E09000033"})
+		So(res.Count, ShouldEqual, 1)
+		So(res.Offset, ShouldEqual, 1)
+		So(res.TotalCount, ShouldEqual, 2)
+	})
+
+	Convey("When the page is past the last leaf, we get no items with the total count", t, func() {
+		_, res := getLeaves(newMockDatastore(nil), "/hierarchies/hier12/dim34/E92000001/leaves?offset=5")
+
+		So(res.Items, ShouldNotBeNil)
+		So(res.Items, ShouldBeEmpty)
+		So(res.Count, ShouldEqual, 0)
+		So(res.TotalCount, ShouldEqual, 3)
+	})
+
+	Convey("When a query parameter is invalid, we get a 400 response", t, func() {
+		for _, query := range []string{"has_data=maybe", "offset=-1", "offset=first", "limit=0", "limit=1001"} {
+			store := newMockDatastore(nil)
+
+			w, _ := getLeaves(store, "/hierarchies/hier12/dim34/E92000001/leaves?"+query)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(decodeProblem(w).ErrorCode, ShouldEqual, errCodeInvalidParameter)
+			So(store.GetHierarchyLeavesCalls(), ShouldBeEmpty)
+		}
+	})
+
+	Convey("When the code does not exist, we get a 404 response", t, func() {
+		w, _ := getLeaves(newMockDatastore(driver.ErrNotFound), "/hierarchies/hier12/dim34/E92000001/leaves")
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, errCodeCodeNotFound)
+	})
+
+	Convey("When the datastore fails, we get a 500 response", t, func() {
+		w, _ := getLeaves(newMockDatastore(errors.New("graph error")), "/hierarchies/hier12/dim34/E92000001/leaves")
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
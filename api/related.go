@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// relatedLookup gets the nodes related to a code in some way
+type relatedLookup func(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error)
+
+func (api *API) ancestorsHandler(w http.ResponseWriter, req *http.Request) {
+	// ancestors run from the parent up to the root, which is linked without a code
+	api.relatedHandler(w, req, "ancestors", api.store.GetHierarchyAncestors, func(i, count int) bool { return i == count-1 })
+}
+
+func (api *API) siblingsHandler(w http.ResponseWriter, req *http.Request) {
+	// the root has no siblings, so none of them are linked as the root
+	api.relatedHandler(w, req, "siblings", api.store.GetHierarchySiblings, func(int, int) bool { return false })
+}
+
+// relatedHandler returns the nodes related to a code as found by lookup, without their own children
+// or breadcrumbs. isRoot reports whether the ith of count nodes is the root of the hierarchy.
+func (api *API) relatedHandler(w http.ResponseWriter, req *http.Request, relation string, lookup relatedLookup, isRoot func(i, count int) bool) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	code := mux.Vars(req)["code"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code, "relation": relation}
+	ctx := req.Context()
+
+	log.Info(ctx, "attempting to get related hierarchy nodes for code", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
+		return
+	}
+
+	dbRes, err := lookup(ctx, instance, dimension, code)
+	if err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "error getting related hierarchy nodes", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "code not found", err, logData)
+		writeCodeNotFound(ctx, w, req)
+		return
+	}
+
+	res := models.Elements{
+		Count: len(dbRes),
+		Items: models.MapHierarchyElements(dbRes),
+	}
+	if res.Items == nil {
+		res.Items = []*models.Element{}
+	}
+
+	var hierarchyHost, codeListHost string
+	if api.enableURLRewriting {
+		hierarchyHost = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		codeListHost = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+
+	for i, item := range res.Items {
+		withID := !isRoot(i, res.Count)
+		if api.enableURLRewriting {
+			item.AddRewrittenLinks(hierarchyHost, codeListHost, instance, dimension, codelistID, withID)
+		} else {
+			item.AddLinks(api.host.String(), instance, dimension, codelistID, withID)
+		}
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "related hierarchy nodes for code not modified", logData)
+		return
+	}
+
+	logData["count"] = res.Count
+	log.Info(ctx, "get related hierarchy nodes for code successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "relatedHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRelatedHandlers(t *testing.T) {
+	t.Parallel()
+
+	ancestors := []*dbmodels.HierarchyElement{
+		{ID: "E92000001", Label: "England", HasData: true},
+		{ID: "K02000001", Label: "United Kingdom", HasData: true},
+	}
+	siblings := []*dbmodels.HierarchyElement{
+		{ID: "E12000001", Label: "North East", HasData: true},
+	}
+
+	newMockDatastore := func(err error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyAncestorsFunc: func(_ context.Context, _, _, _ string) ([]*dbmodels.HierarchyElement, error) {
+				return ancestors, err
+			},
+			GetHierarchySiblingsFunc: func(_ context.Context, _, _, _ string) ([]*dbmodels.HierarchyElement, error) {
+				return siblings, err
+			},
+		}
+	}
+
+	newRequest := func(target string) *http.Request {
+		r := httptest.NewRequest("GET", target, http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34", "code": "E12000007"})
+	}
+
+	decode := func(w *httptest.ResponseRecorder) models.Elements {
+		var res models.Elements
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		return res
+	}
+
+	Convey("When getting the ancestors of a code, we get them up to the root with self links", t, func() {
+		store := newMockDatastore(nil)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.ancestorsHandler(w, newRequest("/hierarchies/hier12/dim34/E12000007/ancestors"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(store.GetHierarchyAncestorsCalls(), ShouldHaveLength, 1)
+		So(store.GetHierarchyAncestorsCalls()[0].Code, ShouldEqual, "E12000007")
+
+		res := decode(w)
+		So(res.Count, ShouldEqual, 2)
+		So(res.Items[0].Label, ShouldEqual, "England")
+		So(res.Items[0].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34/E92000001")
+		So(res.Items[1].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34")
+		So(res.Items[1].Links["code"].ID, ShouldEqual, "K02000001")
+	})
+
+	Convey("When getting the siblings of a code, we get them with self links", t, func() {
+		store := newMockDatastore(nil)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.siblingsHandler(w, newRequest("/hierarchies/hier12/dim34/E12000007/siblings"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("ETag"), ShouldNotBeEmpty)
+
+		res := decode(w)
+		So(res.Count, ShouldEqual, 1)
+		So(res.Items[0].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34/E12000001")
+	})
+
+	Convey("When getting the siblings of a code with URL rewriting enabled from an external host, the links are rewritten", t, func() {
+		r := newRequest("/hierarchies/hier12/dim34/E12000007/siblings")
+		addExternalHeaders(r)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, true, "")
+		api.siblingsHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/hierarchies/hier12/dim34/E12000001"`)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/code-lists/codelistID/codes/E12000001"`)
+	})
+
+	Convey("When a code has no siblings, we get an empty list", t, func() {
+		store := newMockDatastore(nil)
+		store.GetHierarchySiblingsFunc = func(_ context.Context, _, _, _ string) ([]*dbmodels.HierarchyElement, error) {
+			return nil, nil
+		}
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.siblingsHandler(w, newRequest("/hierarchies/hier12/dim34/K02000001/siblings"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"count":0,"items":[]}`)
+	})
+
+	Convey("When the code does not exist, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(driver.ErrNotFound), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.ancestorsHandler(w, newRequest("/hierarchies/hier12/dim34/E12000007/ancestors"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, errCodeCodeNotFound)
+	})
+
+	Convey("When the datastore fails, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.siblingsHandler(w, newRequest("/hierarchies/hier12/dim34/E12000007/siblings"))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// Media types the API can represent hierarchy nodes as
+const (
+	mediaTypeJSON   = "application/json"
+	mediaTypeCSV    = "text/csv"
+	mediaTypeNDJSON = "application/x-ndjson"
+)
+
+var errNotAcceptable = errors.New("none of the requested media types can be provided")
+
+// nodeMediaTypes are the representations offered for a hierarchy node, in order of preference
+var nodeMediaTypes = []string{mediaTypeJSON, mediaTypeCSV, mediaTypeNDJSON, mediaTypeTurtle, mediaTypeJSONLD}
+
+// elementCSVHeader is the header row of the CSV representation of a node's children and breadcrumbs
+var elementCSVHeader = []string{"relation", "code", "label", "order", "has_data", "no_of_children"}
+
+// elementRow is a child or breadcrumb of a node, as represented in CSV and NDJSON
+type elementRow struct {
+	Relation     string `json:"relation"`
+	Code         string `json:"code"`
+	Label        string `json:"label"`
+	Order        *int64 `json:"order,omitempty"`
+	HasData      bool   `json:"has_data"`
+	NoOfChildren int64  `json:"no_of_children"`
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// negotiate returns the offered media type the client most prefers according to the Accept header,
+// using the order of offers to break ties. An empty string is returned if no offer is acceptable.
+func negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := quality(ranges, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q value of the most specific media range matching the offer
+func quality(ranges []acceptRange, offer string) float64 {
+	offerType, _, _ := strings.Cut(offer, "/")
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.mediaType == offer:
+			s = 2
+		case r.mediaType == offerType+"/*":
+			s = 1
+		case r.mediaType == "*/*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// renderResponse returns the given node in the requested media type. The CSV and NDJSON
+// representations list the node's children followed by its breadcrumbs, one per row. The SKOS
+// representations derive concept URIs from the node's links, so these must have been added.
+func renderResponse(res *models.Response, mediaType string) ([]byte, error) {
+	switch mediaType {
+	case mediaTypeCSV:
+		return renderElementsCSV(elementRows(res))
+	case mediaTypeNDJSON:
+		return renderElementsNDJSON(elementRows(res))
+	case mediaTypeTurtle, mediaTypeJSONLD:
+		return renderSKOS(nodeResources(res), mediaType)
+	default:
+		return json.Marshal(res)
+	}
+}
+
+func elementRows(res *models.Response) []*elementRow {
+	rows := make([]*elementRow, 0, len(res.Children)+len(res.Breadcrumbs))
+	for _, child := range res.Children {
+		rows = append(rows, newElementRow("child", child))
+	}
+	for _, crumb := range res.Breadcrumbs {
+		rows = append(rows, newElementRow("breadcrumb", crumb))
+	}
+	return rows
+}
+
+func newElementRow(relation string, element *models.Element) *elementRow {
+	return &elementRow{
+		Relation:     relation,
+		Code:         element.ID,
+		Label:        element.Label,
+		Order:        element.Order,
+		HasData:      element.HasData,
+		NoOfChildren: element.NoOfChildren,
+	}
+}
+
+func renderElementsCSV(rows []*elementRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(elementCSVHeader); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.Relation,
+			row.Code,
+			row.Label,
+			formatOrder(row.Order),
+			strconv.FormatBool(row.HasData),
+			strconv.FormatInt(row.NoOfChildren, 10),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func renderElementsNDJSON(rows []*elementRow) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func formatOrder(order *int64) string {
+	if order == nil {
+		return ""
+	}
+	return strconv.FormatInt(*order, 10)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	offers := []string{mediaTypeJSON, mediaTypeCSV, mediaTypeNDJSON}
+
+	Convey("When no Accept header is sent, the first offer is chosen", t, func() {
+		So(negotiate("", offers), ShouldEqual, mediaTypeJSON)
+	})
+
+	Convey("When a single offered media type is accepted, it is chosen", t, func() {
+		So(negotiate("text/csv", offers), ShouldEqual, mediaTypeCSV)
+		So(negotiate("application/x-ndjson", offers), ShouldEqual, mediaTypeNDJSON)
+	})
+
+	Convey("When several media types are accepted, the one with the highest quality is chosen", t, func() {
+		So(negotiate("application/json;q=0.5, text/csv;q=0.9", offers), ShouldEqual, mediaTypeCSV)
+	})
+
+	Convey("When media types are accepted equally, the first offer is chosen", t, func() {
+		So(negotiate("text/csv, application/json", offers), ShouldEqual, mediaTypeJSON)
+	})
+
+	Convey("When wildcards are accepted, the most specific range decides the quality", t, func() {
+		So(negotiate("*/*", offers), ShouldEqual, mediaTypeJSON)
+		So(negotiate("text/*", offers), ShouldEqual, mediaTypeCSV)
+		So(negotiate("*/*;q=0.1, application/json;q=0", offers), ShouldEqual, mediaTypeCSV)
+	})
+
+	Convey("When nothing offered is accepted, an empty string is returned", t, func() {
+		So(negotiate("application/xml", offers), ShouldBeEmpty)
+		So(negotiate("text/csv;q=0", offers), ShouldBeEmpty)
+	})
+}
+
+func TestRenderResponse(t *testing.T) {
+	t.Parallel()
+
+	var order int64 = 2
+	res := &models.Response{
+		ID:    "node",
+		Label: "Node",
+		Children: []*models.Element{
+			{ID: "child1", Label: "Child, first", Order: &order, HasData: true, NoOfChildren: 3},
+			{ID: "child2", Label: "Child second"},
+		},
+		Breadcrumbs: []*models.Element{
+			{ID: "root", Label: "Root", NoOfChildren: 1},
+		},
+	}
+
+	Convey("A node is rendered as CSV with one row per child and breadcrumb", t, func() {
+		b, err := renderResponse(res, mediaTypeCSV)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, "relation,code,label,order,has_data,no_of_children\n"+
+			"child,child1,\"Child, first\",2,true,3\n"+
+			"child,child2,Child second,,false,0\n"+
+			"breadcrumb,root,Root,,false,1\n")
+	})
+
+	Convey("A node is rendered as NDJSON with one line per child and breadcrumb", t, func() {
+		b, err := renderResponse(res, mediaTypeNDJSON)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"relation":"child","code":"child1","label":"Child, first","order":2,"has_data":true,"no_of_children":3}`+"\n"+
+			`{"relation":"child","code":"child2","label":"Child second","has_data":false,"no_of_children":0}`+"\n"+
+			`{"relation":"breadcrumb","code":"root","label":"Root","has_data":false,"no_of_children":1}`+"\n")
+	})
+
+	Convey("A node is rendered as JSON by default", t, func() {
+		b, err := renderResponse(res, mediaTypeJSON)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldStartWith, `{"label":"Node","children":[`)
+	})
+}
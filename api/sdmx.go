@@ -0,0 +1,420 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// Media types of the SDMX representations of a hierarchy export, for statistical data exchange. The
+// export is given as a structure message holding the codes of the hierarchy's code list and an SDMX
+// 3.0 hierarchy, the successor of the SDMX 2.1 hierarchical codelist, arranging them into the tree.
+const (
+	mediaTypeSDMXML   = "application/vnd.sdmx.structure+xml"
+	mediaTypeSDMXJSON = "application/vnd.sdmx.structure+json"
+)
+
+// sdmxVersions are the versions of SDMX-ML and SDMX-JSON given as the version parameter of the
+// SDMX representations' content types
+var sdmxVersions = map[string]string{
+	mediaTypeSDMXML:   "3.0.0",
+	mediaTypeSDMXJSON: "2.0.0",
+}
+
+const (
+	sdmxAgencyID = "ONS"
+	// sdmxVersion is the version of the code lists and hierarchies, which are not versioned themselves
+	sdmxVersion  = "1.0"
+	sdmxLanguage = "en"
+	// sdmxOrderAnnotation is the type of the annotation giving the order of a code amongst its siblings
+	sdmxOrderAnnotation = "ORDER"
+
+	sdmxMessageNamespace   = "http://www.sdmx.org/resources/sdmxml/schemas/v3_0/message"
+	sdmxStructureNamespace = "http://www.sdmx.org/resources/sdmxml/schemas/v3_0/structure"
+	sdmxCommonNamespace    = "http://www.sdmx.org/resources/sdmxml/schemas/v3_0/common"
+	sdmxJSONSchema         = "https://raw.githubusercontent.com/sdmx-twg/sdmx-json/master/structure-message/tools/schemas/2.0.0/sdmx-json-structure-schema.json"
+)
+
+// sdmxCode is a node of an exported hierarchy, with the nodes below it
+type sdmxCode struct {
+	id       string
+	label    string
+	order    *int64
+	children []*sdmxCode
+}
+
+// sdmxExportEncoder encodes an export as an SDMX structure message. Codes are nested in their parents,
+// so the export is only written once every node has been walked.
+type sdmxExportEncoder struct {
+	mediaType  string
+	instanceID string
+	dimension  string
+	codelistID string
+	prepared   time.Time
+
+	roots []*sdmxCode
+	codes map[string]*sdmxCode
+}
+
+func newSDMXExportEncoder(mediaType string, linker *skosLinker) *sdmxExportEncoder {
+	return &sdmxExportEncoder{
+		mediaType:  mediaType,
+		instanceID: linker.instanceID,
+		dimension:  linker.dimension,
+		codelistID: linker.codelistID,
+		prepared:   time.Now().UTC(),
+		codes:      make(map[string]*sdmxCode),
+	}
+}
+
+// encode adds the node to the tree. The first node is its root, which for an export of a subtree
+// keeps a parent outside the export. A code visited again under another parent is added there without
+// the codes below it, which are only given under the parent it was first visited from.
+func (e *sdmxExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	code := &sdmxCode{id: node.Code, label: node.Label, order: node.Order}
+
+	if first || node.ParentCode == "" {
+		e.roots = append(e.roots, code)
+	} else {
+		parent, ok := e.codes[node.ParentCode]
+		if !ok {
+			return nil, fmt.Errorf("parent %q of code %q was not exported before it", node.ParentCode, node.Code)
+		}
+		parent.children = append(parent.children, code)
+	}
+
+	if _, ok := e.codes[node.Code]; !ok {
+		e.codes[node.Code] = code
+	}
+
+	return nil, nil
+}
+
+func (e *sdmxExportEncoder) end(int) []byte {
+	var b []byte
+	if e.mediaType == mediaTypeSDMXJSON {
+		b, _ = json.Marshal(e.jsonMessage())
+		return b
+	}
+
+	b, _ = xml.Marshal(e.xmlMessage())
+	return append([]byte(xml.Header), b...)
+}
+
+// hierarchyID returns the SDMX ID of the hierarchy, which is specific to the instance
+func (e *sdmxExportEncoder) hierarchyID() string {
+	return sdmxID(e.instanceID + "_" + e.dimension)
+}
+
+func (e *sdmxExportEncoder) hierarchyName() string {
+	return fmt.Sprintf("Hierarchy of %s for instance %s", e.dimension, e.instanceID)
+}
+
+func (e *sdmxExportEncoder) codelistURN() string {
+	return fmt.Sprintf("urn:sdmx:org.sdmx.infomodel.codelist.Codelist=%s:%s(%s)", sdmxAgencyID, sdmxID(e.codelistID), sdmxVersion)
+}
+
+func (e *sdmxExportEncoder) hierarchyURN() string {
+	return fmt.Sprintf("urn:sdmx:org.sdmx.infomodel.codelist.Hierarchy=%s:%s(%s)", sdmxAgencyID, e.hierarchyID(), sdmxVersion)
+}
+
+func (e *sdmxExportEncoder) codeURN(code string) string {
+	return fmt.Sprintf("urn:sdmx:org.sdmx.infomodel.codelist.Code=%s:%s(%s).%s", sdmxAgencyID, sdmxID(e.codelistID), sdmxVersion, sdmxID(code))
+}
+
+// codelist returns each code in the hierarchy once, parents before their children
+func (e *sdmxExportEncoder) codelist() []*sdmxCode {
+	var codes []*sdmxCode
+	seen := make(map[string]bool)
+
+	var add func(c *sdmxCode)
+	add = func(c *sdmxCode) {
+		if !seen[c.id] {
+			seen[c.id] = true
+			codes = append(codes, c)
+		}
+		for _, child := range c.children {
+			add(child)
+		}
+	}
+	for _, root := range e.roots {
+		add(root)
+	}
+
+	return codes
+}
+
+// sdmxID replaces the characters that cannot appear in an SDMX identifier with underscores
+func sdmxID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("_@$-", r):
+			return r
+		default:
+			return '_'
+		}
+	}, id)
+}
+
+// SDMX-ML 3.0 structure message. Elements are named with the prefixes of their namespaces, which are
+// declared on the root element.
+
+type sdmxStructureXML struct {
+	XMLName     xml.Name           `xml:"mes:Structure"`
+	MessageNS   string             `xml:"xmlns:mes,attr"`
+	StructureNS string             `xml:"xmlns:str,attr"`
+	CommonNS    string             `xml:"xmlns:com,attr"`
+	Header      sdmxHeaderXML      `xml:"mes:Header"`
+	Codelists   []sdmxCodelistXML  `xml:"mes:Structures>str:Codelists>str:Codelist"`
+	Hierarchies []sdmxHierarchyXML `xml:"mes:Structures>str:Hierarchies>str:Hierarchy"`
+}
+
+type sdmxHeaderXML struct {
+	ID       string        `xml:"mes:ID"`
+	Test     bool          `xml:"mes:Test"`
+	Prepared string        `xml:"mes:Prepared"`
+	Sender   sdmxSenderXML `xml:"mes:Sender"`
+}
+
+type sdmxSenderXML struct {
+	ID string `xml:"id,attr"`
+}
+
+type sdmxTextXML struct {
+	Lang  string `xml:"xml:lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+type sdmxAnnotationsXML struct {
+	Annotations []sdmxAnnotationXML `xml:"com:Annotation"`
+}
+
+type sdmxAnnotationXML struct {
+	Type string      `xml:"com:AnnotationType"`
+	Text sdmxTextXML `xml:"com:AnnotationText"`
+}
+
+type sdmxCodelistXML struct {
+	ID        string        `xml:"id,attr"`
+	URN       string        `xml:"urn,attr"`
+	AgencyID  string        `xml:"agencyID,attr"`
+	Version   string        `xml:"version,attr"`
+	IsPartial bool          `xml:"isPartial,attr"`
+	Name      sdmxTextXML   `xml:"com:Name"`
+	Codes     []sdmxCodeXML `xml:"str:Code"`
+}
+
+type sdmxCodeXML struct {
+	ID          string              `xml:"id,attr"`
+	URN         string              `xml:"urn,attr"`
+	Annotations *sdmxAnnotationsXML `xml:"com:Annotations"`
+	Name        sdmxTextXML         `xml:"com:Name"`
+}
+
+type sdmxHierarchyXML struct {
+	ID              string                    `xml:"id,attr"`
+	URN             string                    `xml:"urn,attr"`
+	AgencyID        string                    `xml:"agencyID,attr"`
+	Version         string                    `xml:"version,attr"`
+	HasFormalLevels bool                      `xml:"hasFormalLevels,attr"`
+	Name            sdmxTextXML               `xml:"com:Name"`
+	Codes           []sdmxHierarchicalCodeXML `xml:"str:HierarchicalCode"`
+}
+
+type sdmxHierarchicalCodeXML struct {
+	ID       string                    `xml:"id,attr"`
+	Code     string                    `xml:"str:Code"`
+	Children []sdmxHierarchicalCodeXML `xml:"str:HierarchicalCode"`
+}
+
+func (e *sdmxExportEncoder) xmlMessage() *sdmxStructureXML {
+	codelist := sdmxCodelistXML{
+		ID:        sdmxID(e.codelistID),
+		URN:       e.codelistURN(),
+		AgencyID:  sdmxAgencyID,
+		Version:   sdmxVersion,
+		IsPartial: true,
+		Name:      sdmxTextXML{Lang: sdmxLanguage, Value: e.codelistID},
+	}
+	for _, c := range e.codelist() {
+		code := sdmxCodeXML{
+			ID:   sdmxID(c.id),
+			URN:  e.codeURN(c.id),
+			Name: sdmxTextXML{Lang: sdmxLanguage, Value: c.label},
+		}
+		if c.order != nil {
+			code.Annotations = &sdmxAnnotationsXML{Annotations: []sdmxAnnotationXML{{
+				Type: sdmxOrderAnnotation,
+				Text: sdmxTextXML{Lang: sdmxLanguage, Value: formatOrder(c.order)},
+			}}}
+		}
+		codelist.Codes = append(codelist.Codes, code)
+	}
+
+	return &sdmxStructureXML{
+		MessageNS:   sdmxMessageNamespace,
+		StructureNS: sdmxStructureNamespace,
+		CommonNS:    sdmxCommonNamespace,
+		Header: sdmxHeaderXML{
+			ID:       e.hierarchyID(),
+			Prepared: e.prepared.Format(time.RFC3339),
+			Sender:   sdmxSenderXML{ID: sdmxAgencyID},
+		},
+		Codelists: []sdmxCodelistXML{codelist},
+		Hierarchies: []sdmxHierarchyXML{{
+			ID:       e.hierarchyID(),
+			URN:      e.hierarchyURN(),
+			AgencyID: sdmxAgencyID,
+			Version:  sdmxVersion,
+			Name:     sdmxTextXML{Lang: sdmxLanguage, Value: e.hierarchyName()},
+			Codes:    e.xmlHierarchicalCodes(e.roots),
+		}},
+	}
+}
+
+func (e *sdmxExportEncoder) xmlHierarchicalCodes(codes []*sdmxCode) []sdmxHierarchicalCodeXML {
+	hcodes := make([]sdmxHierarchicalCodeXML, 0, len(codes))
+	for _, c := range codes {
+		hcodes = append(hcodes, sdmxHierarchicalCodeXML{
+			ID:       sdmxID(c.id),
+			Code:     e.codeURN(c.id),
+			Children: e.xmlHierarchicalCodes(c.children),
+		})
+	}
+	return hcodes
+}
+
+// SDMX-JSON 2.0 structure message
+
+type sdmxStructureJSON struct {
+	Meta sdmxMetaJSON       `json:"meta"`
+	Data sdmxStructuresJSON `json:"data"`
+}
+
+type sdmxMetaJSON struct {
+	Schema           string         `json:"schema"`
+	ID               string         `json:"id"`
+	Test             bool           `json:"test"`
+	Prepared         string         `json:"prepared"`
+	ContentLanguages []string       `json:"contentLanguages"`
+	Sender           sdmxSenderJSON `json:"sender"`
+}
+
+type sdmxSenderJSON struct {
+	ID string `json:"id"`
+}
+
+type sdmxStructuresJSON struct {
+	Codelists   []*sdmxCodelistJSON  `json:"codelists"`
+	Hierarchies []*sdmxHierarchyJSON `json:"hierarchies"`
+}
+
+type sdmxAnnotationJSON struct {
+	Type  string            `json:"type"`
+	Text  string            `json:"text"`
+	Texts map[string]string `json:"texts"`
+}
+
+type sdmxCodelistJSON struct {
+	ID        string            `json:"id"`
+	URN       string            `json:"urn"`
+	AgencyID  string            `json:"agencyID"`
+	Version   string            `json:"version"`
+	IsPartial bool              `json:"isPartial"`
+	Name      string            `json:"name"`
+	Names     map[string]string `json:"names"`
+	Codes     []*sdmxCodeJSON   `json:"codes"`
+}
+
+type sdmxCodeJSON struct {
+	ID          string                `json:"id"`
+	URN         string                `json:"urn"`
+	Annotations []*sdmxAnnotationJSON `json:"annotations,omitempty"`
+	Name        string                `json:"name"`
+	Names       map[string]string     `json:"names"`
+}
+
+type sdmxHierarchyJSON struct {
+	ID                string                      `json:"id"`
+	URN               string                      `json:"urn"`
+	AgencyID          string                      `json:"agencyID"`
+	Version           string                      `json:"version"`
+	HasFormalLevels   bool                        `json:"hasFormalLevels"`
+	Name              string                      `json:"name"`
+	Names             map[string]string           `json:"names"`
+	HierarchicalCodes []*sdmxHierarchicalCodeJSON `json:"hierarchicalCodes"`
+}
+
+type sdmxHierarchicalCodeJSON struct {
+	ID                string                      `json:"id"`
+	Code              string                      `json:"code"`
+	HierarchicalCodes []*sdmxHierarchicalCodeJSON `json:"hierarchicalCodes,omitempty"`
+}
+
+func (e *sdmxExportEncoder) jsonMessage() *sdmxStructureJSON {
+	codelist := &sdmxCodelistJSON{
+		ID:        sdmxID(e.codelistID),
+		URN:       e.codelistURN(),
+		AgencyID:  sdmxAgencyID,
+		Version:   sdmxVersion,
+		IsPartial: true,
+		Name:      e.codelistID,
+		Names:     map[string]string{sdmxLanguage: e.codelistID},
+		Codes:     []*sdmxCodeJSON{},
+	}
+	for _, c := range e.codelist() {
+		code := &sdmxCodeJSON{
+			ID:    sdmxID(c.id),
+			URN:   e.codeURN(c.id),
+			Name:  c.label,
+			Names: map[string]string{sdmxLanguage: c.label},
+		}
+		if c.order != nil {
+			order := formatOrder(c.order)
+			code.Annotations = []*sdmxAnnotationJSON{{
+				Type:  sdmxOrderAnnotation,
+				Text:  order,
+				Texts: map[string]string{sdmxLanguage: order},
+			}}
+		}
+		codelist.Codes = append(codelist.Codes, code)
+	}
+
+	return &sdmxStructureJSON{
+		Meta: sdmxMetaJSON{
+			Schema:           sdmxJSONSchema,
+			ID:               e.hierarchyID(),
+			Prepared:         e.prepared.Format(time.RFC3339),
+			ContentLanguages: []string{sdmxLanguage},
+			Sender:           sdmxSenderJSON{ID: sdmxAgencyID},
+		},
+		Data: sdmxStructuresJSON{
+			Codelists: []*sdmxCodelistJSON{codelist},
+			Hierarchies: []*sdmxHierarchyJSON{{
+				ID:                e.hierarchyID(),
+				URN:               e.hierarchyURN(),
+				AgencyID:          sdmxAgencyID,
+				Version:           sdmxVersion,
+				Name:              e.hierarchyName(),
+				Names:             map[string]string{sdmxLanguage: e.hierarchyName()},
+				HierarchicalCodes: e.jsonHierarchicalCodes(e.roots),
+			}},
+		},
+	}
+}
+
+func (e *sdmxExportEncoder) jsonHierarchicalCodes(codes []*sdmxCode) []*sdmxHierarchicalCodeJSON {
+	hcodes := make([]*sdmxHierarchicalCodeJSON, 0, len(codes))
+	for _, c := range codes {
+		hcode := &sdmxHierarchicalCodeJSON{ID: sdmxID(c.id), Code: e.codeURN(c.id)}
+		if len(c.children) > 0 {
+			hcode.HierarchicalCodes = e.jsonHierarchicalCodes(c.children)
+		}
+		hcodes = append(hcodes, hcode)
+	}
+	return hcodes
+}
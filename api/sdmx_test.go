@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSDMXExportEncoder(t *testing.T) {
+	t.Parallel()
+
+	var order int64 = 2
+	nodes := []*models.ExportNode{
+		{Code: "root", Label: "Root"},
+		{Code: "a", Label: "A & B", ParentCode: "root", Order: &order},
+		{Code: "a.1", Label: "A1", ParentCode: "a"},
+	}
+
+	newEncoder := func(mediaType string) *sdmxExportEncoder {
+		e := newSDMXExportEncoder(mediaType, &skosLinker{instanceID: "inst", dimension: "dim", codelistID: "cl"})
+		e.prepared = time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+		return e
+	}
+
+	encode := func(e *sdmxExportEncoder) {
+		for i, node := range nodes {
+			b, err := e.encode(node, i == 0)
+			So(err, ShouldBeNil)
+			So(b, ShouldBeEmpty)
+		}
+	}
+
+	Convey("An export is encoded as an SDMX-ML structure message once every node has been added", t, func() {
+		e := newEncoder(mediaTypeSDMXML)
+		encode(e)
+
+		So(string(e.end(len(nodes))), ShouldEqual, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+			`<mes:Structure xmlns:mes="`+sdmxMessageNamespace+`" xmlns:str="`+sdmxStructureNamespace+`" xmlns:com="`+sdmxCommonNamespace+`">`+
+			`<mes:Header><mes:ID>inst_dim</mes:ID><mes:Test>false</mes:Test><mes:Prepared>2024-05-01T09:30:00Z</mes:Prepared><mes:Sender id="ONS"></mes:Sender></mes:Header>`+
+			`<mes:Structures><str:Codelists>`+
+			`<str:Codelist id="cl" urn="urn:sdmx:org.sdmx.infomodel.codelist.Codelist=ONS:cl(1.0)" agencyID="ONS" version="1.0" isPartial="true">`+
+			`<com:Name xml:lang="en">cl</com:Name>`+
+			`<str:Code id="root" urn="urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).root"><com:Name xml:lang="en">Root</com:Name></str:Code>`+
+			`<str:Code id="a" urn="urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a">`+
+			`<com:Annotations><com:Annotation><com:AnnotationType>ORDER</com:AnnotationType><com:AnnotationText xml:lang="en">2</com:AnnotationText></com:Annotation></com:Annotations>`+
+			`<com:Name xml:lang="en">A &amp; B</com:Name></str:Code>`+
+			`<str:Code id="a_1" urn="urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a_1"><com:Name xml:lang="en">A1</com:Name></str:Code>`+
+			`</str:Codelist></str:Codelists><str:Hierarchies>`+
+			`<str:Hierarchy id="inst_dim" urn="urn:sdmx:org.sdmx.infomodel.codelist.Hierarchy=ONS:inst_dim(1.0)" agencyID="ONS" version="1.0" hasFormalLevels="false">`+
+			`<com:Name xml:lang="en">Hierarchy of dim for instance inst</com:Name>`+
+			`<str:HierarchicalCode id="root"><str:Code>urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).root</str:Code>`+
+			`<str:HierarchicalCode id="a"><str:Code>urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a</str:Code>`+
+			`<str:HierarchicalCode id="a_1"><str:Code>urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a_1</str:Code></str:HierarchicalCode>`+
+			`</str:HierarchicalCode></str:HierarchicalCode>`+
+			`</str:Hierarchy></str:Hierarchies></mes:Structures></mes:Structure>`)
+	})
+
+	Convey("An export is encoded as an SDMX-JSON structure message once every node has been added", t, func() {
+		e := newEncoder(mediaTypeSDMXJSON)
+		encode(e)
+
+		So(string(e.end(len(nodes))), ShouldEqual, `{"meta":{"schema":"`+sdmxJSONSchema+`","id":"inst_dim","test":false,`+
+			`"prepared":"2024-05-01T09:30:00Z","contentLanguages":["en"],"sender":{"id":"ONS"}},"data":{`+
+			`"codelists":[{"id":"cl","urn":"urn:sdmx:org.sdmx.infomodel.codelist.Codelist=ONS:cl(1.0)","agencyID":"ONS","version":"1.0",`+
+			`"isPartial":true,"name":"cl","names":{"en":"cl"},"codes":[`+
+			`{"id":"root","urn":"urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).root","name":"Root","names":{"en":"Root"}},`+
+			`{"id":"a","urn":"urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a","annotations":[{"type":"ORDER","text":"2","texts":{"en":"2"}}],`+
+			`"name":"A \u0026 B","names":{"en":"A \u0026 B"}},`+
+			`{"id":"a_1","urn":"urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a_1","name":"A1","names":{"en":"A1"}}]}],`+
+			`"hierarchies":[{"id":"inst_dim","urn":"urn:sdmx:org.sdmx.infomodel.codelist.Hierarchy=ONS:inst_dim(1.0)","agencyID":"ONS","version":"1.0",`+
+			`"hasFormalLevels":false,"name":"Hierarchy of dim for instance inst","names":{"en":"Hierarchy of dim for instance inst"},"hierarchicalCodes":[`+
+			`{"id":"root","code":"urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).root","hierarchicalCodes":[`+
+			`{"id":"a","code":"urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a","hierarchicalCodes":[`+
+			`{"id":"a_1","code":"urn:sdmx:org.sdmx.infomodel.codelist.Code=ONS:cl(1.0).a_1"}]}]}]}]}}`)
+	})
+
+	Convey("A code visited again under another parent is listed once in the code list and given under both parents", t, func() {
+		e := newEncoder(mediaTypeSDMXJSON)
+		encode(e)
+		_, err := e.encode(&models.ExportNode{Code: "a.1", Label: "A1", ParentCode: "root"}, false)
+		So(err, ShouldBeNil)
+
+		msg := e.jsonMessage()
+		So(msg.Data.Codelists[0].Codes, ShouldHaveLength, 3)
+		So(msg.Data.Hierarchies[0].HierarchicalCodes[0].HierarchicalCodes, ShouldHaveLength, 2)
+		So(msg.Data.Hierarchies[0].HierarchicalCodes[0].HierarchicalCodes[1].ID, ShouldEqual, "a_1")
+	})
+
+	Convey("A node whose parent has not been added is rejected", t, func() {
+		e := newEncoder(mediaTypeSDMXML)
+		_, err := e.encode(&models.ExportNode{Code: "a", ParentCode: "root"}, false)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Characters that cannot appear in SDMX identifiers are replaced", t, func() {
+		So(sdmxID("cpih1dim1A0"), ShouldEqual, "cpih1dim1A0")
+		So(sdmxID("a.b c/d_e-f@g$h"), ShouldEqual, "a_b_c_d_e-f@g$h")
+	})
+}
+
+func TestSDMXExport(t *testing.T) {
+	t.Parallel()
+
+	walkedNodes := []*datastore.WalkedNode{
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "root", Label: "Root", NoOfChildren: 1}},
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "a", Label: "A"}, ParentID: "root", Depth: 1},
+	}
+
+	newMockDatastore := func(walkErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+				for _, node := range walkedNodes {
+					if err := fn(node); err != nil {
+						return err
+					}
+				}
+				return walkErr
+			},
+		}
+	}
+
+	newRequest := func(accept string) *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/_export", http.NoBody)
+		r.Header.Set("Accept", accept)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	Convey("When exporting a hierarchy as SDMX-ML, the content type gives the SDMX version", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest("application/vnd.sdmx.structure+xml;version=3.0.0"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/vnd.sdmx.structure+xml; version=3.0.0")
+		So(w.Body.String(), ShouldContainSubstring, `<str:Codelist id="codelistID"`)
+		So(w.Body.String(), ShouldContainSubstring, `<str:Hierarchy id="hier12_dim34"`)
+	})
+
+	Convey("When exporting a hierarchy as SDMX-JSON, the content type gives the SDMX version", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest(mediaTypeSDMXJSON))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/vnd.sdmx.structure+json; version=2.0.0")
+		So(w.Body.String(), ShouldStartWith, `{"meta":{`)
+	})
+
+	Convey("When the walk fails part way through an SDMX export, nothing has been written and an error is returned", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("walk failed")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest(mediaTypeSDMXML))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "internal_error")
+	})
+}
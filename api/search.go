@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var errMissingQuery = errors.New("the q query parameter is required")
+
+func (api *API) searchHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	query := strings.TrimSpace(req.URL.Query().Get("q"))
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "query": query}
+	ctx := req.Context()
+
+	if query == "" {
+		log.Error(ctx, "missing search query", errMissingQuery, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, errMissingQuery.Error())
+		return
+	}
+
+	limit, err := getLimit(req, defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		log.Error(ctx, "invalid limit query parameter", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidParameter, err.Error())
+		return
+	}
+	logData["limit"] = limit
+
+	log.Info(ctx, "attempting to search hierarchy", logData)
+
+	codelistID, ok := api.getCodelistID(w, req, logData)
+	if !ok {
+		return
+	}
+
+	var dbRes []*dbmodels.HierarchyResponse
+	if dbRes, err = api.store.SearchHierarchy(ctx, instance, dimension, query, limit); err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "error searching hierarchy", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "hierarchy root not found", err, logData)
+		writeHierarchyNotFound(ctx, w, req)
+		return
+	}
+
+	res := models.SearchResults{
+		Count: len(dbRes),
+		Limit: limit,
+		Items: make([]*models.Response, 0, len(dbRes)),
+	}
+
+	var hierarchyHost, codeListHost string
+	if api.enableURLRewriting {
+		hierarchyHost = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		codeListHost = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+
+	for _, dbItem := range dbRes {
+		item := models.MapHierarchyResponse(dbItem)
+		isRoot := len(item.Breadcrumbs) == 0
+		if api.enableURLRewriting {
+			item.AddLinksWithRewriting(hierarchyHost, codeListHost, instance, dimension, codelistID, isRoot)
+		} else {
+			item.AddLinks(api.host.String(), instance, dimension, codelistID, isRoot)
+		}
+		res.Items = append(res.Items, &item)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	logData["count"] = res.Count
+	log.Info(ctx, "search hierarchy successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "searchHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getLimit returns the limit query parameter, or defaultLimit when it is not provided
+func getLimit(req *http.Request, defaultLimit, maxLimit int) (int, error) {
+	value := req.URL.Query().Get("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be an integer between 1 and %d", maxLimit)
+	}
+
+	return limit, nil
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSearchHandler(t *testing.T) {
+	t.Parallel()
+
+	results := []*dbmodels.HierarchyResponse{
+		{ID: "root", Label: "United Kingdom"},
+		{
+			ID:    "E92000001",
+			Label: "England",
+			Breadcrumbs: []*dbmodels.HierarchyElement{
+				{ID: "root", Label: "United Kingdom"},
+			},
+		},
+	}
+
+	newMockDatastore := func(searchErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			SearchHierarchyFunc: func(_ context.Context, _, _, _ string, _ int) ([]*dbmodels.HierarchyResponse, error) {
+				return results, searchErr
+			},
+		}
+	}
+
+	newRequest := func(target string) *http.Request {
+		r := httptest.NewRequest("GET", target, http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	Convey("When searching a hierarchy, we get the matching nodes with breadcrumbs and self links", t, func() {
+		store := newMockDatastore(nil)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.searchHandler(w, newRequest("/hierarchies/hier12/dim34/_search?q=+Eng+&limit=5"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(store.SearchHierarchyCalls(), ShouldHaveLength, 1)
+		So(store.SearchHierarchyCalls()[0].Query, ShouldEqual, "Eng")
+		So(store.SearchHierarchyCalls()[0].Limit, ShouldEqual, 5)
+
+		var res models.SearchResults
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		So(res.Count, ShouldEqual, 2)
+		So(res.Limit, ShouldEqual, 5)
+		So(res.Items[0].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34")
+		So(res.Items[1].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34/E92000001")
+		So(res.Items[1].Breadcrumbs, ShouldHaveLength, 1)
+		So(res.Items[1].Breadcrumbs[0].Links["self"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34")
+	})
+
+	Convey("When searching a hierarchy with URL rewriting enabled from an external host, the links are rewritten", t, func() {
+		r := newRequest("/hierarchies/hier12/dim34/_search?q=eng")
+		addExternalHeaders(r)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, true, "")
+		api.searchHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/hierarchies/hier12/dim34/E92000001"`)
+		So(w.Body.String(), ShouldContainSubstring, `"https://api.example.com/v1/code-lists/codelistID/codes/E92000001"`)
+	})
+
+	Convey("When searching without a query, we get a 400 response", t, func() {
+		store := newMockDatastore(nil)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), store, hierarchyAPIURL, codeListAPIURL, false, "")
+		api.searchHandler(w, newRequest("/hierarchies/hier12/dim34/_search?q=++"))
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(decodeProblem(w).Detail, ShouldEqual, errMissingQuery.Error())
+		So(store.SearchHierarchyCalls(), ShouldBeEmpty)
+	})
+
+	Convey("When searching with an invalid limit, we get a 400 response", t, func() {
+		for _, limit := range []string{"0", "101", "ten"} {
+			w := httptest.NewRecorder()
+
+			api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+			api.searchHandler(w, newRequest("/hierarchies/hier12/dim34/_search?q=eng&limit="+limit))
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		}
+	})
+
+	Convey("When searching a hierarchy that does not exist, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(driver.ErrNotFound), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.searchHandler(w, newRequest("/hierarchies/hier12/dim34/_search?q=eng"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("When the datastore fails to search, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("graph error")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.searchHandler(w, newRequest("/hierarchies/hier12/dim34/_search?q=eng"))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
+
+func TestSearchRoute(t *testing.T) {
+	t.Parallel()
+
+	Convey("When asking for a hierarchy node whose code is search, the node is returned rather than search results", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyElementFunc: func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: code, Label: "node " + code}, 0, nil
+			},
+		}
+		r := mux.NewRouter()
+		New(r, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34/search", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"label":"node search"`)
+		So(store.SearchHierarchyCalls(), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// Media types of the SKOS representations of hierarchies, for linked data consumers. Each node is a
+// skos:Concept identified by its code link, in a skos:ConceptScheme identified by the link to the
+// root of its hierarchy. The node's own link is given as its rdfs:seeAlso.
+const (
+	mediaTypeTurtle = "text/turtle"
+	mediaTypeJSONLD = "application/ld+json"
+)
+
+const (
+	skosNamespace = "http://www.w3.org/2004/02/skos/core#"
+	rdfsNamespace = "http://www.w3.org/2000/01/rdf-schema#"
+)
+
+// turtlePrefixes begins every Turtle representation
+var turtlePrefixes = "@prefix skos: <" + skosNamespace + "> .\n@prefix rdfs: <" + rdfsNamespace + "> .\n"
+
+// jsonldContext is the context of every JSON-LD representation, which uses the same prefixes as Turtle
+var jsonldContext = map[string]string{"skos": skosNamespace, "rdfs": rdfsNamespace}
+
+// skosResource is a concept or concept scheme. Empty properties are left out of its representations.
+type skosResource struct {
+	ID            string
+	Type          string
+	PrefLabel     string
+	Notation      string
+	InScheme      string
+	TopConceptOf  string
+	HasTopConcept string
+	Broader       string
+	Narrower      []string
+	SeeAlso       string
+}
+
+// jsonldRef refers to another resource by its ID
+type jsonldRef struct {
+	ID string `json:"@id"`
+}
+
+// jsonldResource is the JSON-LD representation of a skosResource
+type jsonldResource struct {
+	ID            string      `json:"@id"`
+	Type          string      `json:"@type,omitempty"`
+	PrefLabel     string      `json:"skos:prefLabel,omitempty"`
+	Notation      string      `json:"skos:notation,omitempty"`
+	InScheme      *jsonldRef  `json:"skos:inScheme,omitempty"`
+	TopConceptOf  *jsonldRef  `json:"skos:topConceptOf,omitempty"`
+	HasTopConcept *jsonldRef  `json:"skos:hasTopConcept,omitempty"`
+	Broader       *jsonldRef  `json:"skos:broader,omitempty"`
+	Narrower      []jsonldRef `json:"skos:narrower,omitempty"`
+	SeeAlso       *jsonldRef  `json:"rdfs:seeAlso,omitempty"`
+}
+
+// jsonldDocument is a JSON-LD representation of a list of resources
+type jsonldDocument struct {
+	Context map[string]string `json:"@context"`
+	Graph   []*jsonldResource `json:"@graph"`
+}
+
+// nodeResources returns the concepts for a node, its children and its breadcrumbs, preceded by the
+// concept scheme of the hierarchy. The node's links must have been added.
+func nodeResources(res *models.Response) []*skosResource {
+	schemeID := res.Links["self"].HRef
+	rootID := res.Links["code"].HRef
+	if n := len(res.Breadcrumbs); n > 0 {
+		schemeID = res.Breadcrumbs[n-1].Links["self"].HRef
+		rootID = res.Breadcrumbs[n-1].Links["code"].HRef
+	}
+
+	scheme := &skosResource{ID: schemeID, Type: "skos:ConceptScheme", HasTopConcept: rootID}
+	resources := make([]*skosResource, 0, 2+len(res.Children)+len(res.Breadcrumbs))
+	resources = append(resources, scheme)
+
+	node := newConcept(res.Links, res.Label, res.ID, schemeID)
+	if len(res.Breadcrumbs) == 0 {
+		node.TopConceptOf = schemeID
+	} else {
+		node.Broader = res.Breadcrumbs[0].Links["code"].HRef
+	}
+	resources = append(resources, node)
+
+	for _, child := range res.Children {
+		concept := newConcept(child.Links, child.Label, child.ID, schemeID)
+		concept.Broader = node.ID
+		node.Narrower = append(node.Narrower, concept.ID)
+		resources = append(resources, concept)
+	}
+
+	// breadcrumbs run from the node's parent up to the root
+	narrower := node.ID
+	for i, crumb := range res.Breadcrumbs {
+		concept := newConcept(crumb.Links, crumb.Label, crumb.ID, schemeID)
+		concept.Narrower = []string{narrower}
+		if i == len(res.Breadcrumbs)-1 {
+			concept.TopConceptOf = schemeID
+		} else {
+			concept.Broader = res.Breadcrumbs[i+1].Links["code"].HRef
+		}
+		narrower = concept.ID
+		resources = append(resources, concept)
+	}
+
+	return resources
+}
+
+func newConcept(links map[string]models.Link, label, code, schemeID string) *skosResource {
+	return &skosResource{
+		ID:        links["code"].HRef,
+		Type:      "skos:Concept",
+		PrefLabel: label,
+		Notation:  code,
+		InScheme:  schemeID,
+		SeeAlso:   links["self"].HRef,
+	}
+}
+
+// renderSKOS returns the resources in the given SKOS media type
+func renderSKOS(resources []*skosResource, mediaType string) ([]byte, error) {
+	if mediaType == mediaTypeJSONLD {
+		doc := jsonldDocument{Context: jsonldContext, Graph: make([]*jsonldResource, 0, len(resources))}
+		for _, r := range resources {
+			doc.Graph = append(doc.Graph, r.jsonld())
+		}
+		return json.Marshal(doc)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(turtlePrefixes)
+	for _, r := range resources {
+		r.writeTurtle(&buf)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *skosResource) jsonld() *jsonldResource {
+	res := &jsonldResource{
+		ID:            r.ID,
+		Type:          r.Type,
+		PrefLabel:     r.PrefLabel,
+		Notation:      r.Notation,
+		InScheme:      newJSONLDRef(r.InScheme),
+		TopConceptOf:  newJSONLDRef(r.TopConceptOf),
+		HasTopConcept: newJSONLDRef(r.HasTopConcept),
+		Broader:       newJSONLDRef(r.Broader),
+		SeeAlso:       newJSONLDRef(r.SeeAlso),
+	}
+
+	for _, id := range r.Narrower {
+		res.Narrower = append(res.Narrower, jsonldRef{ID: id})
+	}
+
+	return res
+}
+
+func newJSONLDRef(id string) *jsonldRef {
+	if id == "" {
+		return nil
+	}
+	return &jsonldRef{ID: id}
+}
+
+// writeTurtle writes the resource as a Turtle statement, preceded by a blank line
+func (r *skosResource) writeTurtle(buf *bytes.Buffer) {
+	var predicates []string
+	if r.Type != "" {
+		predicates = append(predicates, "a "+r.Type)
+	}
+
+	literals := []struct{ predicate, value string }{
+		{"skos:prefLabel", r.PrefLabel},
+		{"skos:notation", r.Notation},
+	}
+	for _, l := range literals {
+		if l.value != "" {
+			predicates = append(predicates, l.predicate+" "+turtleString(l.value))
+		}
+	}
+
+	refs := []struct{ predicate, id string }{
+		{"skos:inScheme", r.InScheme},
+		{"skos:topConceptOf", r.TopConceptOf},
+		{"skos:hasTopConcept", r.HasTopConcept},
+		{"skos:broader", r.Broader},
+	}
+	for _, ref := range refs {
+		if ref.id != "" {
+			predicates = append(predicates, ref.predicate+" "+turtleIRI(ref.id))
+		}
+	}
+
+	if len(r.Narrower) > 0 {
+		objects := make([]string, 0, len(r.Narrower))
+		for _, id := range r.Narrower {
+			objects = append(objects, turtleIRI(id))
+		}
+		predicates = append(predicates, "skos:narrower "+strings.Join(objects, ", "))
+	}
+
+	if r.SeeAlso != "" {
+		predicates = append(predicates, "rdfs:seeAlso "+turtleIRI(r.SeeAlso))
+	}
+
+	buf.WriteString("\n")
+	buf.WriteString(turtleIRI(r.ID))
+	buf.WriteString(" ")
+	buf.WriteString(strings.Join(predicates, " ;\n    "))
+	buf.WriteString(" .\n")
+}
+
+// turtleString quotes a literal, escaping the characters that cannot appear in a Turtle string
+func turtleString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// turtleIRI encloses an IRI in angle brackets, percent-encoding the characters that cannot appear in one
+func turtleIRI(iri string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for i := 0; i < len(iri); i++ {
+		if c := iri[i]; c <= ' ' || strings.IndexByte("<>\"{}|^`\\", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(iri[i])
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// skosLinker gives the nodes of an export the same links as the API gives them elsewhere, from which
+// their concept URIs are derived
+type skosLinker struct {
+	host        string
+	codeListURL string
+	instanceID  string
+	dimension   string
+	codelistID  string
+}
+
+// links returns the self and code links of the node for code
+func (l *skosLinker) links(code string, isRoot bool) map[string]models.Link {
+	element := &models.Element{ID: code}
+	element.AddRewrittenLinks(l.host, l.codeListURL, l.instanceID, l.dimension, l.codelistID, !isRoot)
+	return element.Links
+}
+
+// exportResources returns the concept for a node in an export. The root is preceded by the concept
+// scheme, and every other node is followed by a statement that it is narrower than its parent.
+func (l *skosLinker) exportResources(node *models.ExportNode) []*skosResource {
+	isRoot := node.ParentCode == ""
+	nodeLinks := l.links(node.Code, isRoot)
+	schemeID := nodeLinks["self"].HRef
+	if !isRoot {
+		schemeID = l.links("", true)["self"].HRef
+	}
+
+	concept := newConcept(nodeLinks, node.Label, node.Code, schemeID)
+	if isRoot {
+		concept.TopConceptOf = schemeID
+		return []*skosResource{
+			{ID: schemeID, Type: "skos:ConceptScheme", HasTopConcept: concept.ID},
+			concept,
+		}
+	}
+
+	concept.Broader = l.links(node.ParentCode, false)["code"].HRef
+	return []*skosResource{
+		concept,
+		{ID: concept.Broader, Narrower: []string{concept.ID}},
+	}
+}
+
+// turtleExportEncoder encodes an export as Turtle
+type turtleExportEncoder struct {
+	linker *skosLinker
+	buf    bytes.Buffer
+}
+
+func (e *turtleExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	e.buf.Reset()
+	if first {
+		e.buf.WriteString(turtlePrefixes)
+	}
+
+	for _, r := range e.linker.exportResources(node) {
+		r.writeTurtle(&e.buf)
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+func (e *turtleExportEncoder) end(count int) []byte {
+	if count == 0 {
+		return []byte(turtlePrefixes)
+	}
+	return nil
+}
+
+// jsonldExportEncoder encodes an export as a JSON-LD document
+type jsonldExportEncoder struct {
+	linker *skosLinker
+}
+
+func (e *jsonldExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if first {
+		context, err := json.Marshal(jsonldContext)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`{"@context":`)
+		buf.Write(context)
+		buf.WriteString(`,"@graph":[`)
+	}
+
+	for i, r := range e.linker.exportResources(node) {
+		b, err := json.Marshal(r.jsonld())
+		if err != nil {
+			return nil, err
+		}
+		if !first || i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(b)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (e *jsonldExportEncoder) end(count int) []byte {
+	if count == 0 {
+		b, _ := json.Marshal(jsonldDocument{Context: jsonldContext, Graph: []*jsonldResource{}})
+		return b
+	}
+	return []byte("]}")
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNodeResources(t *testing.T) {
+	t.Parallel()
+
+	links := func(code string) map[string]models.Link {
+		return map[string]models.Link{
+			"self": {ID: code, HRef: "http://h/hierarchies/i/d/" + code},
+			"code": {ID: code, HRef: "http://c/code-lists/cl/codes/" + code},
+		}
+	}
+
+	Convey("Given the root of a hierarchy", t, func() {
+		res := &models.Response{
+			ID:    "root",
+			Label: "Root",
+			Links: map[string]models.Link{
+				"self": {ID: "root", HRef: "http://h/hierarchies/i/d"},
+				"code": {ID: "root", HRef: "http://c/code-lists/cl/codes/root"},
+			},
+			Children: []*models.Element{{ID: "a", Label: "A", Links: links("a")}},
+		}
+
+		Convey("The scheme is identified by the root's self link and the root is its top concept", func() {
+			resources := nodeResources(res)
+			So(resources, ShouldResemble, []*skosResource{
+				{ID: "http://h/hierarchies/i/d", Type: "skos:ConceptScheme", HasTopConcept: "http://c/code-lists/cl/codes/root"},
+				{
+					ID:           "http://c/code-lists/cl/codes/root",
+					Type:         "skos:Concept",
+					PrefLabel:    "Root",
+					Notation:     "root",
+					InScheme:     "http://h/hierarchies/i/d",
+					TopConceptOf: "http://h/hierarchies/i/d",
+					Narrower:     []string{"http://c/code-lists/cl/codes/a"},
+					SeeAlso:      "http://h/hierarchies/i/d",
+				},
+				{
+					ID:        "http://c/code-lists/cl/codes/a",
+					Type:      "skos:Concept",
+					PrefLabel: "A",
+					Notation:  "a",
+					InScheme:  "http://h/hierarchies/i/d",
+					Broader:   "http://c/code-lists/cl/codes/root",
+					SeeAlso:   "http://h/hierarchies/i/d/a",
+				},
+			})
+		})
+	})
+
+	Convey("Given a node with breadcrumbs", t, func() {
+		res := &models.Response{
+			ID:    "b",
+			Label: "B",
+			Links: links("b"),
+			Breadcrumbs: []*models.Element{
+				{ID: "a", Label: "A", Links: links("a")},
+				{ID: "root", Label: "Root", Links: map[string]models.Link{
+					"self": {ID: "root", HRef: "http://h/hierarchies/i/d"},
+					"code": {ID: "root", HRef: "http://c/code-lists/cl/codes/root"},
+				}},
+			},
+		}
+
+		Convey("The breadcrumbs are linked as a chain of broader concepts up to the top concept", func() {
+			resources := nodeResources(res)
+			So(resources, ShouldHaveLength, 4)
+			So(resources[0].ID, ShouldEqual, "http://h/hierarchies/i/d")
+			So(resources[0].HasTopConcept, ShouldEqual, "http://c/code-lists/cl/codes/root")
+
+			So(resources[1].ID, ShouldEqual, "http://c/code-lists/cl/codes/b")
+			So(resources[1].Broader, ShouldEqual, "http://c/code-lists/cl/codes/a")
+			So(resources[1].TopConceptOf, ShouldBeEmpty)
+
+			So(resources[2].ID, ShouldEqual, "http://c/code-lists/cl/codes/a")
+			So(resources[2].Narrower, ShouldResemble, []string{"http://c/code-lists/cl/codes/b"})
+			So(resources[2].Broader, ShouldEqual, "http://c/code-lists/cl/codes/root")
+
+			So(resources[3].ID, ShouldEqual, "http://c/code-lists/cl/codes/root")
+			So(resources[3].Narrower, ShouldResemble, []string{"http://c/code-lists/cl/codes/a"})
+			So(resources[3].Broader, ShouldBeEmpty)
+			So(resources[3].TopConceptOf, ShouldEqual, "http://h/hierarchies/i/d")
+		})
+	})
+}
+
+func TestRenderSKOS(t *testing.T) {
+	t.Parallel()
+
+	resources := []*skosResource{
+		{ID: "http://h/scheme", Type: "skos:ConceptScheme", HasTopConcept: "http://c/root"},
+		{
+			ID:        "http://c/root",
+			Type:      "skos:Concept",
+			PrefLabel: `Root "all"`,
+			Notation:  "root",
+			InScheme:  "http://h/scheme",
+			Narrower:  []string{"http://c/a", "http://c/b"},
+		},
+	}
+
+	Convey("Resources are rendered as Turtle statements after the prefixes", t, func() {
+		b, err := renderSKOS(resources, mediaTypeTurtle)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, turtlePrefixes+"\n"+
+			"<http://h/scheme> a skos:ConceptScheme ;\n"+
+			"    skos:hasTopConcept <http://c/root> .\n"+
+			"\n"+
+			"<http://c/root> a skos:Concept ;\n"+
+			"    skos:prefLabel \"Root \\\"all\\\"\" ;\n"+
+			"    skos:notation \"root\" ;\n"+
+			"    skos:inScheme <http://h/scheme> ;\n"+
+			"    skos:narrower <http://c/a>, <http://c/b> .\n")
+	})
+
+	Convey("Resources are rendered as the graph of a JSON-LD document", t, func() {
+		b, err := renderSKOS(resources, mediaTypeJSONLD)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"@context":{"rdfs":"`+rdfsNamespace+`","skos":"`+skosNamespace+`"},"@graph":[`+
+			`{"@id":"http://h/scheme","@type":"skos:ConceptScheme","skos:hasTopConcept":{"@id":"http://c/root"}},`+
+			`{"@id":"http://c/root","@type":"skos:Concept","skos:prefLabel":"Root \"all\"","skos:notation":"root",`+
+			`"skos:inScheme":{"@id":"http://h/scheme"},"skos:narrower":[{"@id":"http://c/a"},{"@id":"http://c/b"}]}]}`)
+	})
+
+	Convey("Characters that cannot appear in Turtle literals are escaped", t, func() {
+		So(turtleString("a\\b\n\r\tc"), ShouldEqual, `"a\\b\n\r\tc"`)
+	})
+
+	Convey("Characters that cannot appear in Turtle IRIs are percent-encoded", t, func() {
+		So(turtleIRI("http://c/codes/a b<c>{d}"), ShouldEqual, "<http://c/codes/a%20b%3Cc%3E%7Bd%7D>")
+		So(turtleIRI("http://c/codes/é"), ShouldEqual, "<http://c/codes/é>")
+	})
+}
+
+func TestSKOSExport(t *testing.T) {
+	t.Parallel()
+
+	newMockDatastore := func(walkedNodes []*datastore.WalkedNode) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+				for _, node := range walkedNodes {
+					if err := fn(node); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+	}
+
+	walkedNodes := []*datastore.WalkedNode{
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "root", Label: "Root", NoOfChildren: 1}},
+		{HierarchyElement: dbmodels.HierarchyElement{ID: "a", Label: "A"}, ParentID: "root", Depth: 1},
+	}
+
+	newRequest := func(accept string) *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/hier12/dim34/_export", http.NoBody)
+		r.Header.Set("Accept", accept)
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	Convey("When exporting a hierarchy as Turtle, every node is streamed as a concept", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(walkedNodes), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.exportHandler(w, newRequest(mediaTypeTurtle))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, mediaTypeTurtle)
+		So(w.Body.String(), ShouldEqual, turtlePrefixes+"\n"+
+			"<http://localhost:22600/hierarchies/hier12/dim34> a skos:ConceptScheme ;\n"+
+			"    skos:hasTopConcept </code-lists/codelistID/codes/root> .\n"+
+			"\n"+
+			"</code-lists/codelistID/codes/root> a skos:Concept ;\n"+
+			"    skos:prefLabel \"Root\" ;\n"+
+			"    skos:notation \"root\" ;\n"+
+			"    skos:inScheme <http://localhost:22600/hierarchies/hier12/dim34> ;\n"+
+			"    skos:topConceptOf <http://localhost:22600/hierarchies/hier12/dim34> ;\n"+
+			"    rdfs:seeAlso <http://localhost:22600/hierarchies/hier12/dim34> .\n"+
+			"\n"+
+			"</code-lists/codelistID/codes/a> a skos:Concept ;\n"+
+			"    skos:prefLabel \"A\" ;\n"+
+			"    skos:notation \"a\" ;\n"+
+			"    skos:inScheme <http://localhost:22600/hierarchies/hier12/dim34> ;\n"+
+			"    skos:broader </code-lists/codelistID/codes/root> ;\n"+
+			"    rdfs:seeAlso <http://localhost:22600/hierarchies/hier12/dim34/a> .\n"+
+			"\n"+
+			"</code-lists/codelistID/codes/root> skos:narrower </code-lists/codelistID/codes/a> .\n")
+	})
+
+	Convey("When exporting a hierarchy as JSON-LD with URL rewriting enabled, the concepts are identified by external links", t, func() {
+		r := newRequest(mediaTypeJSONLD)
+		addExternalHeaders(r)
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(walkedNodes), hierarchyAPIURL, codeListAPIURL, true, "")
+		api.exportHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, mediaTypeJSONLD)
+
+		var doc jsonldDocument
+		So(json.Unmarshal(w.Body.Bytes(), &doc), ShouldBeNil)
+		So(doc.Context, ShouldResemble, jsonldContext)
+		So(doc.Graph, ShouldHaveLength, 4)
+		So(doc.Graph[0].ID, ShouldEqual, "https://api.example.com/v1/hierarchies/hier12/dim34")
+		So(doc.Graph[1].ID, ShouldEqual, "https://api.example.com/v1/code-lists/codelistID/codes/root")
+		So(doc.Graph[2].ID, ShouldEqual, "https://api.example.com/v1/code-lists/codelistID/codes/a")
+		So(doc.Graph[2].Broader, ShouldResemble, &jsonldRef{ID: "https://api.example.com/v1/code-lists/codelistID/codes/root"})
+		So(doc.Graph[3].ID, ShouldEqual, "https://api.example.com/v1/code-lists/codelistID/codes/root")
+		So(doc.Graph[3].Narrower, ShouldResemble, []jsonldRef{{ID: "https://api.example.com/v1/code-lists/codelistID/codes/a"}})
+	})
+
+	Convey("When exporting an empty hierarchy, an empty document is returned", t, func() {
+		Convey("As Turtle", func() {
+			w := httptest.NewRecorder()
+
+			api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+			api.exportHandler(w, newRequest(mediaTypeTurtle))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, turtlePrefixes)
+		})
+
+		Convey("As JSON-LD", func() {
+			w := httptest.NewRecorder()
+
+			api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+			api.exportHandler(w, newRequest(mediaTypeJSONLD))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, `{"@context":{"rdfs":"`+rdfsNamespace+`","skos":"`+skosNamespace+`"},"@graph":[]}`)
+		})
+	})
+}
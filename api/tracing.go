@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/ONSdigital/dp-hierarchy-api/api")
+
+// traced wraps a handler in a server span named after its route. The span continues any trace
+// propagated by the caller, and records the hierarchy requested and the response's status and size.
+func traced(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+		vars := mux.Vars(req)
+		ctx, span := tracer.Start(ctx, req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", req.URL.Path),
+				attribute.String("instance_id", vars["instance"]),
+				attribute.String("dimension", vars["dimension"]),
+			))
+		defer span.End()
+
+		if code, ok := vars["code"]; ok {
+			span.SetAttributes(attribute.String("code", code))
+		}
+
+		rec := &tracedResponse{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, req.WithContext(ctx))
+
+		span.SetAttributes(
+			attribute.Int("http.response.status_code", rec.status),
+			attribute.Int("http.response.body.size", rec.size),
+		)
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	}
+}
+
+// tracedResponse captures the status code and size of a response
+type tracedResponse struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (r *tracedResponse) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *tracedResponse) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer, so that streamed
+// responses can still be flushed
+func (r *tracedResponse) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
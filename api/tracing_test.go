@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spans     *tracetest.SpanRecorder
+	spansOnce sync.Once
+)
+
+// recordSpans installs a global tracer provider that records spans in memory. The package's tracer
+// is bound to the first provider installed, so it is only installed once for all tests.
+func recordSpans() *tracetest.SpanRecorder {
+	spansOnce.Do(func() {
+		spans = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spans
+}
+
+func TestTraced(t *testing.T) {
+	t.Parallel()
+
+	recorder := recordSpans()
+
+	findSpan := func(path string) sdktrace.ReadOnlySpan {
+		for _, span := range recorder.Ended() {
+			for _, attr := range span.Attributes() {
+				if attr.Key == "url.path" && attr.Value.AsString() == path {
+					return span
+				}
+			}
+		}
+		return nil
+	}
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range span.Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+		return attrs
+	}
+
+	Convey("When a traced handler is called, a server span describing the request is recorded", t, func() {
+		handler := traced("code_url", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("missing"))
+		})
+
+		r := httptest.NewRequest("GET", "/hierarchies/traced1/dim34/codeN", http.NoBody)
+		r = mux.SetURLVars(r, map[string]string{"instance": "traced1", "dimension": "dim34", "code": "codeN"})
+		handler(httptest.NewRecorder(), r)
+
+		span := findSpan("/hierarchies/traced1/dim34/codeN")
+		So(span, ShouldNotBeNil)
+		So(span.Name(), ShouldEqual, "GET code_url")
+
+		attrs := attributes(span)
+		So(attrs["instance_id"].AsString(), ShouldEqual, "traced1")
+		So(attrs["dimension"].AsString(), ShouldEqual, "dim34")
+		So(attrs["code"].AsString(), ShouldEqual, "codeN")
+		So(attrs["http.response.status_code"].AsInt64(), ShouldEqual, http.StatusNotFound)
+		So(attrs["http.response.body.size"].AsInt64(), ShouldEqual, len("missing"))
+	})
+
+	Convey("When the request carries a trace context, the span continues that trace", t, func() {
+		handler := traced("hierarchy_url", func(w http.ResponseWriter, _ *http.Request) {})
+
+		r := httptest.NewRequest("GET", "/hierarchies/traced2/dim34", http.NoBody)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		handler(httptest.NewRecorder(), r)
+
+		span := findSpan("/hierarchies/traced2/dim34")
+		So(span, ShouldNotBeNil)
+		So(span.SpanContext().TraceID().String(), ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+		So(span.Parent().SpanID().String(), ShouldEqual, "00f067aa0ba902b7")
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-hierarchy-api/validate"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// validateHandler checks that the hierarchy is a well formed tree. The report is returned whether or
+// not any problems are found, so callers gate on its valid flag rather than the status code.
+func (api *API) validateHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension}
+	ctx := req.Context()
+
+	log.Info(ctx, "attempting to validate hierarchy", logData)
+
+	if _, ok := api.getCodelistID(w, req, logData); !ok {
+		return
+	}
+
+	report, err := validate.Validate(ctx, api.store, instance, dimension)
+	if err != nil {
+		log.Error(ctx, "error validating hierarchy", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	res := mapValidationReport(report)
+	res.InstanceID = instance
+	res.Dimension = dimension
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	if api.writeNotModified(w, req, b) {
+		log.Info(ctx, "hierarchy validation not modified", logData)
+		return
+	}
+
+	logData["valid"] = res.Valid
+	logData["problems"] = res.Count
+	log.Info(ctx, "validate hierarchy successful", logData)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "validateHandler endpoint: error writing bytes to response", err, logData)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func mapValidationReport(report *validate.Report) *models.ValidationReport {
+	res := &models.ValidationReport{
+		Valid:     report.IsValid(),
+		NoOfNodes: report.NoOfNodes,
+		Count:     len(report.Problems),
+		Problems:  make([]*models.ValidationProblem, 0, len(report.Problems)),
+	}
+
+	for _, problem := range report.Problems {
+		res.Problems = append(res.Problems, &models.ValidationProblem{
+			Type:       string(problem.Type),
+			Code:       problem.Code,
+			ParentCode: problem.ParentCode,
+			Detail:     problem.Detail,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateHandler(t *testing.T) {
+	t.Parallel()
+
+	walked := func(code, parent string, depth int, noOfChildren int64) *datastore.WalkedNode {
+		return &datastore.WalkedNode{HierarchyElement: dbmodels.HierarchyElement{ID: code, NoOfChildren: noOfChildren}, ParentID: parent, Depth: depth}
+	}
+
+	hierarchies := map[string][]*datastore.WalkedNode{
+		"valid": {
+			walked("root", "", 0, 1),
+			walked("a", "root", 1, 0),
+		},
+		"invalid": {
+			walked("root", "", 0, 2),
+			walked("a", "root", 1, 1),
+			walked("root", "a", 2, 2),
+		},
+	}
+
+	newMockDatastore := func(walkErr error) *datastoretest.StorerMock {
+		return &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, instanceID, _ string) (string, error) {
+				if _, ok := hierarchies[instanceID]; !ok {
+					return "", driver.ErrNotFound
+				}
+				return "codelistID", nil
+			},
+			ListDuplicateCodesFunc: func(_ context.Context, _, _ string) ([]string, error) {
+				return nil, nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, instanceID, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+				if walkErr != nil {
+					return walkErr
+				}
+				for _, node := range hierarchies[instanceID] {
+					if err := fn(node); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			ListHierarchyNodesFunc: func(_ context.Context, instanceID, _ string, fn datastore.ListFunc) error {
+				for _, node := range hierarchies[instanceID] {
+					if err := fn(&datastore.ListedNode{HierarchyElement: node.HierarchyElement, ParentIDs: []string{node.ParentID}}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+	}
+
+	newRequest := func(instance string) *http.Request {
+		r := httptest.NewRequest("GET", "/hierarchies/"+instance+"/geography/_validate", http.NoBody)
+		return mux.SetURLVars(r, map[string]string{"instance": instance, "dimension": "geography"})
+	}
+
+	Convey("When validating a well formed hierarchy, we get a valid report without problems", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.validateHandler(w, newRequest("valid"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("ETag"), ShouldNotBeEmpty)
+		So(w.Body.String(), ShouldEqual, `{"instance_id":"valid","dimension":"geography","valid":true,"no_of_nodes":2,"count":0,"problems":[]}`)
+	})
+
+	Convey("When validating a hierarchy with problems, each is reported", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.validateHandler(w, newRequest("invalid"))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		var res models.ValidationReport
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		So(res.Valid, ShouldBeFalse)
+		So(res.NoOfNodes, ShouldEqual, 2)
+		So(res.Count, ShouldEqual, 2)
+		So(res.Problems[0], ShouldResemble, &models.ValidationProblem{
+			Type: "cycle", Code: "root", ParentCode: "a", Detail: `code "root" is below itself: root > a > root`,
+		})
+		So(res.Problems[1].Type, ShouldEqual, "child_count_mismatch")
+		So(res.Problems[1].Code, ShouldEqual, "root")
+	})
+
+	Convey("When the hierarchy does not exist, we get a 404 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.validateHandler(w, newRequest("missing"))
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "hierarchy_not_found")
+	})
+
+	Convey("When the hierarchy cannot be walked, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		api := New(mux.NewRouter(), newMockDatastore(errors.New("walk failed")), hierarchyAPIURL, codeListAPIURL, false, "")
+		api.validateHandler(w, newRequest("valid"))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "internal_error")
+	})
+
+	Convey("Given the API's router, the validate route is matched before the code route", t, func() {
+		router := mux.NewRouter()
+		New(router, newMockDatastore(nil), hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/valid/geography/_validate", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"valid":true`)
+	})
+}
+
+func TestValidateRoute(t *testing.T) {
+	t.Parallel()
+
+	Convey("When asking for a hierarchy node whose code is validate, the node is returned rather than a report", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyElementFunc: func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: code, Label: "node " + code}, 0, nil
+			},
+		}
+		r := mux.NewRouter()
+		New(r, store, hierarchyAPIURL, codeListAPIURL, false, "")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34/validate", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"label":"node validate"`)
+		So(store.WalkHierarchyCalls(), ShouldBeEmpty)
+	})
+}
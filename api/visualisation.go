@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// Media types of the graph representations of an export, for rendering a hierarchy in Graphviz or
+// Gephi. Nodes are labelled with their labels and coloured by whether the instance has data for them.
+const (
+	mediaTypeDOT     = "text/vnd.graphviz"
+	mediaTypeGraphML = "application/graphml+xml"
+)
+
+// nodeColour is the fill colour of a node in a graph representation
+type nodeColour struct {
+	hex     string
+	r, g, b int
+}
+
+var (
+	hasDataColour = nodeColour{hex: "#9ecae1", r: 158, g: 202, b: 225}
+	noDataColour  = nodeColour{hex: "#f0f0f0", r: 240, g: 240, b: 240}
+)
+
+func colourOf(node *models.ExportNode) nodeColour {
+	if node.HasData {
+		return hasDataColour
+	}
+	return noDataColour
+}
+
+// graphName returns the name of the graph of an export
+func graphName(linker *skosLinker) string {
+	return linker.instanceID + "/" + linker.dimension
+}
+
+// dotExportEncoder encodes an export as a Graphviz DOT digraph. Nodes without data are drawn dashed.
+type dotExportEncoder struct {
+	linker *skosLinker
+	buf    bytes.Buffer
+}
+
+func (e *dotExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	e.buf.Reset()
+	if first {
+		e.writeHeader()
+	}
+
+	style := "filled"
+	if !node.HasData {
+		style = "filled,dashed"
+	}
+	e.buf.WriteString("\t" + dotID(node.Code) + " [label=" + dotID(node.Label) +
+		", style=" + dotID(style) + ", fillcolor=" + dotID(colourOf(node).hex) + "];\n")
+
+	// the root of an export of a subtree keeps its parent, which is not in the export
+	if !first && node.ParentCode != "" {
+		e.buf.WriteString("\t" + dotID(node.ParentCode) + " -> " + dotID(node.Code) + ";\n")
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+func (e *dotExportEncoder) end(count int) []byte {
+	if count == 0 {
+		e.buf.Reset()
+		e.writeHeader()
+		e.buf.WriteString("}\n")
+		return e.buf.Bytes()
+	}
+	return []byte("}\n")
+}
+
+func (e *dotExportEncoder) writeHeader() {
+	e.buf.WriteString("digraph " + dotID(graphName(e.linker)) + " {\n")
+	e.buf.WriteString("\tnode [shape=box];\n")
+}
+
+// dotEscaper escapes the characters that would end or change a quoted DOT identifier
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+
+// dotID quotes a DOT identifier
+func dotID(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// graphMLHeader declares the attributes of the nodes of a GraphML export. Gephi colours nodes by
+// their r, g and b attributes.
+const graphMLHeader = xml.Header + `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="has_data" for="node" attr.name="has_data" attr.type="boolean"/>
+  <key id="order" for="node" attr.name="order" attr.type="long"/>
+  <key id="no_of_children" for="node" attr.name="no_of_children" attr.type="long"/>
+  <key id="r" for="node" attr.name="r" attr.type="int"/>
+  <key id="g" for="node" attr.name="g" attr.type="int"/>
+  <key id="b" for="node" attr.name="b" attr.type="int"/>
+`
+
+// graphMLExportEncoder encodes an export as a GraphML graph. Nodes are identified by their codes, so
+// a node visited again under another parent only adds an edge.
+type graphMLExportEncoder struct {
+	linker *skosLinker
+	buf    bytes.Buffer
+	seen   map[string]bool
+}
+
+func (e *graphMLExportEncoder) encode(node *models.ExportNode, first bool) ([]byte, error) {
+	e.buf.Reset()
+	if first {
+		e.writeHeader()
+		e.seen = make(map[string]bool)
+	}
+
+	if !e.seen[node.Code] {
+		e.seen[node.Code] = true
+
+		colour := colourOf(node)
+		e.buf.WriteString(`    <node id="` + xmlEscape(node.Code) + `">` + "\n")
+		e.writeData("label", node.Label)
+		e.writeData("has_data", strconv.FormatBool(node.HasData))
+		if node.Order != nil {
+			e.writeData("order", formatOrder(node.Order))
+		}
+		e.writeData("no_of_children", strconv.FormatInt(node.NoOfChildren, 10))
+		e.writeData("r", strconv.Itoa(colour.r))
+		e.writeData("g", strconv.Itoa(colour.g))
+		e.writeData("b", strconv.Itoa(colour.b))
+		e.buf.WriteString("    </node>\n")
+	}
+
+	// the root of an export of a subtree keeps its parent, which is not in the export
+	if !first && node.ParentCode != "" {
+		e.buf.WriteString(`    <edge source="` + xmlEscape(node.ParentCode) + `" target="` + xmlEscape(node.Code) + `"/>` + "\n")
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+func (e *graphMLExportEncoder) end(count int) []byte {
+	if count == 0 {
+		e.buf.Reset()
+		e.writeHeader()
+		e.buf.WriteString("  </graph>\n</graphml>\n")
+		return e.buf.Bytes()
+	}
+	return []byte("  </graph>\n</graphml>\n")
+}
+
+func (e *graphMLExportEncoder) writeHeader() {
+	e.buf.WriteString(graphMLHeader)
+	e.buf.WriteString(`  <graph id="` + xmlEscape(graphName(e.linker)) + `" edgedefault="directed">` + "\n")
+}
+
+func (e *graphMLExportEncoder) writeData(key, value string) {
+	e.buf.WriteString(`      <data key="` + key + `">` + xmlEscape(value) + "</data>\n")
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	// EscapeText only fails if the writer does
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
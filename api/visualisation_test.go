@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGraphExportEncoders(t *testing.T) {
+	t.Parallel()
+
+	var order int64 = 1
+	linker := &skosLinker{instanceID: "inst", dimension: "dim"}
+	nodes := []*models.ExportNode{
+		{Code: "root", Label: `Root "all"`, HasData: true, NoOfChildren: 1},
+		{Code: "a", Label: "A & B", ParentCode: "root", Order: &order},
+		{Code: "a", Label: "A & B", ParentCode: "other"},
+	}
+
+	encode := func(e exportEncoder) string {
+		var out string
+		for i, node := range nodes {
+			b, err := e.encode(node, i == 0)
+			So(err, ShouldBeNil)
+			out += string(b)
+		}
+		return out + string(e.end(len(nodes)))
+	}
+
+	Convey("An export is encoded as a DOT digraph with an edge from each node's parent", t, func() {
+		So(encode(&dotExportEncoder{linker: linker}), ShouldEqual, `digraph "inst/dim" {`+"\n"+
+			"\tnode [shape=box];\n"+
+			`	"root" [label="Root \"all\"", style="filled", fillcolor="#9ecae1"];`+"\n"+
+			`	"a" [label="A & B", style="filled,dashed", fillcolor="#f0f0f0"];`+"\n"+
+			`	"root" -> "a";`+"\n"+
+			`	"a" [label="A & B", style="filled,dashed", fillcolor="#f0f0f0"];`+"\n"+
+			`	"other" -> "a";`+"\n"+
+			"}\n")
+	})
+
+	Convey("An export is encoded as a GraphML graph, declaring each node once", t, func() {
+		So(encode(&graphMLExportEncoder{linker: linker}), ShouldEqual, graphMLHeader+
+			`  <graph id="inst/dim" edgedefault="directed">`+"\n"+
+			`    <node id="root">`+"\n"+
+			`      <data key="label">Root &#34;all&#34;</data>`+"\n"+
+			`      <data key="has_data">true</data>`+"\n"+
+			`      <data key="no_of_children">1</data>`+"\n"+
+			`      <data key="r">158</data>`+"\n"+
+			`      <data key="g">202</data>`+"\n"+
+			`      <data key="b">225</data>`+"\n"+
+			`    </node>`+"\n"+
+			`    <node id="a">`+"\n"+
+			`      <data key="label">A &amp; B</data>`+"\n"+
+			`      <data key="has_data">false</data>`+"\n"+
+			`      <data key="order">1</data>`+"\n"+
+			`      <data key="no_of_children">0</data>`+"\n"+
+			`      <data key="r">240</data>`+"\n"+
+			`      <data key="g">240</data>`+"\n"+
+			`      <data key="b">240</data>`+"\n"+
+			`    </node>`+"\n"+
+			`    <edge source="root" target="a"/>`+"\n"+
+			`    <edge source="other" target="a"/>`+"\n"+
+			"  </graph>\n</graphml>\n")
+	})
+
+	Convey("The root of an export of a subtree has no edge to its parent", t, func() {
+		e := &dotExportEncoder{linker: linker}
+		b, err := e.encode(&models.ExportNode{Code: "a", Label: "A", ParentCode: "root"}, true)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldNotContainSubstring, "->")
+	})
+
+	Convey("An empty export is encoded as an empty graph", t, func() {
+		So(string((&dotExportEncoder{linker: linker}).end(0)), ShouldEqual, "digraph \"inst/dim\" {\n\tnode [shape=box];\n}\n")
+		So(string((&graphMLExportEncoder{linker: linker}).end(0)), ShouldEqual, graphMLHeader+
+			"  <graph id=\"inst/dim\" edgedefault=\"directed\">\n  </graph>\n</graphml>\n")
+	})
+
+	Convey("DOT identifiers are quoted with their quotes, backslashes and newlines escaped", t, func() {
+		So(dotID("a\"b\\c\nd"), ShouldEqual, `"a\"b\\c\nd"`)
+	})
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// maxHierarchyBodyBytes is the largest hierarchy request body accepted. The largest geography
+// hierarchies have tens of thousands of nodes.
+const maxHierarchyBodyBytes = 32 << 20
+
+var (
+	errWritesDisabled       = errors.New("hierarchies cannot be written to this service")
+	errUnauthorised         = errors.New("a valid bearer token is required to write hierarchies")
+	errUnsupportedMediaType = errors.New("request body must be application/json or text/csv")
+	errNoCodelistID         = errors.New("code_list_id must be provided")
+	errNullNode             = errors.New("nodes must not be null")
+)
+
+// EnableWrites allows hierarchies to be created and replaced through the API, by requests
+// authorised with the given bearer token
+func (api *API) EnableWrites(writer datastore.Writer, token string) {
+	api.writer = writer
+	api.writeToken = token
+}
+
+// putHierarchyHandler creates or replaces the hierarchy of a dimension of an instance with the
+// complete tree in the request body
+func (api *API) putHierarchyHandler(w http.ResponseWriter, req *http.Request) {
+	instance := mux.Vars(req)["instance"]
+	dimension := mux.Vars(req)["dimension"]
+	logData := log.Data{"instance_id": instance, "dimension": dimension}
+	ctx := req.Context()
+
+	if api.writer == nil {
+		log.Error(ctx, "hierarchy write requested but writes are disabled", errWritesDisabled, logData)
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(ctx, w, req, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, errWritesDisabled.Error())
+		return
+	}
+
+	if !api.authorisedToWrite(req) {
+		log.Error(ctx, "unauthorised hierarchy write", errUnauthorised, logData)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(ctx, w, req, http.StatusUnauthorized, errCodeUnauthorised, errUnauthorised.Error())
+		return
+	}
+
+	hierarchy, err := readHierarchy(w, req)
+	if errors.Is(err, errUnsupportedMediaType) {
+		logData["content_type"] = req.Header.Get("Content-Type")
+		log.Error(ctx, "unsupported hierarchy request body", err, logData)
+		writeError(ctx, w, req, http.StatusUnsupportedMediaType, errCodeUnsupportedMediaType, err.Error())
+		return
+	}
+	if err != nil {
+		log.Error(ctx, "invalid hierarchy request", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidBody, err.Error())
+		return
+	}
+	logData["code_list_id"] = hierarchy.CodelistID
+	logData["num_nodes"] = len(hierarchy.Nodes)
+
+	log.Info(ctx, "attempting to put hierarchy", logData)
+
+	created, err := api.writer.PutHierarchy(ctx, instance, dimension, hierarchy)
+	if errors.Is(err, datastore.ErrInvalidHierarchy) {
+		log.Error(ctx, "invalid hierarchy", err, logData)
+		writeError(ctx, w, req, http.StatusBadRequest, errCodeInvalidHierarchy, err.Error())
+		return
+	}
+	if err != nil {
+		log.Error(ctx, "error putting hierarchy", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	res := &models.HierarchySummary{
+		Dimension:  dimension,
+		CodelistID: hierarchy.CodelistID,
+		NoOfNodes:  int64(len(hierarchy.Nodes)),
+		Depth:      treeDepth(hierarchy.Nodes),
+	}
+	hierarchyHost, codeListHost := api.host.String(), models.CodelistURL
+	if api.enableURLRewriting {
+		hierarchyHost = links.FromHeadersOrDefault(&req.Header, req, api.host).URL.String()
+		codeListHost = links.FromHeadersOrDefault(&req.Header, req, api.codeListAPIURL).URL.String()
+	}
+	res.AddLinks(hierarchyHost, codeListHost, instance)
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(ctx, "error marshalling json response", err, logData)
+		writeInternalError(ctx, w, req)
+		return
+	}
+
+	logData["created"] = created
+	log.Info(ctx, "put hierarchy successful", logData)
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		w.Header().Set("Location", res.Links["root"].HRef)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "putHierarchyHandler endpoint: error writing bytes to response", err, logData)
+	}
+}
+
+// authorisedToWrite reports whether the request has the bearer token writes are authorised with
+func (api *API) authorisedToWrite(req *http.Request) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(api.writeToken)) == 1
+}
+
+// readHierarchy returns the hierarchy in a request body. A JSON body gives its code list in the body,
+// and a CSV body gives it in the code_list_id query parameter.
+func readHierarchy(w http.ResponseWriter, req *http.Request) (*datastore.Hierarchy, error) {
+	mediaType := mediaTypeJSON
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, errUnsupportedMediaType
+		}
+	}
+
+	body := http.MaxBytesReader(w, req.Body, maxHierarchyBodyBytes)
+	hierarchy := &datastore.Hierarchy{}
+
+	switch mediaType {
+	case mediaTypeJSON:
+		var request models.HierarchyRequest
+		if err := json.NewDecoder(body).Decode(&request); err != nil {
+			return nil, fmt.Errorf("failed to parse request body: %w", err)
+		}
+
+		hierarchy.CodelistID = request.CodelistID
+		hierarchy.Nodes = make([]datastore.Node, 0, len(request.Nodes))
+		for _, node := range request.Nodes {
+			if node == nil {
+				return nil, errNullNode
+			}
+			hierarchy.Nodes = append(hierarchy.Nodes, datastore.Node(*node))
+		}
+	case mediaTypeCSV:
+		nodes, err := datastore.ReadNodesCSV(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse request body: %w", err)
+		}
+
+		hierarchy.CodelistID = req.URL.Query().Get("code_list_id")
+		hierarchy.Nodes = nodes
+	default:
+		return nil, errUnsupportedMediaType
+	}
+
+	if hierarchy.CodelistID == "" {
+		return nil, errNoCodelistID
+	}
+
+	return hierarchy, nil
+}
+
+// treeDepth returns the number of levels below the root of a valid hierarchy
+func treeDepth(nodes []datastore.Node) int {
+	parents := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		parents[node.Code] = node.Parent
+	}
+
+	depths := make(map[string]int, len(nodes))
+	var depthOf func(code string) int
+	depthOf = func(code string) int {
+		if parents[code] == "" {
+			return 0
+		}
+		if depth, ok := depths[code]; ok {
+			return depth
+		}
+		depths[code] = 1 + depthOf(parents[code])
+		return depths[code]
+	}
+
+	deepest := 0
+	for _, node := range nodes {
+		deepest = max(deepest, depthOf(node.Code))
+	}
+	return deepest
+}
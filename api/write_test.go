@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const writeToken = "write-token"
+
+func TestPutHierarchyHandler(t *testing.T) {
+	t.Parallel()
+
+	newMockWriter := func(created bool, err error) *datastoretest.WriterMock {
+		return &datastoretest.WriterMock{
+			PutHierarchyFunc: func(_ context.Context, _, _ string, _ *datastore.Hierarchy) (bool, error) {
+				return created, err
+			},
+		}
+	}
+
+	newAPI := func(writer datastore.Writer) *API {
+		api := New(mux.NewRouter(), &datastoretest.StorerMock{}, hierarchyAPIURL, codeListAPIURL, false, "")
+		if writer != nil {
+			api.EnableWrites(writer, writeToken)
+		}
+		return api
+	}
+
+	newRequest := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest("PUT", "/hierarchies/hier12/dim34", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+writeToken)
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return mux.SetURLVars(r, map[string]string{"instance": "hier12", "dimension": "dim34"})
+	}
+
+	jsonBody := `{"code_list_id": "cl", "nodes": [
+		{"code": "root", "label": "Root"},
+		{"code": "a", "label": "A", "parent": "root", "order": 1, "has_data": true},
+		{"code": "a.1", "label": "A1", "parent": "a"}
+	]}`
+
+	Convey("When a new hierarchy is put as JSON, it is written and summarised with a 201 response", t, func() {
+		writer := newMockWriter(true, nil)
+		w := httptest.NewRecorder()
+
+		newAPI(writer).putHierarchyHandler(w, newRequest("application/json", jsonBody))
+
+		So(w.Code, ShouldEqual, http.StatusCreated)
+		So(w.Header().Get("Location"), ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34")
+
+		So(writer.PutHierarchyCalls(), ShouldHaveLength, 1)
+		call := writer.PutHierarchyCalls()[0]
+		So(call.InstanceID, ShouldEqual, "hier12")
+		So(call.Dimension, ShouldEqual, "dim34")
+		So(call.H.CodelistID, ShouldEqual, "cl")
+		So(call.H.Nodes, ShouldHaveLength, 3)
+		So(call.H.Nodes[1].Parent, ShouldEqual, "root")
+		So(*call.H.Nodes[1].Order, ShouldEqual, 1)
+		So(call.H.Nodes[1].HasData, ShouldBeTrue)
+
+		var res models.HierarchySummary
+		So(json.Unmarshal(w.Body.Bytes(), &res), ShouldBeNil)
+		So(res.Dimension, ShouldEqual, "dim34")
+		So(res.CodelistID, ShouldEqual, "cl")
+		So(res.NoOfNodes, ShouldEqual, 3)
+		So(res.Depth, ShouldEqual, 2)
+		So(res.Links["root"].HRef, ShouldEqual, "http://localhost:22600/hierarchies/hier12/dim34")
+	})
+
+	Convey("When an existing hierarchy is replaced, we get a 200 response without a location", t, func() {
+		w := httptest.NewRecorder()
+
+		newAPI(newMockWriter(false, nil)).putHierarchyHandler(w, newRequest("", jsonBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Location"), ShouldBeEmpty)
+	})
+
+	Convey("When a hierarchy is put as CSV, its code list is taken from the query", t, func() {
+		writer := newMockWriter(true, nil)
+		w := httptest.NewRecorder()
+
+		r := newRequest("text/csv; charset=utf-8", "code,parent,label,order\nroot,,Root,\na,root,A,1\n")
+		r.URL.RawQuery = "code_list_id=cl"
+		newAPI(writer).putHierarchyHandler(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusCreated)
+		call := writer.PutHierarchyCalls()[0]
+		So(call.H.CodelistID, ShouldEqual, "cl")
+		So(call.H.Nodes, ShouldHaveLength, 2)
+		So(call.H.Nodes[1].Label, ShouldEqual, "A")
+	})
+
+	Convey("When writes are not enabled, we get a 405 response", t, func() {
+		w := httptest.NewRecorder()
+
+		newAPI(nil).putHierarchyHandler(w, newRequest("", jsonBody))
+
+		So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		So(w.Header().Get("Allow"), ShouldEqual, http.MethodGet)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "method_not_allowed")
+	})
+
+	Convey("When the request does not have the write token, we get a 401 response and nothing is written", t, func() {
+		for _, authorization := range []string{"", "Bearer wrong", writeToken, "Basic " + writeToken} {
+			writer := newMockWriter(true, nil)
+			w := httptest.NewRecorder()
+
+			r := newRequest("", jsonBody)
+			r.Header.Set("Authorization", authorization)
+			newAPI(writer).putHierarchyHandler(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusUnauthorized)
+			So(w.Header().Get("WWW-Authenticate"), ShouldEqual, "Bearer")
+			So(decodeProblem(w).ErrorCode, ShouldEqual, "unauthorised")
+			So(writer.PutHierarchyCalls(), ShouldBeEmpty)
+		}
+	})
+
+	Convey("When the request body is not JSON or CSV, we get a 415 response", t, func() {
+		w := httptest.NewRecorder()
+
+		newAPI(newMockWriter(true, nil)).putHierarchyHandler(w, newRequest("application/xml", "<hierarchy/>"))
+
+		So(w.Code, ShouldEqual, http.StatusUnsupportedMediaType)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "unsupported_media_type")
+	})
+
+	Convey("When the request body is invalid, we get a 400 response and nothing is written", t, func() {
+		for body, detail := range map[string]string{
+			`{"nodes": [`: "failed to parse request body",
+			`{"nodes": [{"code": "root", "label": "Root"}]}`:                    "code_list_id must be provided",
+			`{"code_list_id": "cl", "nodes": [null]}`:                           "nodes must not be null",
+			`{"code_list_id": "cl", "nodes": [{"code": "root", "order": "1"}]}`: "failed to parse request body",
+		} {
+			writer := newMockWriter(true, nil)
+			w := httptest.NewRecorder()
+
+			newAPI(writer).putHierarchyHandler(w, newRequest("application/json", body))
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			problem := decodeProblem(w)
+			So(problem.ErrorCode, ShouldEqual, "invalid_body")
+			So(problem.Detail, ShouldStartWith, detail)
+			So(writer.PutHierarchyCalls(), ShouldBeEmpty)
+		}
+	})
+
+	Convey("When the hierarchy is not a single tree, we get a 400 response saying why", t, func() {
+		w := httptest.NewRecorder()
+
+		err := fmt.Errorf("%w: %w", datastore.ErrInvalidHierarchy, errors.New(`code "a" has unknown parent "b"`))
+		newAPI(newMockWriter(false, err)).putHierarchyHandler(w, newRequest("", jsonBody))
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		problem := decodeProblem(w)
+		So(problem.ErrorCode, ShouldEqual, "invalid_hierarchy")
+		So(problem.Detail, ShouldEqual, `invalid hierarchy: code "a" has unknown parent "b"`)
+	})
+
+	Convey("When the datastore fails to write the hierarchy, we get a 500 response", t, func() {
+		w := httptest.NewRecorder()
+
+		newAPI(newMockWriter(false, errors.New("write failed"))).putHierarchyHandler(w, newRequest("", jsonBody))
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(decodeProblem(w).ErrorCode, ShouldEqual, "internal_error")
+	})
+
+	Convey("Given the API's router with writes enabled", t, func() {
+		writer := newMockWriter(true, nil)
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "cl", nil
+			},
+			GetHierarchyRootFunc: func(_ context.Context, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: "root", Label: "Root"}, 0, nil
+			},
+		}
+		router := mux.NewRouter()
+		New(router, store, hierarchyAPIURL, codeListAPIURL, false, "").EnableWrites(writer, writeToken)
+
+		Convey("When a hierarchy is put, it is written", func() {
+			r := httptest.NewRequest("PUT", "/hierarchies/hier12/dim34", strings.NewReader(jsonBody))
+			r.Header.Set("Authorization", "Bearer "+writeToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(writer.PutHierarchyCalls(), ShouldHaveLength, 1)
+			So(store.GetHierarchyRootCalls(), ShouldBeEmpty)
+		})
+
+		Convey("When the hierarchy is got, its root is returned", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/hierarchies/hier12/dim34", http.NoBody))
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(writer.PutHierarchyCalls(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestTreeDepth(t *testing.T) {
+	t.Parallel()
+
+	Convey("The depth of a hierarchy is the number of levels below its root", t, func() {
+		So(treeDepth([]datastore.Node{{Code: "root"}}), ShouldEqual, 0)
+		So(treeDepth([]datastore.Node{
+			{Code: "a.1.i", Parent: "a.1"},
+			{Code: "root"},
+			{Code: "a", Parent: "root"},
+			{Code: "a.1", Parent: "a"},
+			{Code: "b", Parent: "root"},
+		}), ShouldEqual, 3)
+	})
+}
@@ -3,20 +3,33 @@ package main
 import (
 	"context"
 	"errors"
+	"net"
 	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/health"
 	"github.com/ONSdigital/dp-graph/v2/graph"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/ONSdigital/dp-hierarchy-api/api"
 	"github.com/ONSdigital/dp-hierarchy-api/config"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/cache"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/factory"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/instrumented"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/traced"
+	"github.com/ONSdigital/dp-hierarchy-api/grpcapi"
+	"github.com/ONSdigital/dp-hierarchy-api/hierarchypb"
+	"github.com/ONSdigital/dp-hierarchy-api/metrics"
 	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/dp-hierarchy-api/tracing"
 	dphttp "github.com/ONSdigital/dp-net/v2/http"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -41,20 +54,61 @@ func main() {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 
+	shutdownTracing, err := tracing.Init(ctx, config.OTExporter, config.OTExporterOTLPEndpoint, config.OTServiceName)
+	if err != nil {
+		log.Fatal(ctx, "error setting up tracing", err, log.Data{"exporter": config.OTExporter})
+		os.Exit(1)
+	}
+
 	// setup database
-	graphDB, err := graph.NewHierarchyStore(ctx)
+	store, graphDB, err := factory.New(ctx, config)
 	if err != nil {
-		log.Fatal(ctx, "error creating hierarchy store", err)
+		log.Fatal(ctx, "error creating hierarchy store", err, log.Data{"datastore_type": config.DatastoreType})
 		os.Exit(1)
 	}
+	if graphDB == nil {
+		log.Info(ctx, "using in-memory datastore", log.Data{"fixture_path": config.DatastoreFixturePath})
+	}
+
+	// the wrapped store is always a datastore.Writer, so check the store it wraps
+	_, writable := store.(datastore.Writer)
+	memoryStore, _ := store.(*memory.Store)
+
+	serviceMetrics := metrics.New()
+	store = instrumented.New(traced.New(store), serviceMetrics)
+
+	stopCacheStats := func() {}
+	var cachedStore *cache.Store
+	if config.CacheSize > 0 {
+		// a write only clears the cache of the replica it is made through, so others expire their lookups sooner
+		cacheTTL := config.CacheTTL
+		if config.WriteAuthToken != "" {
+			cacheTTL = config.WritableCacheTTL
+		}
+		cachedStore = cache.New(store, config.CacheSize, cacheTTL)
+		stopCacheStats = logCacheStats(ctx, cachedStore, config.CacheStatsInterval)
+		serviceMetrics.RegisterCache(cachedStore)
+		store = cachedStore
+		log.Info(ctx, "datastore caching enabled", log.Data{"cache_size": config.CacheSize, "cache_ttl": cacheTTL.String()})
+	}
 
-	graphErrorConsumer := graph.NewLoggingErrorConsumer(ctx, graphDB.Errors)
+	stopWatchingFixtures := func() {}
+	if memoryStore != nil && config.DatastoreReloadInterval > 0 {
+		stopWatchingFixtures = watchFixtures(ctx, memoryStore, cachedStore, config.DatastoreFixturePath, config.DatastoreReloadInterval)
+	}
+
+	var graphErrorConsumer *graph.ErrorConsumer
+	if graphDB != nil {
+		graphErrorConsumer = graph.NewLoggingErrorConsumer(ctx, graphDB.Errors)
+	}
 
 	hc := startHealthCheck(ctx, config, graphDB)
 
 	// setup http server
 	router := mux.NewRouter()
-	router.Path("/health").HandlerFunc(hc.Handler)
+	router.Use(serviceMetrics.Middleware)
+	router.Path("/health").HandlerFunc(hc.Handler).Name("health")
+	router.Path("/metrics").Handler(serviceMetrics.Handler()).Name("metrics")
 
 	// store URLs using net/url URL type to prevent error checking in handlers
 	hierarchyAPIURL, err := url.Parse(config.HierarchyAPIURL)
@@ -75,7 +129,16 @@ func main() {
 		log.Info(ctx, "URL rewriting enabled")
 	}
 
-	api.New(router, graphDB, hierarchyAPIURL, codeListAPIURL, enableURLRewriting)
+	hierarchyAPI := api.New(router, store, hierarchyAPIURL, codeListAPIURL, enableURLRewriting, config.CacheControl)
+
+	if config.WriteAuthToken != "" {
+		if !writable {
+			log.Fatal(ctx, "hierarchy writes are not supported by the datastore", datastore.ErrReadOnly, log.Data{"datastore_type": config.DatastoreType})
+			os.Exit(1)
+		}
+		hierarchyAPI.EnableWrites(store.(datastore.Writer), config.WriteAuthToken)
+		log.Info(ctx, "hierarchy writes enabled")
+	}
 
 	srv := dphttp.NewServer(config.BindAddr, router)
 	srv.HandleOSSignals = false
@@ -83,6 +146,29 @@ func main() {
 	// put constants into model
 	models.CodelistURL = codeListAPIURL.String()
 
+	// start grpc server, unless it is disabled. Its done channel is left nil when it is, so never fires.
+	var grpcServer *grpc.Server
+	var grpcServerDoneChan chan error
+	if config.GRPCBindAddr != "" {
+		listener, listenErr := net.Listen("tcp", config.GRPCBindAddr)
+		if listenErr != nil {
+			log.Fatal(ctx, "error listening for grpc server", listenErr, log.Data{"grpc_bind_addr": config.GRPCBindAddr})
+			os.Exit(1)
+		}
+
+		grpcServer = grpc.NewServer(grpc.UnaryInterceptor(grpcapi.TracingInterceptor))
+		hierarchypb.RegisterHierarchyServer(grpcServer, grpcapi.New(store, hierarchyAPIURL, codeListAPIURL))
+
+		grpcServerDoneChan = make(chan error)
+		go func() {
+			log.Info(ctx, "starting grpc server", log.Data{"grpc_bind_addr": config.GRPCBindAddr})
+			if serveErr := grpcServer.Serve(listener); serveErr != nil {
+				log.Error(ctx, "grpc server error", serveErr)
+			}
+			close(grpcServerDoneChan)
+		}()
+	}
+
 	// start http server
 	httpServerDoneChan := make(chan error)
 	go func() {
@@ -93,9 +179,10 @@ func main() {
 		close(httpServerDoneChan)
 	}()
 
-	// wait (indefinitely) for an exit event (either an OS signal or the httpServerDoneChan)
+	// wait (indefinitely) for an exit event (either an OS signal, the httpServerDoneChan or the grpcServerDoneChan)
 	// set `err` and logData
 	wantHTTPShutdown := true
+	wantGRPCShutdown := grpcServer != nil
 	logData := log.Data{}
 	select {
 	case sig := <-signals:
@@ -103,6 +190,8 @@ func main() {
 		log.Info(ctx, "aborting after signal", logData)
 	case <-httpServerDoneChan:
 		wantHTTPShutdown = false
+	case <-grpcServerDoneChan:
+		wantGRPCShutdown = false
 	}
 
 	// gracefully shutdown the application, closing any open resources
@@ -114,6 +203,8 @@ func main() {
 	go func() {
 		log.Info(ctx, "stopping health checks")
 		hc.Stop()
+		stopCacheStats()
+		stopWatchingFixtures()
 
 		if wantHTTPShutdown {
 			log.Info(ctx, "stopping http server")
@@ -123,15 +214,31 @@ func main() {
 			}
 		}
 
-		log.Info(ctx, "closing graph db connection")
-		if dbErr := graphDB.Close(shutdownContext); dbErr != nil {
+		if wantGRPCShutdown {
+			log.Info(ctx, "stopping grpc server")
+			if grpcErr := stopGRPCServer(shutdownContext, grpcServer); grpcErr != nil {
+				log.Error(ctx, "error stopping grpc server", grpcErr)
+				hasShutdownError = true
+			}
+		}
+
+		log.Info(ctx, "closing datastore connection")
+		if dbErr := store.Close(shutdownContext); dbErr != nil {
 			log.Error(ctx, "error closing db connection", dbErr)
 			hasShutdownError = true
 		}
 
-		log.Info(ctx, "closing graph db error consumer")
-		if consumerErr := graphErrorConsumer.Close(shutdownContext); consumerErr != nil {
-			log.Error(ctx, "error closing graph db error consumer", consumerErr)
+		if graphErrorConsumer != nil {
+			log.Info(ctx, "closing graph db error consumer")
+			if consumerErr := graphErrorConsumer.Close(shutdownContext); consumerErr != nil {
+				log.Error(ctx, "error closing graph db error consumer", consumerErr)
+				hasShutdownError = true
+			}
+		}
+
+		log.Info(ctx, "flushing traces")
+		if traceErr := shutdownTracing(shutdownContext); traceErr != nil {
+			log.Error(ctx, "error flushing traces", traceErr)
 			hasShutdownError = true
 		}
 
@@ -161,9 +268,12 @@ func startHealthCheck(ctx context.Context, config *config.Config, graphDB *graph
 
 	hc := healthcheck.New(versionInfo, config.HealthCheckCriticalTimeout, config.HealthCheckInterval)
 
-	if err = hc.AddCheck("Graph DB", graphDB.Checker); err != nil {
-		hasErrors = true
-		log.Error(ctx, "error adding check for graph db", err)
+	// the graph DB is nil when the service is running against a local datastore
+	if graphDB != nil {
+		if err = hc.AddCheck("Graph DB", graphDB.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding check for graph db", err)
+		}
 	}
 
 	codeListAPIHealthCheckClient := health.NewClient("Code List API", config.CodelistAPIURL)
@@ -180,3 +290,69 @@ func startHealthCheck(ctx context.Context, config *config.Config, graphDB *graph
 
 	return &hc
 }
+
+// stopGRPCServer waits for calls in progress to finish, cancelling those still running when ctx is done
+func stopGRPCServer(ctx context.Context, srv *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		srv.Stop()
+		return ctx.Err()
+	}
+}
+
+// logCacheStats logs how effective the cache has been at every interval, so that it can be sized.
+// The returned function stops the logging.
+func logCacheStats(ctx context.Context, store *cache.Store, interval time.Duration) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				stats := store.Stats()
+				log.Info(ctx, "datastore cache stats", log.Data{
+					"hits":      stats.Hits,
+					"misses":    stats.Misses,
+					"evictions": stats.Evictions,
+					"entries":   stats.Entries,
+				})
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// watchFixtures reloads the in-memory datastore whenever its fixtures change, clearing the cache in front
+// of it if there is one. The returned function stops the watching.
+func watchFixtures(ctx context.Context, store *memory.Store, cachedStore *cache.Store, path string, interval time.Duration) func() {
+	logData := log.Data{"fixture_path": path, "reload_interval": interval.String()}
+	log.Info(ctx, "watching fixtures for changes", logData)
+
+	return store.Watch(path, interval, func(err error) {
+		if err != nil {
+			log.Error(ctx, "error reloading fixtures, keeping the hierarchies already loaded", err, logData)
+			return
+		}
+
+		if cachedStore != nil {
+			cachedStore.Clear()
+		}
+		log.Info(ctx, "reloaded fixtures", logData)
+	})
+}
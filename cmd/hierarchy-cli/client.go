@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/api"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+)
+
+// inProcessURL is the base URL of the API when it is served in process. Links in its responses point
+// here, but commands only read the codes from them.
+const inProcessURL = "http://localhost"
+
+var errTwoSources = errors.New("only one of -fixture and -api can be given")
+
+// source is where a command reads hierarchies from, as chosen by its flags
+type source struct {
+	fixture string
+	apiURL  string
+}
+
+// open returns a client of the API at apiURL, if one is given, or of the API served in process from the
+// fixture file or configured datastore. Either way, commands see the same responses.
+func (s *source) open(ctx context.Context) (*client, error) {
+	if s.apiURL != "" {
+		if s.fixture != "" {
+			return nil, errTwoSources
+		}
+		return &client{http: &http.Client{}, baseURL: strings.TrimSuffix(s.apiURL, "/")}, nil
+	}
+
+	store, err := openStore(ctx, s.fixture)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := url.Parse(inProcessURL)
+	router := mux.NewRouter()
+	api.New(router, store, host, host, false, "")
+
+	return &client{
+		http:    &http.Client{Transport: handlerTransport{handler: router}},
+		baseURL: inProcessURL,
+		store:   store,
+	}, nil
+}
+
+// handlerTransport serves requests with a handler in process instead of sending them over the network
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	t.handler.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+// client gets hierarchies from the API
+type client struct {
+	http    *http.Client
+	baseURL string
+	// store is the datastore the API is served from in process, closed with the client
+	store datastore.Storer
+}
+
+// apiError is a problem the API responded with
+type apiError struct {
+	status  int
+	problem models.Problem
+}
+
+func (e *apiError) Error() string {
+	if e.problem.Detail != "" {
+		return e.problem.Detail
+	}
+	return fmt.Sprintf("unexpected response status %d %s", e.status, http.StatusText(e.status))
+}
+
+// Close closes the datastore the API is served from, if it is served in process
+func (c *client) Close(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+	return c.store.Close(ctx)
+}
+
+// get requests the hierarchy resource at the path below /hierarchies, whose segments are escaped, with the
+// given query and Accept header. An *apiError is returned for a response that is not successful, otherwise
+// the caller must close the response body.
+func (c *client) get(ctx context.Context, segments []string, query url.Values, accept string) (*http.Response, error) {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+
+	target := c.baseURL + "/hierarchies/" + strings.Join(escaped, "/")
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		apiErr := &apiError{status: res.StatusCode}
+		// a body that is not a problem document, such as one from a proxy, is reported by its status
+		_ = json.NewDecoder(res.Body).Decode(&apiErr.problem)
+		return nil, apiErr
+	}
+
+	return res, nil
+}
+
+// getJSON requests the hierarchy resource at the path below /hierarchies and decodes it into v
+func (c *client) getJSON(ctx context.Context, segments []string, query url.Values, v any) error {
+	res, err := c.get(ctx, segments, query, "application/json")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// stream copies the hierarchy resource at the path below /hierarchies to w, in the requested media type
+func (c *client) stream(ctx context.Context, w io.Writer, segments []string, query url.Values, accept string) error {
+	res, err := c.get(ctx, segments, query, accept)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("Given a client of the API served in process from a fixture", t, func() {
+		c, err := (&source{fixture: fixturePath}).open(ctx)
+		So(err, ShouldBeNil)
+		defer c.Close(ctx)
+
+		Convey("When a resource is got, it is decoded from the API's response", func() {
+			var root models.Response
+			So(c.getJSON(ctx, []string{"cpih01-instance", "aggregate"}, nil, &root), ShouldBeNil)
+			So(root.Label, ShouldEqual, "Overall Index")
+			So(codeOf(root.Links), ShouldEqual, "cpih1dim1A0")
+		})
+
+		Convey("When the API responds with a problem, its detail is the error", func() {
+			var node models.Response
+			err := c.getJSON(ctx, []string{"cpih01-instance", "aggregate", "missing code"}, nil, &node)
+
+			var apiErr *apiError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.status, ShouldEqual, http.StatusNotFound)
+			So(apiErr.problem.ErrorCode, ShouldEqual, "code_not_found")
+			So(err.Error(), ShouldEqual, `code "missing code" not found in the hierarchy`)
+		})
+	})
+
+	Convey("Given a client of a running API", t, func() {
+		var requested *url.URL
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		c, err := (&source{apiURL: server.URL + "/"}).open(ctx)
+		So(err, ShouldBeNil)
+
+		Convey("When a resource is got, its path segments and query are escaped", func() {
+			err := c.getJSON(ctx, []string{"instance", "dimension", "a/b", "_search"}, url.Values{"q": {"x y"}}, &models.SearchResults{})
+			So(err, ShouldNotBeNil)
+			So(requested.EscapedPath(), ShouldEqual, "/hierarchies/instance/dimension/a%2Fb/_search")
+			So(requested.RawQuery, ShouldEqual, "q=x+y")
+		})
+
+		Convey("When the API responds without a problem document, the error is the status", func() {
+			err := c.getJSON(ctx, []string{"instance", "dimension"}, nil, &models.Response{})
+			So(err.Error(), ShouldEqual, "unexpected response status 502 Bad Gateway")
+		})
+	})
+
+	Convey("When both a fixture and an API are given, the client is not opened", t, func() {
+		_, err := (&source{fixture: fixturePath, apiURL: "http://localhost:22600"}).open(ctx)
+		So(err, ShouldEqual, errTwoSources)
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// runRoot prints the root of a hierarchy with its children
+func runRoot(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, src := newFlagSet("root", stderr)
+	positional, err := parseArgs(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	c, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+
+	var root models.Response
+	if err = c.getJSON(ctx, positional, nil, &root); err != nil {
+		return err
+	}
+
+	printNode(stdout, &root)
+	return nil
+}
+
+// runNode prints a node of a hierarchy with the path to it from the root and its children
+func runNode(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, src := newFlagSet("node", stderr)
+	positional, err := parseArgs(flags, args, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	c, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+
+	var node models.Response
+	if err = c.getJSON(ctx, positional, nil, &node); err != nil {
+		return err
+	}
+
+	if len(node.Breadcrumbs) > 0 {
+		fmt.Fprintln(stdout, breadcrumbPath(node.Breadcrumbs))
+	}
+	printNode(stdout, &node)
+	return nil
+}
+
+// runTree prints the subtree below a code, or the whole hierarchy when no code is given
+func runTree(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, src := newFlagSet("tree", stderr)
+	depth := flags.Int("depth", 0, "the number of levels below the code to print, or 0 for all of them")
+	positional, err := parseArgs(flags, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	instanceID, dimension := positional[0], positional[1]
+
+	c, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+
+	var code string
+	if len(positional) == 3 {
+		code = positional[2]
+	} else {
+		var root models.Response
+		if err = c.getJSON(ctx, []string{instanceID, dimension}, nil, &root); err != nil {
+			return err
+		}
+		code = codeOf(root.Links)
+	}
+
+	query := url.Values{}
+	if *depth != 0 {
+		query.Set("depth", strconv.Itoa(*depth))
+	}
+
+	var tree models.Node
+	if err = c.getJSON(ctx, []string{instanceID, dimension, code, "descendants"}, query, &tree); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, nodeLine(&tree.Element, len(tree.Children)))
+	printTree(stdout, tree.Children, "")
+	return nil
+}
+
+// runSearch prints the nodes whose labels match a query, with the path to each from the root
+func runSearch(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, src := newFlagSet("search", stderr)
+	limit := flags.Int("limit", 0, "the maximum number of matches to print, or 0 for the API's default")
+	positional, err := parseArgs(flags, args, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	c, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+
+	query := url.Values{"q": {positional[2]}}
+	if *limit != 0 {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+
+	var results models.SearchResults
+	if err = c.getJSON(ctx, []string{positional[0], positional[1], "_search"}, query, &results); err != nil {
+		return err
+	}
+
+	if results.Count == 0 {
+		fmt.Fprintf(stdout, "no matches for %q\n", positional[2])
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, item := range results.Items {
+		fmt.Fprintf(tw, "%s\t%s\n", nodeLine(responseElement(item), len(item.Children)), breadcrumbPath(item.Breadcrumbs))
+	}
+	return tw.Flush()
+}
+
+// printNode prints a node and the children listed with it
+func printNode(w io.Writer, node *models.Response) {
+	fmt.Fprintln(w, nodeLine(responseElement(node), len(node.Children)))
+
+	children := make([]*models.Node, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = &models.Node{Element: *child}
+	}
+	printTree(w, children, "")
+}
+
+// printTree prints nodes as branches below their parent, each line indented by prefix
+func printTree(w io.Writer, nodes []*models.Node, prefix string) {
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		fmt.Fprintln(w, prefix+branch+nodeLine(&node.Element, len(node.Children)))
+		printTree(w, node.Children, prefix+indent)
+	}
+}
+
+// nodeLine describes a node as its has_data marker, label and code. A node with children that are not
+// printed below it is followed by their number, so that it is clear the tree goes on.
+func nodeLine(e *models.Element, childrenShown int) string {
+	marker := "[ ]"
+	if e.HasData {
+		marker = "[x]"
+	}
+
+	line := fmt.Sprintf("%s %s (%s)", marker, e.Label, codeOf(e.Links))
+	if childrenShown == 0 && e.NoOfChildren > 0 {
+		line += fmt.Sprintf(" +%d", e.NoOfChildren)
+	}
+	return line
+}
+
+// breadcrumbPath joins the labels of the ancestors of a node from the root down. Breadcrumbs are listed
+// from the parent of the node up to the root.
+func breadcrumbPath(breadcrumbs []*models.Element) string {
+	labels := make([]string, len(breadcrumbs))
+	for i, crumb := range breadcrumbs {
+		labels[i] = crumb.Label
+	}
+	slices.Reverse(labels)
+	return strings.Join(labels, " > ")
+}
+
+func responseElement(r *models.Response) *models.Element {
+	return &models.Element{Label: r.Label, NoOfChildren: r.NoOfChildren, Links: r.Links, HasData: r.HasData}
+}
+
+// codeOf returns the code of a node, which the API only gives in the id of its code link
+func codeOf(links map[string]models.Link) string {
+	return links["code"].ID
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/api"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRoot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When the root is printed, its children are branches with the number of their own children", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"root", "-fixture", fixturePath, "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "[x] Overall Index (cpih1dim1A0)\n"+
+			"├── [x] 01 Food and non-alcoholic beverages (cpih1dim1G10000) +2\n"+
+			"├── [x] 02 Alcoholic beverages and tobacco (cpih1dim1G20000) +2\n"+
+			"└── [x] 03 Clothing and footwear (cpih1dim1G30000)\n")
+	})
+
+	Convey("Given a running API, the root is read from it", t, func() {
+		store, err := memory.NewFromFile(fixturePath)
+		So(err, ShouldBeNil)
+		host, _ := url.Parse("http://localhost:22600")
+		router := mux.NewRouter()
+		api.New(router, store, host, host, false, "")
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"root", "-api", server.URL, "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldStartWith, "[x] Overall Index (cpih1dim1A0)\n")
+	})
+
+	Convey("When the hierarchy does not exist, the API's reason is printed and the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"root", "-fixture", fixturePath, "cpih01-instance", "geography"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldEqual, "root: no hierarchy found for dimension \"geography\" of instance \"cpih01-instance\"\n")
+	})
+}
+
+func TestNode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When a node is printed, it follows the path to it from the root", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"node", "-fixture", fixturePath, "cpih01-instance", "aggregate", "cpih1dim1G10100"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "Overall Index > 01 Food and non-alcoholic beverages\n"+
+			"[x] 01.1 Food (cpih1dim1G10100)\n"+
+			"├── [x] 01.1.1 Bread and cereals (cpih1dim1S10101)\n"+
+			"└── [ ] 01.1.2 Meat (cpih1dim1S10102)\n")
+	})
+
+	Convey("When the code is not in the hierarchy, the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"node", "-fixture", fixturePath, "cpih01-instance", "aggregate", "missing"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldEqual, "node: code \"missing\" not found in the hierarchy\n")
+	})
+}
+
+func TestTree(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When no code is given, the whole hierarchy is printed from its root", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"tree", "-fixture", fixturePath, "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "[x] Overall Index (cpih1dim1A0)\n"+
+			"├── [x] 01 Food and non-alcoholic beverages (cpih1dim1G10000)\n"+
+			"│   ├── [x] 01.1 Food (cpih1dim1G10100)\n"+
+			"│   │   ├── [x] 01.1.1 Bread and cereals (cpih1dim1S10101)\n"+
+			"│   │   └── [ ] 01.1.2 Meat (cpih1dim1S10102)\n"+
+			"│   └── [x] 01.2 Non-alcoholic beverages (cpih1dim1G10200)\n"+
+			"├── [x] 02 Alcoholic beverages and tobacco (cpih1dim1G20000)\n"+
+			"│   ├── [x] 02.1 Alcoholic beverages (cpih1dim1G20100)\n"+
+			"│   └── [x] 02.2 Tobacco (cpih1dim1G20200)\n"+
+			"└── [x] 03 Clothing and footwear (cpih1dim1G30000)\n")
+	})
+
+	Convey("When a code and depth are given, only those levels below the code are printed", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"tree", "-fixture", fixturePath, "-depth", "1", "cpih01-instance", "aggregate", "cpih1dim1G10000"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "[x] 01 Food and non-alcoholic beverages (cpih1dim1G10000)\n"+
+			"├── [x] 01.1 Food (cpih1dim1G10100) +2\n"+
+			"└── [x] 01.2 Non-alcoholic beverages (cpih1dim1G10200)\n")
+	})
+
+	Convey("When the depth is invalid, the API's reason is printed and the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"tree", "-fixture", fixturePath, "-depth", "-1", "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldStartWith, "tree: ")
+	})
+
+	Convey("When too many arguments are given, the range expected is printed", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"tree", "-fixture", fixturePath, "a", "b", "c", "d"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldContainSubstring, "tree: expected 2 to 3 arguments but got 4: a b c d")
+	})
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When nodes match the query, each is printed with the path to it", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"search", "-fixture", fixturePath, "-limit", "1", "cpih01-instance", "aggregate", "food"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "[x] 01 Food and non-alcoholic beverages (cpih1dim1G10000) +2  Overall Index\n")
+	})
+
+	Convey("When no nodes match the query, it is said so", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"search", "-fixture", fixturePath, "cpih01-instance", "aggregate", "zzz"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "no matches for \"zzz\"\n")
+	})
+}
+
+func TestNodeLine(t *testing.T) {
+	t.Parallel()
+
+	Convey("A node is described by its has_data marker, label, code and any children not shown", t, func() {
+		e := &models.Element{Label: "A", NoOfChildren: 2, Links: map[string]models.Link{"code": {ID: "a"}}}
+		So(nodeLine(e, 0), ShouldEqual, "[ ] A (a) +2")
+		So(nodeLine(e, 2), ShouldEqual, "[ ] A (a)")
+
+		e.HasData = true
+		e.NoOfChildren = 0
+		So(nodeLine(e, 0), ShouldEqual, "[x] A (a)")
+	})
+}
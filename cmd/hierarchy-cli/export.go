@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// exportMediaTypes are the media types requested from the API for each export format
+var exportMediaTypes = map[string]string{
+	"csv":  "text/csv",
+	"json": "application/json",
+}
+
+// runExport writes the whole of a hierarchy, in the format the export endpoint of the API gives it in
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, src := newFlagSet("export", stderr)
+	format := flags.String("format", "csv", "the format to export the hierarchy in, one of csv or json")
+	positional, err := parseArgs(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	mediaType, ok := exportMediaTypes[*format]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("format must be one of csv or json but got %q", *format)
+	}
+
+	c, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+
+	return c.stream(ctx, stdout, []string{positional[0], positional[1], "_export"}, nil, mediaType)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When a hierarchy is exported, it is written as CSV by default", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"export", "-fixture", fixturePath, "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 0)
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		So(lines, ShouldHaveLength, 11)
+		So(lines[0], ShouldEqual, "code,label,parent_code,order,has_data,no_of_children")
+		So(lines[1], ShouldEqual, "cpih1dim1A0,Overall Index,,,true,3")
+	})
+
+	Convey("When a hierarchy is exported as JSON, it is written as a list of nodes", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"export", "-fixture", fixturePath, "-format", "json", "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 0)
+
+		var nodes []*models.ExportNode
+		So(json.Unmarshal(stdout.Bytes(), &nodes), ShouldBeNil)
+		So(nodes, ShouldHaveLength, 10)
+		So(nodes[1].ParentCode, ShouldEqual, "cpih1dim1A0")
+	})
+
+	Convey("When the format is not csv or json, the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"export", "-fixture", fixturePath, "-format", "xml", "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldEndWith, "export: format must be one of csv or json but got \"xml\"\n")
+		So(stdout.String(), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,169 @@
+// Command hierarchy-cli explores, exports and checks hierarchies, either through a running API or in the
+// datastore the API serves them from
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ONSdigital/dp-hierarchy-api/config"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/factory"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// errProblemsFound is returned by a command that ran but found problems it has already reported
+var errProblemsFound = errors.New("problems found")
+
+// command is a subcommand, run with the arguments that follow its name
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+}
+
+// commands is filled in by init, as the commands look up their own usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"root": {
+			usage:   "root [-fixture file | -api url] <instance> <dimension>",
+			summary: "print the root of a hierarchy and its children",
+			run:     runRoot,
+		},
+		"node": {
+			usage:   "node [-fixture file | -api url] <instance> <dimension> <code>",
+			summary: "print a node of a hierarchy, the path to it from the root and its children",
+			run:     runNode,
+		},
+		"tree": {
+			usage:   "tree [-fixture file | -api url] [-depth n] <instance> <dimension> [code]",
+			summary: "print a hierarchy, or the subtree below a code, as an indented tree",
+			run:     runTree,
+		},
+		"search": {
+			usage:   "search [-fixture file | -api url] [-limit n] <instance> <dimension> <query>",
+			summary: "print the nodes whose labels match a query and the path to each",
+			run:     runSearch,
+		},
+		"export": {
+			usage:   "export [-fixture file | -api url] [-format csv|json] <instance> <dimension>",
+			summary: "write a whole hierarchy as CSV or JSON",
+			run:     runExport,
+		},
+		"validate": {
+			usage:   "validate [-fixture file | -api url] <instance> <dimension>",
+			summary: "check a hierarchy for cycles, orphaned nodes, duplicate codes and wrong child counts",
+			run:     runValidate,
+		},
+	}
+}
+
+func main() {
+	// without -api the API is served in process, and its request logs would be mixed into the output
+	log.SetDestination(io.Discard, io.Discard)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command named by the first argument, returning the exit code: 1 when the command found
+// problems and 2 when it could not be run
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	err := cmd.run(ctx, args[1:], stdout, stderr)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errProblemsFound):
+		return 1
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return 2
+	}
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: hierarchy-cli <command> [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n      %s\n", commands[name].usage, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nWithout -fixture or -api, the datastore is configured by the same environment variables as the API.")
+}
+
+// newFlagSet returns the flags of the named command, with the -fixture and -api flags every command
+// takes to choose where hierarchies are read from
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *source) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: hierarchy-cli %s\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+	src := &source{}
+	flags.StringVar(&src.fixture, "fixture", "", "a fixture file or directory to read hierarchies from instead of the configured datastore")
+	flags.StringVar(&src.apiURL, "api", "", "the URL of a running hierarchy API to read hierarchies from instead of the configured datastore")
+	return flags, src
+}
+
+// parseArgs parses the flags of a command and checks that there are between minArgs and maxArgs positional arguments
+func parseArgs(flags *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if n := flags.NArg(); n < minArgs || n > maxArgs {
+		flags.Usage()
+		want := strconv.Itoa(minArgs)
+		if maxArgs > minArgs {
+			want = fmt.Sprintf("%d to %d", minArgs, maxArgs)
+		}
+		return nil, fmt.Errorf("expected %s arguments but got %d: %s", want, n, strings.Join(flags.Args(), " "))
+	}
+	return flags.Args(), nil
+}
+
+// openStore opens the fixture file, if one is given, or the datastore configured for the API
+func openStore(ctx context.Context, fixture string) (datastore.Storer, error) {
+	cfg := &config.Config{DatastoreType: config.DatastoreTypeMemory, DatastoreFixturePath: fixture}
+	if fixture == "" {
+		var err error
+		if cfg, err = config.Get(); err != nil {
+			return nil, err
+		}
+	}
+
+	store, _, err := factory.New(ctx, cfg)
+	return store, err
+}
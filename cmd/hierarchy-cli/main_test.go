@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const fixturePath = "../../datastore/memory/testdata/hierarchies.json"
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When no command is given, the usage is printed and the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, nil, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldContainSubstring, "usage: hierarchy-cli <command> [arguments]")
+		So(stderr.String(), ShouldContainSubstring, "validate [-fixture file | -api url] <instance> <dimension>")
+	})
+
+	Convey("When an unknown command is given, the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"frobnicate"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldStartWith, `unknown command "frobnicate"`)
+	})
+
+	Convey("When a command is given the wrong number of arguments, its usage is printed and the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"validate", "-fixture", fixturePath, "cpih01-instance"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldContainSubstring, "usage: hierarchy-cli validate")
+		So(stderr.String(), ShouldContainSubstring, "validate: expected 2 arguments but got 1")
+	})
+
+	Convey("When a command is given an unknown flag, the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"validate", "-colour", "a", "b"}, &stdout, &stderr), ShouldEqual, 2)
+	})
+}
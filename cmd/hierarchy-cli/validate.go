@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// runValidate prints the problems found in a hierarchy, one per line, failing with errProblemsFound if
+// there are any so that imports can be gated on the exit code
+func runValidate(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, src := newFlagSet("validate", stderr)
+	positional, err := parseArgs(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	c, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+
+	var report models.ValidationReport
+	if err = c.getJSON(ctx, []string{positional[0], positional[1], "_validate"}, nil, &report); err != nil {
+		return err
+	}
+
+	return printReport(stdout, &report)
+}
+
+func printReport(w io.Writer, report *models.ValidationReport) error {
+	if report.Valid {
+		fmt.Fprintf(w, "valid: %d nodes checked\n", report.NoOfNodes)
+		return nil
+	}
+
+	fmt.Fprintf(w, "invalid: %d problems found in %d nodes\n", report.Count, report.NoOfNodes)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, problem := range report.Problems {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", problem.Type, problem.Code, problem.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	return errProblemsFound
+}
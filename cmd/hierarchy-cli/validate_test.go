@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When a valid hierarchy is validated, the number of nodes is printed and the exit code is 0", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"validate", "-fixture", fixturePath, "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "valid: 10 nodes checked\n")
+		So(stderr.String(), ShouldBeEmpty)
+	})
+
+	Convey("When the hierarchy does not exist, the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"validate", "-fixture", fixturePath, "cpih01-instance", "geography"}, &stdout, &stderr), ShouldEqual, 2)
+		So(stderr.String(), ShouldEqual, "validate: no hierarchy found for dimension \"geography\" of instance \"cpih01-instance\"\n")
+	})
+
+	Convey("When the fixture file cannot be read, the exit code is 2", t, func() {
+		var stdout, stderr bytes.Buffer
+		So(run(ctx, []string{"validate", "-fixture", "missing.json", "cpih01-instance", "aggregate"}, &stdout, &stderr), ShouldEqual, 2)
+	})
+
+	Convey("When a report has problems, each is printed on its own line and errProblemsFound is returned", t, func() {
+		var stdout bytes.Buffer
+		err := printReport(&stdout, &models.ValidationReport{NoOfNodes: 3, Count: 2, Problems: []*models.ValidationProblem{
+			{Type: "cycle", Code: "a", Detail: `code "a" is below itself: a > b > a`},
+			{Type: "child_count_mismatch", Code: "root", Detail: `code "root" has 2 children but 1 were found`},
+		}})
+
+		So(err, ShouldEqual, errProblemsFound)
+		So(stdout.String(), ShouldEqual, "invalid: 2 problems found in 3 nodes\n"+
+			"cycle                 a     code \"a\" is below itself: a > b > a\n"+
+			"child_count_mismatch  root  code \"root\" has 2 children but 1 were found\n")
+	})
+}
@@ -9,14 +9,33 @@ import (
 // Config contains configurable details for running the service
 type Config struct {
 	BindAddr                   string        `envconfig:"BIND_ADDR"`
+	GRPCBindAddr               string        `envconfig:"GRPC_BIND_ADDR"`
 	HierarchyAPIURL            string        `envconfig:"HIERARCHY_API_URL"`
 	ShutdownTimeout            time.Duration `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
 	HealthCheckInterval        time.Duration `envconfig:"HEALTHCHECK_INTERVAL"`
 	HealthCheckCriticalTimeout time.Duration `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
 	CodelistAPIURL             string        `envconfig:"CODE_LIST_URL"`
 	EnableURLRewriting         bool          `envconfig:"ENABLE_URL_REWRITING"`
+	CacheControl               string        `envconfig:"CACHE_CONTROL"`
+	DatastoreType              string        `envconfig:"DATASTORE_TYPE"`
+	DatastoreFixturePath       string        `envconfig:"DATASTORE_FIXTURE_PATH"`
+	DatastoreReloadInterval    time.Duration `envconfig:"DATASTORE_RELOAD_INTERVAL"`
+	CacheSize                  int           `envconfig:"CACHE_SIZE"`
+	CacheTTL                   time.Duration `envconfig:"CACHE_TTL"`
+	WritableCacheTTL           time.Duration `envconfig:"WRITABLE_CACHE_TTL"`
+	CacheStatsInterval         time.Duration `envconfig:"CACHE_STATS_INTERVAL"`
+	WriteAuthToken             string        `envconfig:"WRITE_AUTH_TOKEN" json:"-"`
+	OTExporter                 string        `envconfig:"OTEL_TRACES_EXPORTER"`
+	OTExporterOTLPEndpoint     string        `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTServiceName              string        `envconfig:"OTEL_SERVICE_NAME"`
 }
 
+// Supported values for DatastoreType
+const (
+	DatastoreTypeGraph  = "graph"
+	DatastoreTypeMemory = "memory"
+)
+
 var configuration *Config
 
 // Get configures the application and returns the configuration
@@ -24,12 +43,25 @@ func Get() (*Config, error) {
 	if configuration == nil {
 		configuration = &Config{
 			BindAddr:                   ":22600",
+			GRPCBindAddr:               "",
 			HierarchyAPIURL:            "http://localhost:22600",
 			ShutdownTimeout:            5 * time.Second,
 			HealthCheckInterval:        30 * time.Second,
 			HealthCheckCriticalTimeout: 90 * time.Second,
 			CodelistAPIURL:             "http://localhost:22400",
 			EnableURLRewriting:         false,
+			CacheControl:               "public, max-age=300",
+			DatastoreType:              DatastoreTypeGraph,
+			DatastoreFixturePath:       "",
+			DatastoreReloadInterval:    2 * time.Second,
+			CacheSize:                  10000,
+			CacheTTL:                   time.Hour,
+			WritableCacheTTL:           30 * time.Second,
+			CacheStatsInterval:         5 * time.Minute,
+			WriteAuthToken:             "",
+			OTExporter:                 "none",
+			OTExporterOTLPEndpoint:     "http://localhost:4318",
+			OTServiceName:              "dp-hierarchy-api",
 		}
 		if err := envconfig.Process("", configuration); err != nil {
 			return nil, err
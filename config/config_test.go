@@ -14,12 +14,25 @@ func TestGetReturnsDefaultValues(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(config, ShouldResemble, &Config{
 			BindAddr:                   ":22600",
+			GRPCBindAddr:               "",
 			HierarchyAPIURL:            "http://localhost:22600",
 			CodelistAPIURL:             "http://localhost:22400",
 			ShutdownTimeout:            5 * time.Second,
 			HealthCheckInterval:        30 * time.Second,
 			HealthCheckCriticalTimeout: 90 * time.Second,
 			EnableURLRewriting:         false,
+			CacheControl:               "public, max-age=300",
+			DatastoreType:              DatastoreTypeGraph,
+			DatastoreFixturePath:       "",
+			DatastoreReloadInterval:    2 * time.Second,
+			CacheSize:                  10000,
+			CacheTTL:                   time.Hour,
+			WritableCacheTTL:           30 * time.Second,
+			CacheStatsInterval:         5 * time.Minute,
+			WriteAuthToken:             "",
+			OTExporter:                 "none",
+			OTExporterOTLPEndpoint:     "http://localhost:4318",
+			OTServiceName:              "dp-hierarchy-api",
 		})
 	})
 }
@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	_ datastore.Storer = &Store{}
+	_ datastore.Writer = &Store{}
+)
+
+// Store is a datastore.Storer that caches the results of lookups made through another Storer.
+// Hierarchies do not change once an instance is published, so results are only refreshed when
+// they expire or are evicted to make room for others, or when a hierarchy is written through the
+// Store. Errors are never cached.
+//
+// Cached results are shared between callers, so they must not be modified.
+type Store struct {
+	datastore.Storer
+	entries *lru
+	group   singleflight.Group
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+// Stats describes how effective a cache has been since it was created
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// New creates a Store holding up to size results from the given store, each for at most ttl.
+// Results do not expire when ttl is zero. WalkHierarchy and ListHierarchyNodes are not cached, as they
+// stream the whole hierarchy and their results would displace everything else in the cache. ListDuplicateCodes
+// is not cached either, as it is only used to validate the hierarchy as it is stored.
+func New(store datastore.Storer, size int, ttl time.Duration) *Store {
+	return &Store{
+		Storer:  store,
+		entries: newLRU(size, ttl),
+	}
+}
+
+// Stats returns the number of lookups served from and missing the cache, and its current size
+func (s *Store) Stats() Stats {
+	entries, evictions := s.entries.stats()
+	return Stats{
+		Hits:      s.hits.Load(),
+		Misses:    s.misses.Load(),
+		Evictions: evictions,
+		Entries:   entries,
+	}
+}
+
+// GetHierarchyCodelist returns the code list ID for the given instance and dimension
+func (s *Store) GetHierarchyCodelist(ctx context.Context, instanceID, dimension string) (string, error) {
+	v, err := s.get(ctx, key("codelist", instanceID, dimension), func(ctx context.Context) (interface{}, error) {
+		return s.Storer.GetHierarchyCodelist(ctx, instanceID, dimension)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return v.(string), nil
+}
+
+// GetHierarchies summarises the hierarchy of every dimension of the instance that has one
+func (s *Store) GetHierarchies(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error) {
+	v, err := s.get(ctx, key("hierarchies", instanceID), func(ctx context.Context) (interface{}, error) {
+		return s.Storer.GetHierarchies(ctx, instanceID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*datastore.HierarchySummary), nil
+}
+
+// GetHierarchyRoot returns the root node of the hierarchy with the children selected by opts. The root
+// is cached with all of its children, so that every selection of them is served from the same entry.
+func (s *Store) GetHierarchyRoot(ctx context.Context, instanceID, dimension string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	v, err := s.get(ctx, key("root", instanceID, dimension), func(ctx context.Context) (interface{}, error) {
+		res, _, err := s.Storer.GetHierarchyRoot(ctx, instanceID, dimension, datastore.ChildOptions{})
+		return res, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, total := opts.Apply(v.(*dbmodels.HierarchyResponse))
+	return res, total, nil
+}
+
+// GetHierarchyElement returns the node for the given code with its breadcrumbs and the children selected
+// by opts. As with the root, the node is cached with all of its children.
+func (s *Store) GetHierarchyElement(ctx context.Context, instanceID, dimension, code string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	v, err := s.get(ctx, key("element", instanceID, dimension, code), func(ctx context.Context) (interface{}, error) {
+		res, _, err := s.Storer.GetHierarchyElement(ctx, instanceID, dimension, code, datastore.ChildOptions{})
+		return res, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, total := opts.Apply(v.(*dbmodels.HierarchyResponse))
+	return res, total, nil
+}
+
+// GetHierarchyElements returns the nodes for the given codes with their children and breadcrumbs. Nodes
+// are cached individually, shared with GetHierarchyElement, and only those not cached are looked up.
+func (s *Store) GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+	elements := make(map[string]*dbmodels.HierarchyResponse, len(codes))
+	generation := s.entries.currentGeneration()
+
+	var missing []string
+	for _, code := range codes {
+		if v, ok := s.entries.get(key("element", instanceID, dimension, code)); ok {
+			s.hits.Add(1)
+			elements[code] = v.(*dbmodels.HierarchyResponse)
+			continue
+		}
+		s.misses.Add(1)
+		missing = append(missing, code)
+	}
+
+	if len(missing) == 0 {
+		return elements, nil
+	}
+
+	found, err := s.Storer.GetHierarchyElements(ctx, instanceID, dimension, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for code, element := range found {
+		s.entries.addSince(generation, key("element", instanceID, dimension, code), element)
+		elements[code] = element
+	}
+
+	return elements, nil
+}
+
+// GetHierarchyDescendants returns the node for the given code with its descendants, limited to depth levels
+func (s *Store) GetHierarchyDescendants(ctx context.Context, instanceID, dimension, code string, depth int) (*datastore.HierarchyNode, error) {
+	v, err := s.get(ctx, key("descendants", instanceID, dimension, code, strconv.Itoa(depth)), func(ctx context.Context) (interface{}, error) {
+		return s.Storer.GetHierarchyDescendants(ctx, instanceID, dimension, code, depth)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*datastore.HierarchyNode), nil
+}
+
+// GetHierarchyLeaves returns the leaves below the node for the given code selected by opts. Each page of
+// leaves is cached separately, as a node may have too many leaves to cache them all in one entry.
+func (s *Store) GetHierarchyLeaves(ctx context.Context, instanceID, dimension, code string, opts datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+	hasData := ""
+	if opts.HasData != nil {
+		hasData = strconv.FormatBool(*opts.HasData)
+	}
+
+	v, err := s.get(ctx, key("leaves", instanceID, dimension, code, hasData, strconv.Itoa(opts.Offset), strconv.Itoa(opts.Limit)), func(ctx context.Context) (interface{}, error) {
+		leaves, total, err := s.Storer.GetHierarchyLeaves(ctx, instanceID, dimension, code, opts)
+		return &leafPage{leaves: leaves, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page := v.(*leafPage)
+	return page.leaves, page.total, nil
+}
+
+// leafPage is a cached page of leaves with the number of leaves it was paged from
+type leafPage struct {
+	leaves []*dbmodels.HierarchyElement
+	total  int
+}
+
+// GetHierarchyAncestors returns the ancestors of the node for the given code, from its parent up to the root
+func (s *Store) GetHierarchyAncestors(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	v, err := s.get(ctx, key("ancestors", instanceID, dimension, code), func(ctx context.Context) (interface{}, error) {
+		return s.Storer.GetHierarchyAncestors(ctx, instanceID, dimension, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*dbmodels.HierarchyElement), nil
+}
+
+// GetHierarchySiblings returns the other children of the parent of the node for the given code
+func (s *Store) GetHierarchySiblings(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	v, err := s.get(ctx, key("siblings", instanceID, dimension, code), func(ctx context.Context) (interface{}, error) {
+		return s.Storer.GetHierarchySiblings(ctx, instanceID, dimension, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*dbmodels.HierarchyElement), nil
+}
+
+// SearchHierarchy returns the nodes with labels matching the query, with their breadcrumbs
+func (s *Store) SearchHierarchy(ctx context.Context, instanceID, dimension, query string, limit int) ([]*dbmodels.HierarchyResponse, error) {
+	v, err := s.get(ctx, key("search", instanceID, dimension, strings.ToLower(query), strconv.Itoa(limit)), func(ctx context.Context) (interface{}, error) {
+		return s.Storer.SearchHierarchy(ctx, instanceID, dimension, query, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*dbmodels.HierarchyResponse), nil
+}
+
+// PutHierarchy creates or replaces the hierarchy for the given instance and dimension, then clears the
+// cache. Writes are rare, so every entry is dropped rather than working out which ones the write affects.
+func (s *Store) PutHierarchy(ctx context.Context, instanceID, dimension string, h *datastore.Hierarchy) (bool, error) {
+	writer, ok := s.Storer.(datastore.Writer)
+	if !ok {
+		return false, datastore.ErrReadOnly
+	}
+
+	created, err := writer.PutHierarchy(ctx, instanceID, dimension, h)
+	if err != nil {
+		return false, err
+	}
+
+	s.entries.clear()
+	return created, nil
+}
+
+// Clear drops every cached lookup, for when the underlying store has changed other than by a write
+// through the cache
+func (s *Store) Clear() {
+	s.entries.clear()
+}
+
+// get returns the cached result for key, or makes the lookup and caches its result. Concurrent
+// lookups for the same key share a single call to the underlying store. That call is not cancelled
+// along with the context of the caller that made it, as other callers may still be waiting on it.
+// Lookups are only shared and cached within a generation of the cache, so none made before a write
+// is returned or cached after it.
+func (s *Store) get(ctx context.Context, key string, lookup func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if v, ok := s.entries.get(key); ok {
+		s.hits.Add(1)
+		return v, nil
+	}
+	s.misses.Add(1)
+
+	generation := s.entries.currentGeneration()
+	results := s.group.DoChan(key+"\x00"+strconv.FormatUint(generation, 10), func() (interface{}, error) {
+		v, err := lookup(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		s.entries.addSince(generation, key, v)
+		return v, nil
+	})
+
+	select {
+	case res := <-results:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// key joins the parts of a lookup with a separator that cannot appear in any of them
+func key(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
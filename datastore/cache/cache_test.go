@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("Given a cache in front of a datastore", t, func() {
+		mock := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, instanceID, _ string) (string, error) {
+				if instanceID == "missing" {
+					return "", driver.ErrNotFound
+				}
+				return "codelistID", nil
+			},
+			GetHierarchyElementFunc: func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: code}, 0, nil
+			},
+			GetHierarchyDescendantsFunc: func(_ context.Context, _, _, code string, _ int) (*datastore.HierarchyNode, error) {
+				return &datastore.HierarchyNode{HierarchyElement: dbmodels.HierarchyElement{ID: code}}, nil
+			},
+			GetHierarchyLeavesFunc: func(_ context.Context, _, _, code string, opts datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+				return []*dbmodels.HierarchyElement{{ID: code + "1"}}, 10, nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, _ datastore.WalkFunc) error {
+				return nil
+			},
+		}
+		store := New(mock, 10, time.Minute)
+
+		Convey("When pages of leaves are looked up, each page is cached with the number of leaves", func() {
+			hasData := true
+			for i := 0; i < 2; i++ {
+				leaves, total, err := store.GetHierarchyLeaves(ctx, "instance", "dimension", "a", datastore.LeafOptions{Limit: 1})
+				So(err, ShouldBeNil)
+				So(leaves[0].ID, ShouldEqual, "a1")
+				So(total, ShouldEqual, 10)
+			}
+			store.GetHierarchyLeaves(ctx, "instance", "dimension", "a", datastore.LeafOptions{Offset: 1, Limit: 1})
+			store.GetHierarchyLeaves(ctx, "instance", "dimension", "a", datastore.LeafOptions{HasData: &hasData, Limit: 1})
+
+			So(mock.GetHierarchyLeavesCalls(), ShouldHaveLength, 3)
+		})
+
+		Convey("When the same lookup is made twice, the datastore is only called once", func() {
+			for i := 0; i < 2; i++ {
+				codelistID, err := store.GetHierarchyCodelist(ctx, "instance", "dimension")
+				So(err, ShouldBeNil)
+				So(codelistID, ShouldEqual, "codelistID")
+			}
+
+			So(mock.GetHierarchyCodelistCalls(), ShouldHaveLength, 1)
+			So(store.Stats(), ShouldResemble, Stats{Hits: 1, Misses: 1, Entries: 1})
+		})
+
+		Convey("When lookups differ in any argument, each is made against the datastore", func() {
+			a, _, _ := store.GetHierarchyElement(ctx, "instance", "dimension", "a", datastore.ChildOptions{})
+			b, _, _ := store.GetHierarchyElement(ctx, "instance", "dimension", "b", datastore.ChildOptions{})
+			store.GetHierarchyDescendants(ctx, "instance", "dimension", "a", 1)
+			store.GetHierarchyDescendants(ctx, "instance", "dimension", "a", 2)
+
+			So(a.ID, ShouldEqual, "a")
+			So(b.ID, ShouldEqual, "b")
+			So(mock.GetHierarchyElementCalls(), ShouldHaveLength, 2)
+			So(mock.GetHierarchyDescendantsCalls(), ShouldHaveLength, 2)
+		})
+
+		Convey("When a node is looked up with different child options, they are applied to the one cached node", func() {
+			mock.GetHierarchyElementFunc = func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return &dbmodels.HierarchyResponse{ID: code, Children: []*dbmodels.HierarchyElement{{ID: "b"}, {ID: "a"}}}, 2, nil
+			}
+
+			all, total, _ := store.GetHierarchyElement(ctx, "instance", "dimension", "parent", datastore.ChildOptions{})
+			So(all.Children, ShouldHaveLength, 2)
+			So(total, ShouldEqual, 2)
+
+			page, total, _ := store.GetHierarchyElement(ctx, "instance", "dimension", "parent", datastore.ChildOptions{Sort: datastore.SortByCode, Limit: 1})
+			So(page.Children, ShouldHaveLength, 1)
+			So(page.Children[0].ID, ShouldEqual, "a")
+			So(total, ShouldEqual, 2)
+
+			So(all.Children, ShouldHaveLength, 2)
+			So(mock.GetHierarchyElementCalls(), ShouldHaveLength, 1)
+			So(mock.GetHierarchyElementCalls()[0].Opts, ShouldResemble, datastore.ChildOptions{})
+		})
+
+		Convey("When several codes are looked up, only those not already cached are looked up in the datastore", func() {
+			mock.GetHierarchyElementsFunc = func(_ context.Context, _, _ string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+				elements := make(map[string]*dbmodels.HierarchyResponse)
+				for _, code := range codes {
+					if code != "missing" {
+						elements[code] = &dbmodels.HierarchyResponse{ID: code}
+					}
+				}
+				return elements, nil
+			}
+
+			store.GetHierarchyElement(ctx, "instance", "dimension", "a", datastore.ChildOptions{})
+			elements, err := store.GetHierarchyElements(ctx, "instance", "dimension", []string{"a", "b", "missing"})
+			So(err, ShouldBeNil)
+			So(elements, ShouldHaveLength, 2)
+			So(mock.GetHierarchyElementsCalls()[0].Codes, ShouldResemble, []string{"b", "missing"})
+
+			elements, err = store.GetHierarchyElements(ctx, "instance", "dimension", []string{"a", "b"})
+			So(err, ShouldBeNil)
+			So(elements, ShouldHaveLength, 2)
+			So(mock.GetHierarchyElementsCalls(), ShouldHaveLength, 1)
+		})
+
+		Convey("When a lookup fails, the error is returned and not cached", func() {
+			for i := 0; i < 2; i++ {
+				_, err := store.GetHierarchyCodelist(ctx, "missing", "dimension")
+				So(err, ShouldEqual, driver.ErrNotFound)
+			}
+
+			So(mock.GetHierarchyCodelistCalls(), ShouldHaveLength, 2)
+			So(store.Stats().Entries, ShouldEqual, 0)
+		})
+
+		Convey("When the hierarchy is walked, the call is passed straight to the datastore", func() {
+			So(store.WalkHierarchy(ctx, "instance", "dimension", datastore.WalkOptions{}, nil), ShouldBeNil)
+			So(store.WalkHierarchy(ctx, "instance", "dimension", datastore.WalkOptions{}, nil), ShouldBeNil)
+			So(mock.WalkHierarchyCalls(), ShouldHaveLength, 2)
+		})
+	})
+
+	Convey("Given a datastore that is slow to respond", t, func() {
+		release := make(chan struct{})
+		mock := &datastoretest.StorerMock{
+			GetHierarchyRootFunc: func(_ context.Context, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				<-release
+				return &dbmodels.HierarchyResponse{ID: "root"}, 0, nil
+			},
+		}
+		store := New(mock, 10, time.Minute)
+
+		Convey("When the same lookup is made concurrently, the callers share one call to the datastore", func() {
+			const callers = 5
+			var wg sync.WaitGroup
+			results := make([]*dbmodels.HierarchyResponse, callers)
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					results[i], _, _ = store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{})
+				}(i)
+			}
+
+			for store.Stats().Misses < callers {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			So(mock.GetHierarchyRootCalls(), ShouldHaveLength, 1)
+			for _, res := range results {
+				So(res.ID, ShouldEqual, "root")
+			}
+		})
+
+		Convey("When the caller's context is cancelled, it stops waiting for the lookup", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, _, err := store.GetHierarchyRoot(cancelled, "instance", "dimension", datastore.ChildOptions{})
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			close(release)
+		})
+	})
+
+	Convey("Given a cache in front of a datastore that can be written to", t, func() {
+		mock := &datastoretest.WritableStorerMock{
+			StorerMock: &datastoretest.StorerMock{
+				GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+					return "codelistID", nil
+				},
+			},
+			WriterMock: &datastoretest.WriterMock{
+				PutHierarchyFunc: func(_ context.Context, _, dimension string, _ *datastore.Hierarchy) (bool, error) {
+					if dimension == "invalid" {
+						return false, datastore.ErrInvalidHierarchy
+					}
+					return true, nil
+				},
+			},
+		}
+		store := New(mock, 10, time.Minute)
+
+		_, err := store.GetHierarchyCodelist(ctx, "instance", "dimension")
+		So(err, ShouldBeNil)
+
+		Convey("When a hierarchy is written, it is passed to the datastore and the cache is cleared", func() {
+			created, err := store.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{})
+			So(err, ShouldBeNil)
+			So(created, ShouldBeTrue)
+			So(mock.PutHierarchyCalls(), ShouldHaveLength, 1)
+			So(store.Stats().Entries, ShouldEqual, 0)
+
+			_, err = store.GetHierarchyCodelist(ctx, "instance", "dimension")
+			So(err, ShouldBeNil)
+			So(mock.GetHierarchyCodelistCalls(), ShouldHaveLength, 2)
+		})
+
+		Convey("When a write fails, its error is returned and the cache is kept", func() {
+			_, err := store.PutHierarchy(ctx, "instance", "invalid", &datastore.Hierarchy{})
+			So(err, ShouldEqual, datastore.ErrInvalidHierarchy)
+			So(store.Stats().Entries, ShouldEqual, 1)
+		})
+
+		Convey("When the cache is cleared, lookups are made again", func() {
+			store.Clear()
+			So(store.Stats().Entries, ShouldEqual, 0)
+
+			_, err = store.GetHierarchyCodelist(ctx, "instance", "dimension")
+			So(err, ShouldBeNil)
+			So(mock.GetHierarchyCodelistCalls(), ShouldHaveLength, 2)
+		})
+	})
+
+	Convey("Given a cache in front of a datastore that cannot be written to, writes are rejected", t, func() {
+		store := New(&datastoretest.StorerMock{}, 10, time.Minute)
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{})
+		So(err, ShouldEqual, datastore.ErrReadOnly)
+	})
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a fixed size cache that discards the least recently used entry to make room for a new one.
+// Entries also expire once they are older than the TTL, if one is set.
+//
+// Clearing the cache starts a new generation. Values looked up before then can be added with
+// addSince, which drops them if the cache has been cleared since the lookup started.
+type lru struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	now        func() time.Time
+	order      *list.List
+	entries    map[string]*list.Element
+	evictions  uint64
+	generation uint64
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the value for key, if it is cached and has not expired
+func (c *lru) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// add caches value for key, evicting the least recently used entry if the cache is full
+func (c *lru) add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(key, value)
+}
+
+// addSince caches value for key unless the cache has been cleared since the given generation
+func (c *lru) addSince(generation uint64, key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation == c.generation {
+		c.put(key, value)
+	}
+}
+
+// currentGeneration returns the number of times the cache has been cleared
+func (c *lru) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// clear removes every entry and starts a new generation
+func (c *lru) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element, c.size)
+	c.generation++
+}
+
+func (c *lru) put(key string, value interface{}) {
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// stats returns the number of cached entries and the number evicted to make room for others
+func (c *lru) stats() (entries int, evictions uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len(), c.evictions
+}
+
+func (c *lru) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a cache with room for two entries", t, func() {
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := newLRU(2, time.Minute)
+		c.now = func() time.Time { return now }
+
+		c.add("a", 1)
+		c.add("b", 2)
+
+		Convey("When an entry is added, it can be retrieved", func() {
+			v, ok := c.get("a")
+			So(ok, ShouldBeTrue)
+			So(v, ShouldEqual, 1)
+		})
+
+		Convey("When a third entry is added, the least recently used is evicted", func() {
+			c.get("a")
+			c.add("c", 3)
+
+			_, ok := c.get("b")
+			So(ok, ShouldBeFalse)
+			_, ok = c.get("a")
+			So(ok, ShouldBeTrue)
+
+			entries, evictions := c.stats()
+			So(entries, ShouldEqual, 2)
+			So(evictions, ShouldEqual, 1)
+		})
+
+		Convey("When an existing entry is added again, it is replaced without evicting another", func() {
+			c.add("a", 10)
+
+			v, _ := c.get("a")
+			So(v, ShouldEqual, 10)
+			_, ok := c.get("b")
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("When the TTL has passed, entries are no longer returned", func() {
+			now = now.Add(time.Minute)
+
+			_, ok := c.get("a")
+			So(ok, ShouldBeFalse)
+
+			entries, evictions := c.stats()
+			So(entries, ShouldEqual, 1)
+			So(evictions, ShouldEqual, 0)
+		})
+
+		Convey("When the cache is cleared, every entry is removed and values looked up before then are not added", func() {
+			generation := c.currentGeneration()
+			c.clear()
+
+			entries, evictions := c.stats()
+			So(entries, ShouldEqual, 0)
+			So(evictions, ShouldEqual, 0)
+
+			c.addSince(generation, "a", 1)
+			_, ok := c.get("a")
+			So(ok, ShouldBeFalse)
+
+			c.addSince(c.currentGeneration(), "a", 1)
+			_, ok = c.get("a")
+			So(ok, ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a cache without a TTL, entries do not expire", t, func() {
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := newLRU(1, 0)
+		c.now = func() time.Time { return now }
+
+		c.add("a", 1)
+		now = now.Add(24 * time.Hour)
+
+		_, ok := c.get("a")
+		So(ok, ShouldBeTrue)
+	})
+}
@@ -0,0 +1,91 @@
+package datastore
+
+import (
+	"sort"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+)
+
+// ChildSort is the order in which the children of a node are returned
+type ChildSort string
+
+// The orders children can be sorted in. SortByOrder keeps the order of the hierarchy itself: by
+// each child's order when the children have one, otherwise by label.
+const (
+	SortByOrder ChildSort = "order"
+	SortByLabel ChildSort = "label"
+	SortByCode  ChildSort = "code"
+)
+
+// ChildOptions selects which children of a node are returned with it. The zero value selects every
+// child in the order of the hierarchy.
+type ChildOptions struct {
+	// HasData, when set, only selects children whose has_data flag matches
+	HasData *bool
+	Sort    ChildSort
+	Offset  int
+	// Limit is the maximum number of children to select, or 0 for no limit
+	Limit int
+}
+
+// Matches reports whether a child with the given has_data flag passes the HasData filter
+func (o ChildOptions) Matches(hasData bool) bool {
+	return o.HasData == nil || *o.HasData == hasData
+}
+
+// Less reports whether the child with label a and code a sorts before the one with label b and code b,
+// or false when the hierarchy order is kept. Ties between labels are broken by code.
+func (o ChildOptions) Less(aLabel, aCode, bLabel, bCode string) bool {
+	switch o.Sort {
+	case SortByLabel:
+		if aLabel != bLabel {
+			return aLabel < bLabel
+		}
+		return aCode < bCode
+	case SortByCode:
+		return aCode < bCode
+	default:
+		return false
+	}
+}
+
+// PageBounds returns the start and end of the page selected by Offset and Limit from total children
+func (o ChildOptions) PageBounds(total int) (start, end int) {
+	start = min(o.Offset, total)
+	end = total
+	if o.Limit > 0 && start+o.Limit < end {
+		end = start + o.Limit
+	}
+	return start, end
+}
+
+// Page filters, sorts and pages the given children as selected by the options, returning the page
+// and the number of children that passed the filter. The children given are not modified.
+func (o ChildOptions) Page(children []*dbmodels.HierarchyElement) ([]*dbmodels.HierarchyElement, int) {
+	selected := make([]*dbmodels.HierarchyElement, 0, len(children))
+	for _, child := range children {
+		if o.Matches(child.HasData) {
+			selected = append(selected, child)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return o.Less(selected[i].Label, selected[i].ID, selected[j].Label, selected[j].ID)
+	})
+
+	start, end := o.PageBounds(len(selected))
+	if start == end {
+		return nil, len(selected)
+	}
+
+	return selected[start:end], len(selected)
+}
+
+// Apply returns a copy of res with only the children selected by the options, and the number of
+// children that passed the filter. res is not modified.
+func (o ChildOptions) Apply(res *dbmodels.HierarchyResponse) (*dbmodels.HierarchyResponse, int) {
+	page := *res
+	children, total := o.Page(res.Children)
+	page.Children = children
+	return &page, total
+}
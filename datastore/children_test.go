@@ -0,0 +1,71 @@
+package datastore
+
+import (
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChildOptionsPage(t *testing.T) {
+	t.Parallel()
+
+	children := []*dbmodels.HierarchyElement{
+		{ID: "W92000004", Label: "Wales", HasData: true},
+		{ID: "E92000001", Label: "England", HasData: true},
+		{ID: "S92000003", Label: "Scotland", HasData: false},
+		{ID: "N92000002", Label: "Northern Ireland", HasData: true},
+	}
+
+	ids := func(elements []*dbmodels.HierarchyElement) []string {
+		var codes []string
+		for _, element := range elements {
+			codes = append(codes, element.ID)
+		}
+		return codes
+	}
+
+	Convey("The zero value selects every child in the order given", t, func() {
+		page, total := ChildOptions{}.Page(children)
+		So(ids(page), ShouldResemble, []string{"W92000004", "E92000001", "S92000003", "N92000002"})
+		So(total, ShouldEqual, 4)
+	})
+
+	Convey("Children can be sorted by label or code", t, func() {
+		page, _ := ChildOptions{Sort: SortByLabel}.Page(children)
+		So(ids(page), ShouldResemble, []string{"E92000001", "N92000002", "S92000003", "W92000004"})
+
+		page, _ = ChildOptions{Sort: SortByCode}.Page(children)
+		So(ids(page), ShouldResemble, []string{"E92000001", "N92000002", "S92000003", "W92000004"})
+
+		page, _ = ChildOptions{Sort: SortByOrder}.Page(children)
+		So(ids(page), ShouldResemble, []string{"W92000004", "E92000001", "S92000003", "N92000002"})
+	})
+
+	Convey("Filtering on has_data counts only the matching children", t, func() {
+		hasData := false
+		page, total := ChildOptions{HasData: &hasData}.Page(children)
+		So(ids(page), ShouldResemble, []string{"S92000003"})
+		So(total, ShouldEqual, 1)
+	})
+
+	Convey("Paging selects the children after the offset, up to the limit, after filtering and sorting", t, func() {
+		hasData := true
+		page, total := ChildOptions{HasData: &hasData, Sort: SortByCode, Offset: 1, Limit: 1}.Page(children)
+		So(ids(page), ShouldResemble, []string{"N92000002"})
+		So(total, ShouldEqual, 3)
+
+		page, total = ChildOptions{Offset: 10}.Page(children)
+		So(page, ShouldBeEmpty)
+		So(total, ShouldEqual, 4)
+	})
+
+	Convey("Applying the options to a node does not modify it", t, func() {
+		res := &dbmodels.HierarchyResponse{ID: "K02000001", Children: children}
+		page, total := ChildOptions{Limit: 2}.Apply(res)
+		So(page.ID, ShouldEqual, "K02000001")
+		So(page.Children, ShouldHaveLength, 2)
+		So(total, ShouldEqual, 4)
+		So(res.Children, ShouldHaveLength, 4)
+	})
+}
@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"context"
+	"strings"
 
 	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
 )
@@ -12,6 +13,85 @@ import (
 type Storer interface {
 	Close(ctx context.Context) error
 	GetHierarchyCodelist(ctx context.Context, instanceID, dimension string) (string, error)
-	GetHierarchyRoot(ctx context.Context, instanceID, dimension string) (*dbmodels.HierarchyResponse, error)
-	GetHierarchyElement(ctx context.Context, instanceID, dimension, code string) (*dbmodels.HierarchyResponse, error)
+	// GetHierarchies summarises the hierarchy of every dimension of the instance that has one, ordered by
+	// dimension, or returns driver.ErrNotFound when it has none
+	GetHierarchies(ctx context.Context, instanceID string) ([]*HierarchySummary, error)
+	// GetHierarchyRoot returns the root of the hierarchy with the children selected by opts, and the number of
+	// children matching opts before they are paged
+	GetHierarchyRoot(ctx context.Context, instanceID, dimension string, opts ChildOptions) (*dbmodels.HierarchyResponse, int, error)
+	// GetHierarchyElement returns the node for code with its breadcrumbs and the children selected by opts, and
+	// the number of children matching opts before they are paged
+	GetHierarchyElement(ctx context.Context, instanceID, dimension, code string, opts ChildOptions) (*dbmodels.HierarchyResponse, int, error)
+	// GetHierarchyElements returns the nodes for the given codes keyed by code, leaving out codes that are not in the hierarchy
+	GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (map[string]*dbmodels.HierarchyResponse, error)
+	// GetHierarchyDescendants returns the subtree below code, limited to depth levels (0 for the whole subtree)
+	GetHierarchyDescendants(ctx context.Context, instanceID, dimension, code string, depth int) (*HierarchyNode, error)
+	// GetHierarchyAncestors returns the ancestors of code from its parent up to the root of the hierarchy
+	GetHierarchyAncestors(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error)
+	// GetHierarchySiblings returns the other children of code's parent, in order, or none for the root
+	GetHierarchySiblings(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error)
+	// GetHierarchyLeaves returns the nodes without children in the subtree below code, code itself when it has
+	// none, selected by opts and ordered by code, and the number of leaves matching opts before they are paged
+	GetHierarchyLeaves(ctx context.Context, instanceID, dimension, code string, opts LeafOptions) ([]*dbmodels.HierarchyElement, int, error)
+	// WalkHierarchy calls fn for every node of the part of the hierarchy selected by opts, parents before their
+	// children, or returns driver.ErrNotFound when the code it starts from is not in the hierarchy
+	WalkHierarchy(ctx context.Context, instanceID, dimension string, opts WalkOptions, fn WalkFunc) error
+	// ListHierarchyNodes calls fn for every node in the hierarchy, including nodes that cannot be reached from
+	// the root, ordered by code
+	ListHierarchyNodes(ctx context.Context, instanceID, dimension string, fn ListFunc) error
+	// ListDuplicateCodes returns the codes held by more than one node in the hierarchy, ordered by code
+	ListDuplicateCodes(ctx context.Context, instanceID, dimension string) ([]string, error)
+	// SearchHierarchy returns up to limit nodes, with breadcrumbs, whose labels match the query as ranked by MatchLabel
+	SearchHierarchy(ctx context.Context, instanceID, dimension, query string, limit int) ([]*dbmodels.HierarchyResponse, error)
+}
+
+// HierarchySummary describes the hierarchy of one dimension of an instance
+type HierarchySummary struct {
+	Dimension  string
+	CodelistID string
+	NoOfNodes  int64
+	// Depth is the number of levels below the root, or 0 when the root has no children
+	Depth int
+}
+
+// HierarchyNode is a node in a hierarchy together with its nested children
+type HierarchyNode struct {
+	dbmodels.HierarchyElement
+	Children []*HierarchyNode
+}
+
+// WalkOptions selects the part of a hierarchy that is walked
+type WalkOptions struct {
+	// Code is the node the walk starts from, or "" to walk from the root
+	Code string
+	// Depth limits the walk to that many levels below the node it starts from, or 0 for no limit
+	Depth int
+}
+
+// WalkedNode is a node visited while walking a hierarchy. Depth counts levels below the node the walk started
+// from, which keeps its parent even though the parent is not walked.
+type WalkedNode struct {
+	dbmodels.HierarchyElement
+	ParentID string
+	Depth    int
+}
+
+// WalkFunc is called for each node visited while walking a hierarchy. Returning an error stops the walk.
+type WalkFunc func(node *WalkedNode) error
+
+// ListedNode is a node listed from a hierarchy with the codes of its parents. The root has no parents, and
+// every other node of a well formed hierarchy has exactly one.
+type ListedNode struct {
+	dbmodels.HierarchyElement
+	ParentIDs []string
+}
+
+// ListFunc is called for each node listed from a hierarchy. Returning an error stops the listing.
+type ListFunc func(node *ListedNode) error
+
+// MatchLabel reports whether a label contains a search query, ignoring case, and whether it starts
+// with it. Searches rank labels starting with the query before those that only contain it.
+func MatchLabel(label, query string) (matches, isPrefix bool) {
+	i := strings.Index(strings.ToLower(label), strings.ToLower(query))
+	return i >= 0, i == 0
 }
@@ -11,10 +11,20 @@ import (
 )
 
 var (
-	lockStorerMockClose                sync.RWMutex
-	lockStorerMockGetHierarchyCodelist sync.RWMutex
-	lockStorerMockGetHierarchyElement  sync.RWMutex
-	lockStorerMockGetHierarchyRoot     sync.RWMutex
+	lockStorerMockClose                   sync.RWMutex
+	lockStorerMockGetHierarchies          sync.RWMutex
+	lockStorerMockGetHierarchyAncestors   sync.RWMutex
+	lockStorerMockGetHierarchyCodelist    sync.RWMutex
+	lockStorerMockGetHierarchyDescendants sync.RWMutex
+	lockStorerMockGetHierarchyElement     sync.RWMutex
+	lockStorerMockGetHierarchyElements    sync.RWMutex
+	lockStorerMockGetHierarchyLeaves      sync.RWMutex
+	lockStorerMockGetHierarchyRoot        sync.RWMutex
+	lockStorerMockGetHierarchySiblings    sync.RWMutex
+	lockStorerMockListDuplicateCodes      sync.RWMutex
+	lockStorerMockListHierarchyNodes      sync.RWMutex
+	lockStorerMockSearchHierarchy         sync.RWMutex
+	lockStorerMockWalkHierarchy           sync.RWMutex
 )
 
 // Ensure, that StorerMock does implement Storer.
@@ -23,40 +33,100 @@ var _ datastore.Storer = &StorerMock{}
 
 // StorerMock is a mock implementation of datastore.Storer.
 //
-//     func TestSomethingThatUsesStorer(t *testing.T) {
-//
-//         // make and configure a mocked datastore.Storer
-//         mockedStorer := &StorerMock{
-//             CloseFunc: func(ctx context.Context) error {
-// 	               panic("mock out the Close method")
-//             },
-//             GetHierarchyCodelistFunc: func(ctx context.Context, instanceID string, dimension string) (string, error) {
-// 	               panic("mock out the GetHierarchyCodelist method")
-//             },
-//             GetHierarchyElementFunc: func(ctx context.Context, instanceID string, dimension string, code string) (*models.HierarchyResponse, error) {
-// 	               panic("mock out the GetHierarchyElement method")
-//             },
-//             GetHierarchyRootFunc: func(ctx context.Context, instanceID string, dimension string) (*models.HierarchyResponse, error) {
-// 	               panic("mock out the GetHierarchyRoot method")
-//             },
-//         }
-//
-//         // use mockedStorer in code that requires datastore.Storer
-//         // and then make assertions.
-//
-//     }
+//	    func TestSomethingThatUsesStorer(t *testing.T) {
+//
+//	        // make and configure a mocked datastore.Storer
+//	        mockedStorer := &StorerMock{
+//	            CloseFunc: func(ctx context.Context) error {
+//		               panic("mock out the Close method")
+//	            },
+//	            GetHierarchiesFunc: func(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error) {
+//		               panic("mock out the GetHierarchies method")
+//	            },
+//	            GetHierarchyAncestorsFunc: func(ctx context.Context, instanceID string, dimension string, code string) ([]*models.HierarchyElement, error) {
+//		               panic("mock out the GetHierarchyAncestors method")
+//	            },
+//	            GetHierarchyCodelistFunc: func(ctx context.Context, instanceID string, dimension string) (string, error) {
+//		               panic("mock out the GetHierarchyCodelist method")
+//	            },
+//	            GetHierarchyDescendantsFunc: func(ctx context.Context, instanceID string, dimension string, code string, depth int) (*datastore.HierarchyNode, error) {
+//		               panic("mock out the GetHierarchyDescendants method")
+//	            },
+//	            GetHierarchyElementFunc: func(ctx context.Context, instanceID string, dimension string, code string, opts datastore.ChildOptions) (*models.HierarchyResponse, int, error) {
+//		               panic("mock out the GetHierarchyElement method")
+//	            },
+//	            GetHierarchyElementsFunc: func(ctx context.Context, instanceID string, dimension string, codes []string) (map[string]*models.HierarchyResponse, error) {
+//		               panic("mock out the GetHierarchyElements method")
+//	            },
+//	            GetHierarchyLeavesFunc: func(ctx context.Context, instanceID string, dimension string, code string, opts datastore.LeafOptions) ([]*models.HierarchyElement, int, error) {
+//		               panic("mock out the GetHierarchyLeaves method")
+//	            },
+//	            GetHierarchyRootFunc: func(ctx context.Context, instanceID string, dimension string, opts datastore.ChildOptions) (*models.HierarchyResponse, int, error) {
+//		               panic("mock out the GetHierarchyRoot method")
+//	            },
+//	            GetHierarchySiblingsFunc: func(ctx context.Context, instanceID string, dimension string, code string) ([]*models.HierarchyElement, error) {
+//		               panic("mock out the GetHierarchySiblings method")
+//	            },
+//	            ListDuplicateCodesFunc: func(ctx context.Context, instanceID string, dimension string) ([]string, error) {
+//		               panic("mock out the ListDuplicateCodes method")
+//	            },
+//	            ListHierarchyNodesFunc: func(ctx context.Context, instanceID string, dimension string, fn datastore.ListFunc) error {
+//		               panic("mock out the ListHierarchyNodes method")
+//	            },
+//	            SearchHierarchyFunc: func(ctx context.Context, instanceID string, dimension string, query string, limit int) ([]*models.HierarchyResponse, error) {
+//		               panic("mock out the SearchHierarchy method")
+//	            },
+//	            WalkHierarchyFunc: func(ctx context.Context, instanceID string, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) error {
+//		               panic("mock out the WalkHierarchy method")
+//	            },
+//	        }
+//
+//	        // use mockedStorer in code that requires datastore.Storer
+//	        // and then make assertions.
+//
+//	    }
 type StorerMock struct {
 	// CloseFunc mocks the Close method.
 	CloseFunc func(ctx context.Context) error
 
+	// GetHierarchiesFunc mocks the GetHierarchies method.
+	GetHierarchiesFunc func(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error)
+
+	// GetHierarchyAncestorsFunc mocks the GetHierarchyAncestors method.
+	GetHierarchyAncestorsFunc func(ctx context.Context, instanceID string, dimension string, code string) ([]*models.HierarchyElement, error)
+
 	// GetHierarchyCodelistFunc mocks the GetHierarchyCodelist method.
 	GetHierarchyCodelistFunc func(ctx context.Context, instanceID string, dimension string) (string, error)
 
+	// GetHierarchyDescendantsFunc mocks the GetHierarchyDescendants method.
+	GetHierarchyDescendantsFunc func(ctx context.Context, instanceID string, dimension string, code string, depth int) (*datastore.HierarchyNode, error)
+
 	// GetHierarchyElementFunc mocks the GetHierarchyElement method.
-	GetHierarchyElementFunc func(ctx context.Context, instanceID string, dimension string, code string) (*models.HierarchyResponse, error)
+	GetHierarchyElementFunc func(ctx context.Context, instanceID string, dimension string, code string, opts datastore.ChildOptions) (*models.HierarchyResponse, int, error)
+
+	// GetHierarchyElementsFunc mocks the GetHierarchyElements method.
+	GetHierarchyElementsFunc func(ctx context.Context, instanceID string, dimension string, codes []string) (map[string]*models.HierarchyResponse, error)
+
+	// GetHierarchyLeavesFunc mocks the GetHierarchyLeaves method.
+	GetHierarchyLeavesFunc func(ctx context.Context, instanceID string, dimension string, code string, opts datastore.LeafOptions) ([]*models.HierarchyElement, int, error)
 
 	// GetHierarchyRootFunc mocks the GetHierarchyRoot method.
-	GetHierarchyRootFunc func(ctx context.Context, instanceID string, dimension string) (*models.HierarchyResponse, error)
+	GetHierarchyRootFunc func(ctx context.Context, instanceID string, dimension string, opts datastore.ChildOptions) (*models.HierarchyResponse, int, error)
+
+	// GetHierarchySiblingsFunc mocks the GetHierarchySiblings method.
+	GetHierarchySiblingsFunc func(ctx context.Context, instanceID string, dimension string, code string) ([]*models.HierarchyElement, error)
+
+	// ListDuplicateCodesFunc mocks the ListDuplicateCodes method.
+	ListDuplicateCodesFunc func(ctx context.Context, instanceID string, dimension string) ([]string, error)
+
+	// ListHierarchyNodesFunc mocks the ListHierarchyNodes method.
+	ListHierarchyNodesFunc func(ctx context.Context, instanceID string, dimension string, fn datastore.ListFunc) error
+
+	// SearchHierarchyFunc mocks the SearchHierarchy method.
+	SearchHierarchyFunc func(ctx context.Context, instanceID string, dimension string, query string, limit int) ([]*models.HierarchyResponse, error)
+
+	// WalkHierarchyFunc mocks the WalkHierarchy method.
+	WalkHierarchyFunc func(ctx context.Context, instanceID string, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) error
 
 	// calls tracks calls to the methods.
 	calls struct {
@@ -65,6 +135,24 @@ type StorerMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// GetHierarchies holds details about calls to the GetHierarchies method.
+		GetHierarchies []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
+		// GetHierarchyAncestors holds details about calls to the GetHierarchyAncestors method.
+		GetHierarchyAncestors []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Code is the code argument value.
+			Code string
+		}
 		// GetHierarchyCodelist holds details about calls to the GetHierarchyCodelist method.
 		GetHierarchyCodelist []struct {
 			// Ctx is the ctx argument value.
@@ -74,6 +162,19 @@ type StorerMock struct {
 			// Dimension is the dimension argument value.
 			Dimension string
 		}
+		// GetHierarchyDescendants holds details about calls to the GetHierarchyDescendants method.
+		GetHierarchyDescendants []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Code is the code argument value.
+			Code string
+			// Depth is the depth argument value.
+			Depth int
+		}
 		// GetHierarchyElement holds details about calls to the GetHierarchyElement method.
 		GetHierarchyElement []struct {
 			// Ctx is the ctx argument value.
@@ -84,6 +185,32 @@ type StorerMock struct {
 			Dimension string
 			// Code is the code argument value.
 			Code string
+			// Opts is the opts argument value.
+			Opts datastore.ChildOptions
+		}
+		// GetHierarchyElements holds details about calls to the GetHierarchyElements method.
+		GetHierarchyElements []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Codes is the codes argument value.
+			Codes []string
+		}
+		// GetHierarchyLeaves holds details about calls to the GetHierarchyLeaves method.
+		GetHierarchyLeaves []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Code is the code argument value.
+			Code string
+			// Opts is the opts argument value.
+			Opts datastore.LeafOptions
 		}
 		// GetHierarchyRoot holds details about calls to the GetHierarchyRoot method.
 		GetHierarchyRoot []struct {
@@ -93,6 +220,65 @@ type StorerMock struct {
 			InstanceID string
 			// Dimension is the dimension argument value.
 			Dimension string
+			// Opts is the opts argument value.
+			Opts datastore.ChildOptions
+		}
+		// GetHierarchySiblings holds details about calls to the GetHierarchySiblings method.
+		GetHierarchySiblings []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Code is the code argument value.
+			Code string
+		}
+		// ListDuplicateCodes holds details about calls to the ListDuplicateCodes method.
+		ListDuplicateCodes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+		}
+		// ListHierarchyNodes holds details about calls to the ListHierarchyNodes method.
+		ListHierarchyNodes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Fn is the fn argument value.
+			Fn datastore.ListFunc
+		}
+		// SearchHierarchy holds details about calls to the SearchHierarchy method.
+		SearchHierarchy []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Query is the query argument value.
+			Query string
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// WalkHierarchy holds details about calls to the WalkHierarchy method.
+		WalkHierarchy []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Opts is the opts argument value.
+			Opts datastore.WalkOptions
+			// Fn is the fn argument value.
+			Fn datastore.WalkFunc
 		}
 	}
 }
@@ -115,7 +301,8 @@ func (mock *StorerMock) Close(ctx context.Context) error {
 
 // CloseCalls gets all the calls that were made to Close.
 // Check the length with:
-//     len(mockedStorer.CloseCalls())
+//
+//	len(mockedStorer.CloseCalls())
 func (mock *StorerMock) CloseCalls() []struct {
 	Ctx context.Context
 } {
@@ -128,6 +315,86 @@ func (mock *StorerMock) CloseCalls() []struct {
 	return calls
 }
 
+// GetHierarchies calls GetHierarchiesFunc.
+func (mock *StorerMock) GetHierarchies(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error) {
+	if mock.GetHierarchiesFunc == nil {
+		panic("StorerMock.GetHierarchiesFunc: method is nil but Storer.GetHierarchies was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+	}
+	lockStorerMockGetHierarchies.Lock()
+	mock.calls.GetHierarchies = append(mock.calls.GetHierarchies, callInfo)
+	lockStorerMockGetHierarchies.Unlock()
+	return mock.GetHierarchiesFunc(ctx, instanceID)
+}
+
+// GetHierarchiesCalls gets all the calls that were made to GetHierarchies.
+// Check the length with:
+//
+//	len(mockedStorer.GetHierarchiesCalls())
+func (mock *StorerMock) GetHierarchiesCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+	}
+	lockStorerMockGetHierarchies.RLock()
+	calls = mock.calls.GetHierarchies
+	lockStorerMockGetHierarchies.RUnlock()
+	return calls
+}
+
+// GetHierarchyAncestors calls GetHierarchyAncestorsFunc.
+func (mock *StorerMock) GetHierarchyAncestors(ctx context.Context, instanceID string, dimension string, code string) ([]*models.HierarchyElement, error) {
+	if mock.GetHierarchyAncestorsFunc == nil {
+		panic("StorerMock.GetHierarchyAncestorsFunc: method is nil but Storer.GetHierarchyAncestors was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Code:       code,
+	}
+	lockStorerMockGetHierarchyAncestors.Lock()
+	mock.calls.GetHierarchyAncestors = append(mock.calls.GetHierarchyAncestors, callInfo)
+	lockStorerMockGetHierarchyAncestors.Unlock()
+	return mock.GetHierarchyAncestorsFunc(ctx, instanceID, dimension, code)
+}
+
+// GetHierarchyAncestorsCalls gets all the calls that were made to GetHierarchyAncestors.
+// Check the length with:
+//
+//	len(mockedStorer.GetHierarchyAncestorsCalls())
+func (mock *StorerMock) GetHierarchyAncestorsCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Code       string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+	}
+	lockStorerMockGetHierarchyAncestors.RLock()
+	calls = mock.calls.GetHierarchyAncestors
+	lockStorerMockGetHierarchyAncestors.RUnlock()
+	return calls
+}
+
 // GetHierarchyCodelist calls GetHierarchyCodelistFunc.
 func (mock *StorerMock) GetHierarchyCodelist(ctx context.Context, instanceID string, dimension string) (string, error) {
 	if mock.GetHierarchyCodelistFunc == nil {
@@ -150,7 +417,8 @@ func (mock *StorerMock) GetHierarchyCodelist(ctx context.Context, instanceID str
 
 // GetHierarchyCodelistCalls gets all the calls that were made to GetHierarchyCodelist.
 // Check the length with:
-//     len(mockedStorer.GetHierarchyCodelistCalls())
+//
+//	len(mockedStorer.GetHierarchyCodelistCalls())
 func (mock *StorerMock) GetHierarchyCodelistCalls() []struct {
 	Ctx        context.Context
 	InstanceID string
@@ -167,8 +435,56 @@ func (mock *StorerMock) GetHierarchyCodelistCalls() []struct {
 	return calls
 }
 
+// GetHierarchyDescendants calls GetHierarchyDescendantsFunc.
+func (mock *StorerMock) GetHierarchyDescendants(ctx context.Context, instanceID string, dimension string, code string, depth int) (*datastore.HierarchyNode, error) {
+	if mock.GetHierarchyDescendantsFunc == nil {
+		panic("StorerMock.GetHierarchyDescendantsFunc: method is nil but Storer.GetHierarchyDescendants was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+		Depth      int
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Code:       code,
+		Depth:      depth,
+	}
+	lockStorerMockGetHierarchyDescendants.Lock()
+	mock.calls.GetHierarchyDescendants = append(mock.calls.GetHierarchyDescendants, callInfo)
+	lockStorerMockGetHierarchyDescendants.Unlock()
+	return mock.GetHierarchyDescendantsFunc(ctx, instanceID, dimension, code, depth)
+}
+
+// GetHierarchyDescendantsCalls gets all the calls that were made to GetHierarchyDescendants.
+// Check the length with:
+//
+//	len(mockedStorer.GetHierarchyDescendantsCalls())
+func (mock *StorerMock) GetHierarchyDescendantsCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Code       string
+	Depth      int
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+		Depth      int
+	}
+	lockStorerMockGetHierarchyDescendants.RLock()
+	calls = mock.calls.GetHierarchyDescendants
+	lockStorerMockGetHierarchyDescendants.RUnlock()
+	return calls
+}
+
 // GetHierarchyElement calls GetHierarchyElementFunc.
-func (mock *StorerMock) GetHierarchyElement(ctx context.Context, instanceID string, dimension string, code string) (*models.HierarchyResponse, error) {
+func (mock *StorerMock) GetHierarchyElement(ctx context.Context, instanceID string, dimension string, code string, opts datastore.ChildOptions) (*models.HierarchyResponse, int, error) {
 	if mock.GetHierarchyElementFunc == nil {
 		panic("StorerMock.GetHierarchyElementFunc: method is nil but Storer.GetHierarchyElement was just called")
 	}
@@ -177,32 +493,37 @@ func (mock *StorerMock) GetHierarchyElement(ctx context.Context, instanceID stri
 		InstanceID string
 		Dimension  string
 		Code       string
+		Opts       datastore.ChildOptions
 	}{
 		Ctx:        ctx,
 		InstanceID: instanceID,
 		Dimension:  dimension,
 		Code:       code,
+		Opts:       opts,
 	}
 	lockStorerMockGetHierarchyElement.Lock()
 	mock.calls.GetHierarchyElement = append(mock.calls.GetHierarchyElement, callInfo)
 	lockStorerMockGetHierarchyElement.Unlock()
-	return mock.GetHierarchyElementFunc(ctx, instanceID, dimension, code)
+	return mock.GetHierarchyElementFunc(ctx, instanceID, dimension, code, opts)
 }
 
 // GetHierarchyElementCalls gets all the calls that were made to GetHierarchyElement.
 // Check the length with:
-//     len(mockedStorer.GetHierarchyElementCalls())
+//
+//	len(mockedStorer.GetHierarchyElementCalls())
 func (mock *StorerMock) GetHierarchyElementCalls() []struct {
 	Ctx        context.Context
 	InstanceID string
 	Dimension  string
 	Code       string
+	Opts       datastore.ChildOptions
 } {
 	var calls []struct {
 		Ctx        context.Context
 		InstanceID string
 		Dimension  string
 		Code       string
+		Opts       datastore.ChildOptions
 	}
 	lockStorerMockGetHierarchyElement.RLock()
 	calls = mock.calls.GetHierarchyElement
@@ -210,8 +531,100 @@ func (mock *StorerMock) GetHierarchyElementCalls() []struct {
 	return calls
 }
 
+// GetHierarchyElements calls GetHierarchyElementsFunc.
+func (mock *StorerMock) GetHierarchyElements(ctx context.Context, instanceID string, dimension string, codes []string) (map[string]*models.HierarchyResponse, error) {
+	if mock.GetHierarchyElementsFunc == nil {
+		panic("StorerMock.GetHierarchyElementsFunc: method is nil but Storer.GetHierarchyElements was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Codes      []string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Codes:      codes,
+	}
+	lockStorerMockGetHierarchyElements.Lock()
+	mock.calls.GetHierarchyElements = append(mock.calls.GetHierarchyElements, callInfo)
+	lockStorerMockGetHierarchyElements.Unlock()
+	return mock.GetHierarchyElementsFunc(ctx, instanceID, dimension, codes)
+}
+
+// GetHierarchyElementsCalls gets all the calls that were made to GetHierarchyElements.
+// Check the length with:
+//
+//	len(mockedStorer.GetHierarchyElementsCalls())
+func (mock *StorerMock) GetHierarchyElementsCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Codes      []string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Codes      []string
+	}
+	lockStorerMockGetHierarchyElements.RLock()
+	calls = mock.calls.GetHierarchyElements
+	lockStorerMockGetHierarchyElements.RUnlock()
+	return calls
+}
+
+// GetHierarchyLeaves calls GetHierarchyLeavesFunc.
+func (mock *StorerMock) GetHierarchyLeaves(ctx context.Context, instanceID string, dimension string, code string, opts datastore.LeafOptions) ([]*models.HierarchyElement, int, error) {
+	if mock.GetHierarchyLeavesFunc == nil {
+		panic("StorerMock.GetHierarchyLeavesFunc: method is nil but Storer.GetHierarchyLeaves was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+		Opts       datastore.LeafOptions
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Code:       code,
+		Opts:       opts,
+	}
+	lockStorerMockGetHierarchyLeaves.Lock()
+	mock.calls.GetHierarchyLeaves = append(mock.calls.GetHierarchyLeaves, callInfo)
+	lockStorerMockGetHierarchyLeaves.Unlock()
+	return mock.GetHierarchyLeavesFunc(ctx, instanceID, dimension, code, opts)
+}
+
+// GetHierarchyLeavesCalls gets all the calls that were made to GetHierarchyLeaves.
+// Check the length with:
+//
+//	len(mockedStorer.GetHierarchyLeavesCalls())
+func (mock *StorerMock) GetHierarchyLeavesCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Code       string
+	Opts       datastore.LeafOptions
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+		Opts       datastore.LeafOptions
+	}
+	lockStorerMockGetHierarchyLeaves.RLock()
+	calls = mock.calls.GetHierarchyLeaves
+	lockStorerMockGetHierarchyLeaves.RUnlock()
+	return calls
+}
+
 // GetHierarchyRoot calls GetHierarchyRootFunc.
-func (mock *StorerMock) GetHierarchyRoot(ctx context.Context, instanceID string, dimension string) (*models.HierarchyResponse, error) {
+func (mock *StorerMock) GetHierarchyRoot(ctx context.Context, instanceID string, dimension string, opts datastore.ChildOptions) (*models.HierarchyResponse, int, error) {
 	if mock.GetHierarchyRootFunc == nil {
 		panic("StorerMock.GetHierarchyRootFunc: method is nil but Storer.GetHierarchyRoot was just called")
 	}
@@ -219,32 +632,261 @@ func (mock *StorerMock) GetHierarchyRoot(ctx context.Context, instanceID string,
 		Ctx        context.Context
 		InstanceID string
 		Dimension  string
+		Opts       datastore.ChildOptions
 	}{
 		Ctx:        ctx,
 		InstanceID: instanceID,
 		Dimension:  dimension,
+		Opts:       opts,
 	}
 	lockStorerMockGetHierarchyRoot.Lock()
 	mock.calls.GetHierarchyRoot = append(mock.calls.GetHierarchyRoot, callInfo)
 	lockStorerMockGetHierarchyRoot.Unlock()
-	return mock.GetHierarchyRootFunc(ctx, instanceID, dimension)
+	return mock.GetHierarchyRootFunc(ctx, instanceID, dimension, opts)
 }
 
 // GetHierarchyRootCalls gets all the calls that were made to GetHierarchyRoot.
 // Check the length with:
-//     len(mockedStorer.GetHierarchyRootCalls())
+//
+//	len(mockedStorer.GetHierarchyRootCalls())
 func (mock *StorerMock) GetHierarchyRootCalls() []struct {
 	Ctx        context.Context
 	InstanceID string
 	Dimension  string
+	Opts       datastore.ChildOptions
 } {
 	var calls []struct {
 		Ctx        context.Context
 		InstanceID string
 		Dimension  string
+		Opts       datastore.ChildOptions
 	}
 	lockStorerMockGetHierarchyRoot.RLock()
 	calls = mock.calls.GetHierarchyRoot
 	lockStorerMockGetHierarchyRoot.RUnlock()
 	return calls
 }
+
+// GetHierarchySiblings calls GetHierarchySiblingsFunc.
+func (mock *StorerMock) GetHierarchySiblings(ctx context.Context, instanceID string, dimension string, code string) ([]*models.HierarchyElement, error) {
+	if mock.GetHierarchySiblingsFunc == nil {
+		panic("StorerMock.GetHierarchySiblingsFunc: method is nil but Storer.GetHierarchySiblings was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Code:       code,
+	}
+	lockStorerMockGetHierarchySiblings.Lock()
+	mock.calls.GetHierarchySiblings = append(mock.calls.GetHierarchySiblings, callInfo)
+	lockStorerMockGetHierarchySiblings.Unlock()
+	return mock.GetHierarchySiblingsFunc(ctx, instanceID, dimension, code)
+}
+
+// GetHierarchySiblingsCalls gets all the calls that were made to GetHierarchySiblings.
+// Check the length with:
+//
+//	len(mockedStorer.GetHierarchySiblingsCalls())
+func (mock *StorerMock) GetHierarchySiblingsCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Code       string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Code       string
+	}
+	lockStorerMockGetHierarchySiblings.RLock()
+	calls = mock.calls.GetHierarchySiblings
+	lockStorerMockGetHierarchySiblings.RUnlock()
+	return calls
+}
+
+// ListDuplicateCodes calls ListDuplicateCodesFunc.
+func (mock *StorerMock) ListDuplicateCodes(ctx context.Context, instanceID string, dimension string) ([]string, error) {
+	if mock.ListDuplicateCodesFunc == nil {
+		panic("StorerMock.ListDuplicateCodesFunc: method is nil but Storer.ListDuplicateCodes was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+	}
+	lockStorerMockListDuplicateCodes.Lock()
+	mock.calls.ListDuplicateCodes = append(mock.calls.ListDuplicateCodes, callInfo)
+	lockStorerMockListDuplicateCodes.Unlock()
+	return mock.ListDuplicateCodesFunc(ctx, instanceID, dimension)
+}
+
+// ListDuplicateCodesCalls gets all the calls that were made to ListDuplicateCodes.
+// Check the length with:
+//
+//	len(mockedStorer.ListDuplicateCodesCalls())
+func (mock *StorerMock) ListDuplicateCodesCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+	}
+	lockStorerMockListDuplicateCodes.RLock()
+	calls = mock.calls.ListDuplicateCodes
+	lockStorerMockListDuplicateCodes.RUnlock()
+	return calls
+}
+
+// ListHierarchyNodes calls ListHierarchyNodesFunc.
+func (mock *StorerMock) ListHierarchyNodes(ctx context.Context, instanceID string, dimension string, fn datastore.ListFunc) error {
+	if mock.ListHierarchyNodesFunc == nil {
+		panic("StorerMock.ListHierarchyNodesFunc: method is nil but Storer.ListHierarchyNodes was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Fn         datastore.ListFunc
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Fn:         fn,
+	}
+	lockStorerMockListHierarchyNodes.Lock()
+	mock.calls.ListHierarchyNodes = append(mock.calls.ListHierarchyNodes, callInfo)
+	lockStorerMockListHierarchyNodes.Unlock()
+	return mock.ListHierarchyNodesFunc(ctx, instanceID, dimension, fn)
+}
+
+// ListHierarchyNodesCalls gets all the calls that were made to ListHierarchyNodes.
+// Check the length with:
+//
+//	len(mockedStorer.ListHierarchyNodesCalls())
+func (mock *StorerMock) ListHierarchyNodesCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Fn         datastore.ListFunc
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Fn         datastore.ListFunc
+	}
+	lockStorerMockListHierarchyNodes.RLock()
+	calls = mock.calls.ListHierarchyNodes
+	lockStorerMockListHierarchyNodes.RUnlock()
+	return calls
+}
+
+// SearchHierarchy calls SearchHierarchyFunc.
+func (mock *StorerMock) SearchHierarchy(ctx context.Context, instanceID string, dimension string, query string, limit int) ([]*models.HierarchyResponse, error) {
+	if mock.SearchHierarchyFunc == nil {
+		panic("StorerMock.SearchHierarchyFunc: method is nil but Storer.SearchHierarchy was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Query      string
+		Limit      int
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Query:      query,
+		Limit:      limit,
+	}
+	lockStorerMockSearchHierarchy.Lock()
+	mock.calls.SearchHierarchy = append(mock.calls.SearchHierarchy, callInfo)
+	lockStorerMockSearchHierarchy.Unlock()
+	return mock.SearchHierarchyFunc(ctx, instanceID, dimension, query, limit)
+}
+
+// SearchHierarchyCalls gets all the calls that were made to SearchHierarchy.
+// Check the length with:
+//
+//	len(mockedStorer.SearchHierarchyCalls())
+func (mock *StorerMock) SearchHierarchyCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Query      string
+	Limit      int
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Query      string
+		Limit      int
+	}
+	lockStorerMockSearchHierarchy.RLock()
+	calls = mock.calls.SearchHierarchy
+	lockStorerMockSearchHierarchy.RUnlock()
+	return calls
+}
+
+// WalkHierarchy calls WalkHierarchyFunc.
+func (mock *StorerMock) WalkHierarchy(ctx context.Context, instanceID string, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) error {
+	if mock.WalkHierarchyFunc == nil {
+		panic("StorerMock.WalkHierarchyFunc: method is nil but Storer.WalkHierarchy was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Opts       datastore.WalkOptions
+		Fn         datastore.WalkFunc
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Opts:       opts,
+		Fn:         fn,
+	}
+	lockStorerMockWalkHierarchy.Lock()
+	mock.calls.WalkHierarchy = append(mock.calls.WalkHierarchy, callInfo)
+	lockStorerMockWalkHierarchy.Unlock()
+	return mock.WalkHierarchyFunc(ctx, instanceID, dimension, opts, fn)
+}
+
+// WalkHierarchyCalls gets all the calls that were made to WalkHierarchy.
+// Check the length with:
+//
+//	len(mockedStorer.WalkHierarchyCalls())
+func (mock *StorerMock) WalkHierarchyCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	Dimension  string
+	Opts       datastore.WalkOptions
+	Fn         datastore.WalkFunc
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		Dimension  string
+		Opts       datastore.WalkOptions
+		Fn         datastore.WalkFunc
+	}
+	lockStorerMockWalkHierarchy.RLock()
+	calls = mock.calls.WalkHierarchy
+	lockStorerMockWalkHierarchy.RUnlock()
+	return calls
+}
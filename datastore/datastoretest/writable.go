@@ -0,0 +1,15 @@
+package datastoretest
+
+import "github.com/ONSdigital/dp-hierarchy-api/datastore"
+
+var (
+	_ datastore.Storer = &WritableStorerMock{}
+	_ datastore.Writer = &WritableStorerMock{}
+)
+
+// WritableStorerMock combines the Storer and Writer mocks, for code that is given a Storer and
+// writes through it when it is also a Writer
+type WritableStorerMock struct {
+	*StorerMock
+	*WriterMock
+}
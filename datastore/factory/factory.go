@@ -0,0 +1,39 @@
+// Package factory creates the datastore selected by configuration, for the service and the command line tool
+package factory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-graph/v2/graph"
+	"github.com/ONSdigital/dp-hierarchy-api/config"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/graphstore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+)
+
+// New creates the datastore selected by configuration. The graph DB is also returned when it is in use,
+// as it needs health checking and its errors consuming
+func New(ctx context.Context, cfg *config.Config) (datastore.Storer, *graph.DB, error) {
+	switch cfg.DatastoreType {
+	case config.DatastoreTypeGraph:
+		graphDB, err := graph.NewHierarchyStore(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		store, err := graphstore.New(graphDB)
+		if err != nil {
+			graphDB.Close(ctx)
+			return nil, nil, err
+		}
+		return store, graphDB, nil
+	case config.DatastoreTypeMemory:
+		store, err := memory.NewFromFile(cfg.DatastoreFixturePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported datastore type %q", cfg.DatastoreType)
+	}
+}
@@ -0,0 +1,37 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/config"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("When the memory datastore is configured, the fixture file is loaded without a graph DB", t, func() {
+		store, graphDB, err := New(ctx, &config.Config{
+			DatastoreType:        config.DatastoreTypeMemory,
+			DatastoreFixturePath: "../memory/testdata/hierarchies.json",
+		})
+		So(err, ShouldBeNil)
+		So(graphDB, ShouldBeNil)
+		So(store, ShouldHaveSameTypeAs, &memory.Store{})
+	})
+
+	Convey("When the memory datastore's fixture file cannot be read, an error is returned", t, func() {
+		_, _, err := New(ctx, &config.Config{DatastoreType: config.DatastoreTypeMemory, DatastoreFixturePath: "missing.json"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("When an unknown datastore is configured, an error is returned", t, func() {
+		_, _, err := New(ctx, &config.Config{DatastoreType: "paper"})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `unsupported datastore type "paper"`)
+	})
+}
@@ -0,0 +1,782 @@
+package graphstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/ONSdigital/dp-graph/v2/graph"
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-graph/v2/neptune"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+// maxConcurrentLookups limits the number of node lookups a single traversal sends to the graph at once
+const maxConcurrentLookups = 10
+
+const (
+	// maxWriteBatch limits the number of nodes added to the graph by a single statement
+	maxWriteBatch = 50
+	// maxListPage limits the number of nodes read by each statement listing a hierarchy
+	maxListPage = 1000
+)
+
+var (
+	_ datastore.Storer = &Store{}
+	_ datastore.Writer = &Store{}
+)
+
+// errUnsupportedDriver is returned when the graph database is not Neptune, which the store needs to run its
+// own statements
+var errUnsupportedDriver = errors.New("the graph datastore requires the neptune graph driver")
+
+// Store implements datastore.Storer on top of a graph database. Single node lookups are passed
+// straight to the graph driver, and traversals are built from those lookups. Lookups the driver has
+// no query for are made with the store's own Gremlin statements.
+type Store struct {
+	driver.Driver
+	driver.Hierarchy
+	pool gremlinPool
+}
+
+// New creates a Store using the hierarchy functionality of the given graph database, which must use
+// the Neptune driver
+func New(db *graph.DB) (*Store, error) {
+	neptuneDB, ok := db.Driver.(*neptune.NeptuneDB)
+	if !ok {
+		return nil, errUnsupportedDriver
+	}
+
+	pool, ok := neptuneDB.Pool.(gremlinPool)
+	if !ok {
+		return nil, errUnsupportedDriver
+	}
+
+	return &Store{
+		Driver:    db.Driver,
+		Hierarchy: db.Hierarchy,
+		pool:      pool,
+	}, nil
+}
+
+// GetHierarchies reads the dimensions of the instance from its instance node, and summarises the hierarchy
+// of each dimension that has one, counting its nodes and levels in the graph
+func (s *Store) GetHierarchies(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error) {
+	dimensions, err := s.stringList(ctx, instanceDimensions(instanceID))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(dimensions)
+	dimensions = slices.Compact(dimensions)
+
+	var summaries []*datastore.HierarchySummary
+	for _, dimension := range dimensions {
+		nodes := hierarchyNodes(instanceID, dimension)
+
+		codelists, err := s.stringList(ctx, nodes+".limit(1).values('code_list')")
+		if err != nil {
+			return nil, err
+		}
+		if len(codelists) == 0 {
+			continue
+		}
+
+		summary := &datastore.HierarchySummary{Dimension: dimension, CodelistID: codelists[0]}
+
+		if summary.NoOfNodes, err = s.count(ctx, nodes+".count()"); err != nil {
+			return nil, err
+		}
+
+		depth, err := s.count(ctx, nodes+leafDepths+".max()")
+		if err != nil {
+			return nil, err
+		}
+		summary.Depth = int(depth)
+
+		summaries = append(summaries, summary)
+	}
+
+	if len(summaries) == 0 {
+		return nil, driver.ErrNotFound
+	}
+
+	return summaries, nil
+}
+
+// PutHierarchy replaces the nodes of the hierarchy in the graph. The old nodes are read and dropped, and the
+// new nodes are added and then linked to their parents in batches, and their children are counted. The nodes
+// and edges written are then counted to check the write. If it fails part way, or the graph does not hold what
+// was written, the nodes written are dropped and the old nodes are written back before the error is returned.
+// The dimension is also recorded on the instance node, if there is one, so that GetHierarchies finds the
+// hierarchy. Lookups made while the hierarchy is replaced see part of it.
+func (s *Store) PutHierarchy(ctx context.Context, instanceID, dimension string, h *datastore.Hierarchy) (bool, error) {
+	if err := h.Validate(); err != nil {
+		return false, fmt.Errorf("%w: %w", datastore.ErrInvalidHierarchy, err)
+	}
+
+	nodes := hierarchyNodes(instanceID, dimension)
+	existing, err := s.count(ctx, nodes+".limit(1).count()")
+	if err != nil {
+		return false, err
+	}
+
+	var previous *snapshot
+	if existing > 0 {
+		if previous, err = s.readHierarchy(ctx, instanceID, dimension); err != nil {
+			return false, err
+		}
+		if err := s.execute(ctx, nodes+".drop()"); err != nil {
+			return false, err
+		}
+	}
+
+	links := make([]datastore.Node, 0, len(h.Nodes))
+	for _, n := range h.Nodes {
+		if n.Parent != "" {
+			links = append(links, n)
+		}
+	}
+
+	if err := s.writeHierarchy(ctx, instanceID, dimension, &snapshot{codelistID: h.CodelistID, nodes: h.Nodes, links: links}); err != nil {
+		return false, s.restoreHierarchy(ctx, instanceID, dimension, previous, err)
+	}
+
+	if err := s.execute(ctx, "g.V().hasId("+quote("_"+instanceID+"_Instance")+").property(set,'dimensions',"+quote(dimension)+")"); err != nil {
+		return false, err
+	}
+
+	return existing == 0, nil
+}
+
+// snapshot is the content of a hierarchy as written to the graph: a vertex for each of nodes, and a hasParent
+// edge from each of links to its parent
+type snapshot struct {
+	codelistID string
+	nodes      []datastore.Node
+	links      []datastore.Node
+}
+
+// readHierarchy reads the nodes of the hierarchy in the graph, with a link to each of their parents, so that
+// they can be written back
+func (s *Store) readHierarchy(ctx context.Context, instanceID, dimension string) (*snapshot, error) {
+	codelists, err := s.stringList(ctx, hierarchyNodes(instanceID, dimension)+".limit(1).values('code_list')")
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{}
+	if len(codelists) > 0 {
+		snap.codelistID = codelists[0]
+	}
+
+	err = s.ListHierarchyNodes(ctx, instanceID, dimension, func(listed *datastore.ListedNode) error {
+		n := datastore.Node{Code: listed.ID, Label: listed.Label, Order: listed.Order, HasData: listed.HasData}
+		snap.nodes = append(snap.nodes, n)
+		for _, parent := range listed.ParentIDs {
+			n.Parent = parent
+			snap.links = append(snap.links, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// writeHierarchy adds the nodes of the snapshot to the graph and links them to their parents, in batches,
+// then counts the children of every node. The nodes and edges of the hierarchy are then counted to check
+// that the graph holds exactly what was written.
+func (s *Store) writeHierarchy(ctx context.Context, instanceID, dimension string, snap *snapshot) error {
+	for batch := range slices.Chunk(snap.nodes, maxWriteBatch) {
+		if err := s.execute(ctx, addNodes(instanceID, dimension, snap.codelistID, batch)); err != nil {
+			return err
+		}
+	}
+
+	for batch := range slices.Chunk(snap.links, maxWriteBatch) {
+		if err := s.execute(ctx, addParentEdges(instanceID, dimension, batch)); err != nil {
+			return err
+		}
+	}
+
+	written := hierarchyNodes(instanceID, dimension)
+	if err := s.execute(ctx, written+countChildren); err != nil {
+		return err
+	}
+
+	vertices, err := s.count(ctx, written+".count()")
+	if err != nil {
+		return err
+	}
+	edges, err := s.count(ctx, written+".outE('hasParent').count()")
+	if err != nil {
+		return err
+	}
+	if vertices != int64(len(snap.nodes)) || edges != int64(len(snap.links)) {
+		return fmt.Errorf("hierarchy written with %d nodes and %d parent edges but the graph holds %d and %d", len(snap.nodes), len(snap.links), vertices, edges)
+	}
+
+	return nil
+}
+
+// restoreHierarchy drops the nodes written by a failed write and writes back the previous hierarchy, if there
+// was one, returning the error of the write along with any error restoring it. The previous hierarchy is
+// restored even if ctx has been cancelled.
+func (s *Store) restoreHierarchy(ctx context.Context, instanceID, dimension string, previous *snapshot, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	err := s.execute(ctx, hierarchyNodes(instanceID, dimension)+".drop()")
+	if err == nil && previous != nil {
+		err = s.writeHierarchy(ctx, instanceID, dimension, previous)
+	}
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("restoring the previous hierarchy: %w", err))
+	}
+
+	return cause
+}
+
+// GetHierarchyRoot returns the root of the hierarchy with the children selected by opts, which the
+// graph filters, sorts and pages
+func (s *Store) GetHierarchyRoot(ctx context.Context, instanceID, dimension string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	vertices, err := s.vertices(ctx, rootNode(instanceID, dimension))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case len(vertices) == 0:
+		return nil, 0, driver.ErrNotFound
+	case len(vertices) > 1:
+		return nil, 0, driver.ErrMultipleFound
+	}
+
+	root, err := toNode(vertices[0])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := &dbmodels.HierarchyResponse{ID: root.ID, Label: root.Label, NoOfChildren: root.NoOfChildren, Order: root.Order, HasData: root.HasData}
+
+	var total int
+	if res.Children, total, err = s.children(ctx, instanceID, dimension, root, opts); err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
+
+// GetHierarchyElement returns the node for code with its breadcrumbs, read along with the node, and the
+// children selected by opts, which the graph filters, sorts and pages as for GetHierarchyRoot
+func (s *Store) GetHierarchyElement(ctx context.Context, instanceID, dimension, code string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	nodes := codeNode(instanceID, dimension, code)
+	g, err := s.subgraph(ctx, nodes+selfAndAncestors, nodes+ancestorEdges)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, ok := g.byCode[code]
+	if !ok {
+		return nil, 0, driver.ErrNotFound
+	}
+
+	res := g.response(n)
+
+	var total int
+	if res.Children, total, err = s.children(ctx, instanceID, dimension, n, opts); err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
+
+// children returns the children of parent selected by opts, and the number of children matching opts before
+// they are paged. The children are only counted separately when the page may not hold all of them.
+func (s *Store) children(ctx context.Context, instanceID, dimension string, parent *node, opts datastore.ChildOptions) ([]*dbmodels.HierarchyElement, int, error) {
+	if parent.NoOfChildren == 0 {
+		return nil, 0, nil
+	}
+
+	nodes := codeNode(instanceID, dimension, parent.ID)
+	vertices, err := s.vertices(ctx, nodes+selectedChildren(opts))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var children []*dbmodels.HierarchyElement
+	for _, v := range vertices {
+		child, err := toNode(v)
+		if err != nil {
+			return nil, 0, err
+		}
+		children = append(children, child.element())
+	}
+
+	if opts.Offset == 0 && (opts.Limit == 0 || len(children) < opts.Limit) {
+		return children, len(children), nil
+	}
+
+	total, err := s.count(ctx, nodes+matchingChildren(opts)+".count()")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return children, int(total), nil
+}
+
+// GetHierarchyLeaves counts the leaves below code in the graph, then reads the page of them selected by opts.
+// The code is only looked up separately when it has no matching leaves, to tell whether it is in the hierarchy.
+func (s *Store) GetHierarchyLeaves(ctx context.Context, instanceID, dimension, code string, opts datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+	nodes := codeNode(instanceID, dimension, code)
+
+	total, err := s.count(ctx, nodes+matchingLeaves(opts)+".count()")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		found, err := s.count(ctx, nodes+".count()")
+		if err != nil {
+			return nil, 0, err
+		}
+		if found == 0 {
+			return nil, 0, driver.ErrNotFound
+		}
+		return nil, 0, nil
+	}
+
+	if int64(opts.Offset) >= total {
+		return nil, int(total), nil
+	}
+
+	end := -1
+	if opts.Limit > 0 {
+		end = opts.Offset + opts.Limit
+	}
+	vertices, err := s.vertices(ctx, nodes+matchingLeaves(opts)+codeOrder+fmt.Sprintf(".range(%d,%d)", opts.Offset, end))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	leaves := make([]*dbmodels.HierarchyElement, 0, len(vertices))
+	for _, v := range vertices {
+		leaf, err := toNode(v)
+		if err != nil {
+			return nil, 0, err
+		}
+		leaves = append(leaves, leaf.element())
+	}
+
+	return leaves, int(total), nil
+}
+
+// GetHierarchyElements reads the nodes for the given codes, with their children and ancestors, in one
+// statement and the edges between them in another. Codes that are not in the hierarchy are left out.
+func (s *Store) GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+	elements := make(map[string]*dbmodels.HierarchyResponse, len(codes))
+	if len(codes) == 0 {
+		return elements, nil
+	}
+
+	nodes := codeNodes(instanceID, dimension, codes)
+	g, err := s.subgraph(ctx, nodes+selfChildrenAndAncestors, nodes+childAndAncestorEdges)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, code := range codes {
+		if n, ok := g.byCode[code]; ok {
+			elements[code] = g.response(n)
+		}
+	}
+
+	return elements, nil
+}
+
+// GetHierarchyDescendants walks down from the node for code one level at a time, looking up the
+// nodes on each level concurrently. Nodes already seen are not expanded again, so a cycle in the
+// graph cannot cause an endless walk.
+func (s *Store) GetHierarchyDescendants(ctx context.Context, instanceID, dimension, code string, depth int) (*datastore.HierarchyNode, error) {
+	res, err := s.Hierarchy.GetHierarchyElement(ctx, instanceID, dimension, code)
+	if err != nil {
+		return nil, err
+	}
+
+	top := &datastore.HierarchyNode{HierarchyElement: toElement(res)}
+	top.Children = toNodes(res.Children)
+	seen := map[string]bool{top.ID: true}
+
+	level := top.Children
+	for d := 1; (depth == 0 || d < depth) && len(level) > 0; d++ {
+		var expand []*datastore.HierarchyNode
+		for _, n := range level {
+			if n.NoOfChildren > 0 && !seen[n.ID] {
+				seen[n.ID] = true
+				expand = append(expand, n)
+			}
+		}
+
+		responses, err := s.getElements(ctx, instanceID, dimension, nodeIDs(expand))
+		if err != nil {
+			return nil, err
+		}
+
+		var next []*datastore.HierarchyNode
+		for i, n := range expand {
+			n.Children = toNodes(responses[i].Children)
+			next = append(next, n.Children...)
+		}
+		level = next
+	}
+
+	return top, nil
+}
+
+// GetHierarchyAncestors returns the breadcrumbs of the node for code, which the graph driver looks
+// up along with the node
+func (s *Store) GetHierarchyAncestors(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	res, err := s.Hierarchy.GetHierarchyElement(ctx, instanceID, dimension, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Breadcrumbs, nil
+}
+
+// GetHierarchySiblings looks up the parent of the node for code, found from its breadcrumbs, and
+// returns the parent's other children in the order the graph driver gives them
+func (s *Store) GetHierarchySiblings(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	res, err := s.Hierarchy.GetHierarchyElement(ctx, instanceID, dimension, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Breadcrumbs) == 0 {
+		return nil, nil
+	}
+
+	parent, err := s.Hierarchy.GetHierarchyElement(ctx, instanceID, dimension, res.Breadcrumbs[0].ID)
+	if err != nil {
+		return nil, err
+	}
+
+	siblings := make([]*dbmodels.HierarchyElement, 0, len(parent.Children))
+	for _, child := range parent.Children {
+		if child.ID != code {
+			siblings = append(siblings, child)
+		}
+	}
+
+	return siblings, nil
+}
+
+// WalkHierarchy visits the hierarchy depth first, holding only the nodes still to be visited in memory. The
+// walk follows the hasParent edges between vertices rather than the stored number of children of each node,
+// so a node with a wrong count is still expanded and every vertex holding a duplicated code is visited. The
+// children of each node are read along with the edges to their own children, which tell which of them to
+// expand in turn. Vertices already seen are visited again but not expanded.
+func (s *Store) WalkHierarchy(ctx context.Context, instanceID, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) error {
+	start := rootNode(instanceID, dimension)
+	if opts.Code != "" {
+		start = codeNode(instanceID, dimension, opts.Code)
+	}
+
+	vertices, err := s.vertices(ctx, start+codeOrder+".limit(1)")
+	if err != nil {
+		return err
+	}
+	if len(vertices) == 0 {
+		return driver.ErrNotFound
+	}
+
+	top, err := toNode(vertices[0])
+	if err != nil {
+		return err
+	}
+
+	first := &walkStep{WalkedNode: datastore.WalkedNode{HierarchyElement: top.HierarchyElement}, vertexID: top.vertexID, hasChildren: true}
+	if opts.Code != "" {
+		parents, err := s.stringList(ctx, vertexNode(top.vertexID)+".out('hasParent').values('code')")
+		if err != nil {
+			return err
+		}
+		if len(parents) > 0 {
+			first.ParentID = slices.Min(parents)
+		}
+	}
+
+	seen := make(map[string]bool)
+	stack := []*walkStep{first}
+	for len(stack) > 0 {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if err = fn(&n.WalkedNode); err != nil {
+			return err
+		}
+
+		if !n.hasChildren || seen[n.vertexID] || (opts.Depth > 0 && n.Depth >= opts.Depth) {
+			continue
+		}
+		seen[n.vertexID] = true
+
+		// the children at the depth the walk stops at are not expanded, so their edges are not needed
+		leaves := opts.Depth > 0 && n.Depth+1 >= opts.Depth
+		children, err := s.walkChildren(ctx, n, leaves)
+		if err != nil {
+			return err
+		}
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+
+	return nil
+}
+
+// walkStep is a node still to be visited by a walk, with its vertex and whether the graph has children below it
+type walkStep struct {
+	datastore.WalkedNode
+	vertexID    string
+	hasChildren bool
+}
+
+// walkChildren reads the children of a walked node in the order of the hierarchy. Unless leaves is set, the
+// hasParent edges to their own children are also read, to tell which of them have children.
+func (s *Store) walkChildren(ctx context.Context, parent *walkStep, leaves bool) ([]*walkStep, error) {
+	children := vertexNode(parent.vertexID) + ".in('hasParent')"
+
+	vertices, err := s.vertices(ctx, children+hierarchyOrder)
+	if err != nil || len(vertices) == 0 {
+		return nil, err
+	}
+
+	hasChildren := make(map[string]bool)
+	if !leaves {
+		edges, err := s.edges(ctx, children+".inE('hasParent')")
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			hasChildren[e.Value.InV] = true
+		}
+	}
+
+	steps := make([]*walkStep, 0, len(vertices))
+	for _, v := range vertices {
+		child, err := toNode(v)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, &walkStep{
+			WalkedNode:  datastore.WalkedNode{HierarchyElement: child.HierarchyElement, ParentID: parent.ID, Depth: parent.Depth + 1},
+			vertexID:    child.vertexID,
+			hasChildren: hasChildren[child.vertexID],
+		})
+	}
+
+	return steps, nil
+}
+
+// ListDuplicateCodes groups the hierarchy's nodes by code in the graph, returning the codes of groups with more
+// than one node
+func (s *Store) ListDuplicateCodes(ctx context.Context, instanceID, dimension string) ([]string, error) {
+	codes, err := s.stringList(ctx, hierarchyNodes(instanceID, dimension)+duplicateCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// ListHierarchyNodes reads the hierarchy's nodes a page at a time, ordered by code, along with the hasParent
+// edges from them and the parents those edges lead to. Nodes that cannot be reached from the root are listed
+// like any other.
+func (s *Store) ListHierarchyNodes(ctx context.Context, instanceID, dimension string, fn datastore.ListFunc) error {
+	for offset := 0; ; offset += maxListPage {
+		page := hierarchyNodes(instanceID, dimension) + codeOrder + fmt.Sprintf(".range(%d,%d)", offset, offset+maxListPage)
+
+		vertices, err := s.vertices(ctx, page)
+		if err != nil {
+			return err
+		}
+		if len(vertices) == 0 {
+			if offset == 0 {
+				return driver.ErrNotFound
+			}
+			return nil
+		}
+
+		parents, err := s.parentCodes(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range vertices {
+			n, err := toNode(v)
+			if err != nil {
+				return err
+			}
+			if err := fn(&datastore.ListedNode{HierarchyElement: n.HierarchyElement, ParentIDs: parents[n.vertexID]}); err != nil {
+				return err
+			}
+		}
+
+		if len(vertices) < maxListPage {
+			return nil
+		}
+	}
+}
+
+// parentCodes returns the codes of the parents of the nodes of a traversal, keyed by the vertex ID of each node
+func (s *Store) parentCodes(ctx context.Context, nodes string) (map[string][]string, error) {
+	vertices, err := s.vertices(ctx, nodes+".out('hasParent').dedup()")
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := s.edges(ctx, nodes+".outE('hasParent')")
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[string]string, len(vertices))
+	for _, v := range vertices {
+		n, err := toNode(v)
+		if err != nil {
+			return nil, err
+		}
+		codes[n.vertexID] = n.ID
+	}
+
+	parents := make(map[string][]string, len(edges))
+	for _, e := range edges {
+		if code, ok := codes[e.Value.InV]; ok {
+			parents[e.Value.OutV] = append(parents[e.Value.OutV], code)
+		}
+	}
+	for _, p := range parents {
+		sort.Strings(p)
+	}
+
+	return parents, nil
+}
+
+// SearchHierarchy matches labels in the graph, finding the codes of up to limit labels starting with the
+// query and, if there are fewer than limit, of labels only containing it. Each rank is ordered by label.
+// The matching nodes are then read with their ancestors to give their breadcrumbs.
+func (s *Store) SearchHierarchy(ctx context.Context, instanceID, dimension, query string, limit int) ([]*dbmodels.HierarchyResponse, error) {
+	nodes := hierarchyNodes(instanceID, dimension)
+
+	codes, err := s.stringList(ctx, nodes+startingWith(query)+labelOrder+fmt.Sprintf(".limit(%d).values('code')", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(codes) < limit {
+		contained, err := s.stringList(ctx, nodes+containing(query)+labelOrder+fmt.Sprintf(".limit(%d).values('code')", limit-len(codes)))
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, contained...)
+	}
+
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	matched := codeNodes(instanceID, dimension, codes)
+	g, err := s.subgraph(ctx, matched+selfAndAncestors, matched+ancestorEdges)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*dbmodels.HierarchyResponse, 0, len(codes))
+	for _, code := range codes {
+		if n, ok := g.byCode[code]; ok {
+			res := g.response(n)
+			res.Children = nil
+			results = append(results, res)
+		}
+	}
+
+	return results, nil
+}
+
+// getElements looks up the nodes for the given codes, failing if any lookup fails
+func (s *Store) getElements(ctx context.Context, instanceID, dimension string, codes []string) ([]*dbmodels.HierarchyResponse, error) {
+	results, errs := s.lookupElements(ctx, instanceID, dimension, codes)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// lookupElements looks up the nodes for the given codes, with at most maxConcurrentLookups in flight.
+// The result and error of each lookup are returned in the same order as the codes.
+func (s *Store) lookupElements(ctx context.Context, instanceID, dimension string, codes []string) ([]*dbmodels.HierarchyResponse, []error) {
+	results := make([]*dbmodels.HierarchyResponse, len(codes))
+	errs := make([]error, len(codes))
+	sem := make(chan struct{}, maxConcurrentLookups)
+
+	var wg sync.WaitGroup
+	for i, code := range codes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, code string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = s.Hierarchy.GetHierarchyElement(ctx, instanceID, dimension, code)
+		}(i, code)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+func toElement(res *dbmodels.HierarchyResponse) dbmodels.HierarchyElement {
+	return dbmodels.HierarchyElement{
+		ID:           res.ID,
+		Label:        res.Label,
+		NoOfChildren: res.NoOfChildren,
+		Order:        res.Order,
+		HasData:      res.HasData,
+	}
+}
+
+func toNodes(elements []*dbmodels.HierarchyElement) []*datastore.HierarchyNode {
+	if len(elements) == 0 {
+		return nil
+	}
+
+	nodes := make([]*datastore.HierarchyNode, 0, len(elements))
+	for _, element := range elements {
+		nodes = append(nodes, &datastore.HierarchyNode{HierarchyElement: *element})
+	}
+	return nodes
+}
+
+func nodeIDs(nodes []*datastore.HierarchyNode) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
@@ -0,0 +1,919 @@
+package graphstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/validate"
+	"github.com/ONSdigital/graphson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeHierarchy serves hierarchy nodes from a map of code to node, keyed like the graph driver
+type fakeHierarchy struct {
+	driver.Hierarchy
+	elements map[string]*dbmodels.HierarchyResponse
+	root     string
+	err      error
+	calls    *int
+}
+
+func (f *fakeHierarchy) GetHierarchyRoot(ctx context.Context, instanceID, dimension string) (*dbmodels.HierarchyResponse, error) {
+	if f.root == "" {
+		return nil, driver.ErrNotFound
+	}
+	return f.GetHierarchyElement(ctx, instanceID, dimension, f.root)
+}
+
+func (f *fakeHierarchy) GetHierarchyElement(ctx context.Context, instanceID, dimension, code string) (*dbmodels.HierarchyResponse, error) {
+	if f.calls != nil {
+		*f.calls++
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	res, ok := f.elements[code]
+	if !ok {
+		return nil, driver.ErrNotFound
+	}
+	return res, nil
+}
+
+var labels = map[string]string{
+	"root": "United Kingdom",
+	"a":    "England",
+	"a1":   "London",
+	"a11":  "City of London",
+	"b":    "Wales",
+}
+
+func element(code string, children ...string) *dbmodels.HierarchyResponse {
+	res := &dbmodels.HierarchyResponse{ID: code, Label: labels[code], NoOfChildren: int64(len(children))}
+	for _, child := range children {
+		res.Children = append(res.Children, &dbmodels.HierarchyElement{ID: child, Label: labels[child]})
+	}
+	return res
+}
+
+// newTestStore creates a store over the tree root -> (a -> (a1 -> a11), b)
+func newTestStore() *Store {
+	elements := map[string]*dbmodels.HierarchyResponse{
+		"root": element("root", "a", "b"),
+		"a":    element("a", "a1"),
+		"a1":   element("a1", "a11"),
+		"a11":  element("a11"),
+		"b":    element("b"),
+	}
+	// the graph reports the number of children on each child element
+	for _, res := range elements {
+		for _, child := range res.Children {
+			child.NoOfChildren = elements[child.ID].NoOfChildren
+		}
+	}
+	return &Store{Hierarchy: &fakeHierarchy{elements: elements}}
+}
+
+func TestGetHierarchies(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	dimensions := "g.V().hasId('_instance_Instance').values('dimensions')"
+	geography := "g.V().hasLabel('_hierarchy_node_instance_geography')"
+	time := "g.V().hasLabel('_hierarchy_node_instance_time')"
+
+	Convey("When listing hierarchies, the instance's dimensions with a hierarchy are counted by the graph", t, func() {
+		pool := &fakePool{
+			strings: map[string][]string{
+				dimensions: {"time", "geography", "time"},
+				geography + ".limit(1).values('code_list')": {"geography-codelist"},
+				time + ".limit(1).values('code_list')":      nil,
+			},
+			counts: map[string]int64{
+				geography + ".count()": 5,
+				geography + ".not(__.in('hasParent')).local(__.repeat(__.out('hasParent').simplePath()).emit().count()).max()": 3,
+			},
+		}
+		store := &Store{pool: pool}
+
+		summaries, err := store.GetHierarchies(ctx, "instance")
+		So(err, ShouldBeNil)
+		So(summaries, ShouldResemble, []*datastore.HierarchySummary{
+			{Dimension: "geography", CodelistID: "geography-codelist", NoOfNodes: 5, Depth: 3},
+		})
+		So(pool.statements, ShouldHaveLength, 5)
+	})
+
+	Convey("When none of the instance's dimensions have a hierarchy, then ErrNotFound is returned", t, func() {
+		store := &Store{pool: &fakePool{strings: map[string][]string{
+			dimensions:                             {"time"},
+			time + ".limit(1).values('code_list')": nil,
+		}}}
+
+		_, err := store.GetHierarchies(ctx, "instance")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When the instance is not found, then ErrNotFound is returned", t, func() {
+		store := &Store{pool: &fakePool{strings: map[string][]string{dimensions: nil}}}
+
+		_, err := store.GetHierarchies(ctx, "instance")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When reading the dimensions fails, the error is returned", t, func() {
+		store := &Store{pool: &fakePool{err: errMalformed}}
+
+		summaries, err := store.GetHierarchies(ctx, "instance")
+		So(err, ShouldEqual, errMalformed)
+		So(summaries, ShouldBeNil)
+	})
+}
+
+func TestPutHierarchy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	nodes := "g.V().hasLabel('_hierarchy_node_instance_dimension')"
+	order := int64(1)
+	h := &datastore.Hierarchy{
+		CodelistID: "cl",
+		Nodes: []datastore.Node{
+			{Code: "root", Label: "United Kingdom"},
+			{Code: "a", Label: "England", Parent: "root", Order: &order, HasData: true},
+		},
+	}
+
+	// written counts the nodes and edges the graph holds after a write
+	written := func(pool *fakePool, vertices, edges int64) *fakePool {
+		pool.counts[nodes+".count()"] = vertices
+		pool.counts[nodes+".outE('hasParent').count()"] = edges
+		return pool
+	}
+
+	// old lists a hierarchy of a single node already in the graph
+	page := nodes + ".order().by('code',asc).range(0,1000)"
+	old := func(pool *fakePool) *fakePool {
+		pool.counts[nodes+".limit(1).count()"] = 1
+		pool.strings = map[string][]string{nodes + ".limit(1).values('code_list')": {"old-cl"}}
+		pool.vertices = map[string][]graphson.Vertex{page: {vertex("old", 0, false)}, page + ".out('hasParent').dedup()": nil}
+		pool.edges = map[string][]graphson.Edge{page + ".outE('hasParent')": nil}
+		return pool
+	}
+
+	Convey("When a new hierarchy is put, its nodes are added, linked to their parents and their children counted", t, func() {
+		pool := written(&fakePool{counts: map[string]int64{nodes + ".limit(1).count()": 0}}, 2, 1)
+		store := &Store{pool: pool}
+
+		created, err := store.PutHierarchy(ctx, "instance", "dimension", h)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+		So(pool.executed, ShouldResemble, []string{
+			"g.addV('_hierarchy_node_instance_dimension').property(single,'code','root').property(single,'label','United Kingdom')" +
+				".property(single,'code_list','cl').property(single,'hasData',false)" +
+				".addV('_hierarchy_node_instance_dimension').property(single,'code','a').property(single,'label','England')" +
+				".property(single,'code_list','cl').property(single,'hasData',true).property(single,'order',1)",
+			"g.V().hasLabel('_hierarchy_node_instance_dimension').has('code','a').as('c0')" +
+				".V().hasLabel('_hierarchy_node_instance_dimension').has('code','root').addE('hasParent').from('c0')",
+			nodes + ".property(single,'numberOfChildren',__.in('hasParent').count())",
+			"g.V().hasId('_instance_Instance').property(set,'dimensions','dimension')",
+		})
+	})
+
+	Convey("When an existing hierarchy is put, its old nodes are read and then dropped first", t, func() {
+		pool := written(old(&fakePool{counts: map[string]int64{}}), 2, 1)
+		store := &Store{pool: pool}
+
+		created, err := store.PutHierarchy(ctx, "instance", "dimension", h)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeFalse)
+		So(pool.statements, ShouldContain, page)
+		So(pool.executed[0], ShouldEqual, nodes+".drop()")
+		So(pool.executed, ShouldHaveLength, 5)
+	})
+
+	Convey("When the graph does not hold the hierarchy written, the nodes written are dropped and the old nodes restored", t, func() {
+		pool := written(old(&fakePool{counts: map[string]int64{}}), 1, 0)
+		store := &Store{pool: pool}
+
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", h)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "hierarchy written with 2 nodes and 1 parent edges but the graph holds 1 and 0")
+		So(pool.executed, ShouldHaveLength, 7)
+		So(pool.executed[4], ShouldEqual, nodes+".drop()")
+		So(pool.executed[5], ShouldEqual, "g.addV('_hierarchy_node_instance_dimension').property(single,'code','old').property(single,'label','')"+
+			".property(single,'code_list','old-cl').property(single,'hasData',false)")
+		So(pool.executed[6], ShouldEqual, nodes+".property(single,'numberOfChildren',__.in('hasParent').count())")
+	})
+
+	Convey("When a new hierarchy cannot be written, the nodes written are dropped", t, func() {
+		pool := written(&fakePool{counts: map[string]int64{nodes + ".limit(1).count()": 0}}, 2, 0)
+		store := &Store{pool: pool}
+
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", h)
+		So(err, ShouldNotBeNil)
+		So(pool.executed[len(pool.executed)-1], ShouldEqual, nodes+".drop()")
+	})
+
+	Convey("When a large hierarchy is put, its nodes are added in batches", t, func() {
+		large := &datastore.Hierarchy{Nodes: []datastore.Node{{Code: "root"}}}
+		for i := 1; i <= maxWriteBatch; i++ {
+			large.Nodes = append(large.Nodes, datastore.Node{Code: fmt.Sprint(i), Parent: "root"})
+		}
+		pool := written(&fakePool{counts: map[string]int64{nodes + ".limit(1).count()": 0}}, maxWriteBatch+1, maxWriteBatch)
+		store := &Store{pool: pool}
+
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", large)
+		So(err, ShouldBeNil)
+		So(pool.executed, ShouldHaveLength, 5)
+		So(strings.Count(pool.executed[0], ".addV("), ShouldEqual, maxWriteBatch)
+		So(strings.Count(pool.executed[1], ".addV("), ShouldEqual, 1)
+		So(strings.Count(pool.executed[2], ".addE("), ShouldEqual, maxWriteBatch)
+	})
+
+	Convey("When the hierarchy is not a single tree, it is rejected without writing to the graph", t, func() {
+		pool := &fakePool{}
+		store := &Store{pool: pool}
+
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{Nodes: []datastore.Node{{Code: "a", Parent: "missing"}}})
+		So(errors.Is(err, datastore.ErrInvalidHierarchy), ShouldBeTrue)
+		So(pool.statements, ShouldBeEmpty)
+	})
+
+	Convey("When writing to the graph fails, the error is returned", t, func() {
+		store := &Store{pool: &fakePool{err: errMalformed}}
+
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", h)
+		So(err, ShouldEqual, errMalformed)
+	})
+}
+
+func TestGetHierarchyRoot(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	root := "g.V().hasLabel('_hierarchy_node_instance_dimension').not(__.outE('hasParent'))"
+	rootCode := "g.V().hasLabel('_hierarchy_node_instance_dimension').has('code','root')"
+
+	Convey("When the root is looked up with a page of children, the graph pages and counts them", t, func() {
+		children := rootCode + ".in('hasParent').order().by('label',asc).by('code',asc).range(0,1)"
+		pool := &fakePool{
+			vertices: map[string][]graphson.Vertex{root: {vertex("root", 2, false)}, children: {vertex("a", 1, false)}},
+			counts:   map[string]int64{rootCode + ".in('hasParent').count()": 2},
+		}
+		store := &Store{pool: pool}
+
+		res, total, err := store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{Sort: datastore.SortByLabel, Limit: 1})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 2)
+		So(res, ShouldResemble, &dbmodels.HierarchyResponse{
+			ID: "root", Label: "United Kingdom", NoOfChildren: 2,
+			Children: []*dbmodels.HierarchyElement{{ID: "a", Label: "England", NoOfChildren: 1}},
+		})
+		So(pool.statements, ShouldHaveLength, 3)
+	})
+
+	Convey("When every child fits on the page, they are not counted separately", t, func() {
+		children := rootCode + ".in('hasParent')" + hierarchyOrder
+		pool := &fakePool{vertices: map[string][]graphson.Vertex{root: {vertex("root", 2, false)}, children: {vertex("a", 1, false), vertex("b", 0, true)}}}
+		store := &Store{pool: pool}
+
+		res, total, err := store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 2)
+		So(res.Children, ShouldHaveLength, 2)
+		So(pool.statements, ShouldResemble, []string{root, children})
+	})
+
+	Convey("When the hierarchy has no root, ErrNotFound is returned", t, func() {
+		store := &Store{pool: &fakePool{vertices: map[string][]graphson.Vertex{root: nil}}}
+
+		_, _, err := store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{})
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When the hierarchy has more than one root, ErrMultipleFound is returned", t, func() {
+		store := &Store{pool: &fakePool{vertices: map[string][]graphson.Vertex{root: {vertex("root", 0, false), vertex("b", 0, false)}}}}
+
+		_, _, err := store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{})
+		So(err, ShouldEqual, driver.ErrMultipleFound)
+	})
+}
+
+func TestGetHierarchyElement(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	a := "g.V().hasLabel('_hierarchy_node_instance_dimension').has('code','a')"
+	ancestry := map[string][]graphson.Vertex{
+		a + ".emit().repeat(__.out('hasParent').simplePath()).dedup()": {vertex("a", 1, false), vertex("root", 2, false)},
+	}
+	ancestryEdges := map[string][]graphson.Edge{
+		a + ".emit().repeat(__.out('hasParent').simplePath()).outE('hasParent').dedup()": {hasParent("a", "root")},
+	}
+
+	Convey("When a node is looked up with child options, it is read with its ancestors and the graph filters and pages its children", t, func() {
+		hasData := true
+		children := a + ".in('hasParent').has('hasData',true)" + hierarchyOrder + ".range(1,2)"
+		pool := &fakePool{vertices: ancestry, edges: ancestryEdges, counts: map[string]int64{a + ".in('hasParent').has('hasData',true).count()": 1}}
+		pool.vertices[children] = nil
+		store := &Store{pool: pool}
+
+		res, total, err := store.GetHierarchyElement(ctx, "instance", "dimension", "a", datastore.ChildOptions{HasData: &hasData, Offset: 1, Limit: 1})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(res, ShouldResemble, &dbmodels.HierarchyResponse{
+			ID: "a", Label: "England", NoOfChildren: 1,
+			Breadcrumbs: []*dbmodels.HierarchyElement{{ID: "root", Label: "United Kingdom", NoOfChildren: 2}},
+		})
+	})
+
+	Convey("When a node without children is looked up, its children are not read", t, func() {
+		b := "g.V().hasLabel('_hierarchy_node_instance_dimension').has('code','b')"
+		pool := &fakePool{
+			vertices: map[string][]graphson.Vertex{b + selfAndAncestors: {vertex("b", 0, true)}},
+			edges:    map[string][]graphson.Edge{b + ancestorEdges: nil},
+		}
+		store := &Store{pool: pool}
+
+		res, total, err := store.GetHierarchyElement(ctx, "instance", "dimension", "b", datastore.ChildOptions{})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 0)
+		So(res.Children, ShouldBeNil)
+		So(pool.statements, ShouldHaveLength, 2)
+	})
+
+	Convey("When the node is not found, ErrNotFound is returned", t, func() {
+		missing := "g.V().hasLabel('_hierarchy_node_instance_dimension').has('code','missing')"
+		store := &Store{pool: &fakePool{
+			vertices: map[string][]graphson.Vertex{missing + selfAndAncestors: nil},
+			edges:    map[string][]graphson.Edge{missing + ancestorEdges: nil},
+		}}
+
+		_, _, err := store.GetHierarchyElement(ctx, "instance", "dimension", "missing", datastore.ChildOptions{})
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestGetHierarchyElements(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	nodes := "g.V().hasLabel('_hierarchy_node_instance_dimension').has('code',within('a1','missing','b'))"
+	verticesStmt := nodes + ".union(__.identity(), __.in('hasParent'), __.repeat(__.out('hasParent').simplePath()).emit()).dedup()"
+	edgesStmt := nodes + ".union(__.inE('hasParent'), __.emit().repeat(__.out('hasParent').simplePath()).outE('hasParent')).dedup()"
+
+	Convey("When several codes are looked up, the nodes and the edges around them are each read in one statement", t, func() {
+		pool := &fakePool{
+			vertices: map[string][]graphson.Vertex{
+				verticesStmt: {vertex("a1", 1, false), vertex("b", 0, true), vertex("a11", 0, true), vertex("a", 1, false), vertex("root", 2, false)},
+			},
+			edges: map[string][]graphson.Edge{
+				edgesStmt: {hasParent("a11", "a1"), hasParent("a1", "a"), hasParent("a", "root"), hasParent("b", "root")},
+			},
+		}
+		store := &Store{pool: pool}
+
+		elements, err := store.GetHierarchyElements(ctx, "instance", "dimension", []string{"a1", "missing", "b"})
+		So(err, ShouldBeNil)
+		So(pool.statements, ShouldResemble, []string{verticesStmt, edgesStmt})
+
+		So(elements, ShouldHaveLength, 2)
+		So(elements, ShouldNotContainKey, "missing")
+		So(elements["a1"], ShouldResemble, &dbmodels.HierarchyResponse{
+			ID: "a1", Label: "London", NoOfChildren: 1,
+			Children:    []*dbmodels.HierarchyElement{{ID: "a11", Label: "City of London", HasData: true}},
+			Breadcrumbs: []*dbmodels.HierarchyElement{{ID: "a", Label: "England", NoOfChildren: 1}, {ID: "root", Label: "United Kingdom", NoOfChildren: 2}},
+		})
+		So(elements["b"], ShouldResemble, &dbmodels.HierarchyResponse{
+			ID: "b", Label: "Wales", HasData: true,
+			Breadcrumbs: []*dbmodels.HierarchyElement{{ID: "root", Label: "United Kingdom", NoOfChildren: 2}},
+		})
+	})
+
+	Convey("When no codes are looked up, nothing is read", t, func() {
+		pool := &fakePool{}
+		store := &Store{pool: pool}
+
+		elements, err := store.GetHierarchyElements(ctx, "instance", "dimension", nil)
+		So(err, ShouldBeNil)
+		So(elements, ShouldBeEmpty)
+		So(pool.statements, ShouldBeEmpty)
+	})
+
+	Convey("When reading the nodes fails, the error is returned", t, func() {
+		store := &Store{pool: &fakePool{err: errMalformed}}
+
+		elements, err := store.GetHierarchyElements(ctx, "instance", "dimension", []string{"a", "b"})
+		So(err, ShouldNotBeNil)
+		So(elements, ShouldBeNil)
+	})
+}
+
+func TestGetHierarchyRelatives(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	newStore := func() *Store {
+		store := newTestStore()
+		elements := store.Hierarchy.(*fakeHierarchy).elements
+		elements["a"].Breadcrumbs = []*dbmodels.HierarchyElement{{ID: "root"}}
+		elements["a1"].Breadcrumbs = []*dbmodels.HierarchyElement{{ID: "a"}, {ID: "root"}}
+		return store
+	}
+
+	Convey("When getting the ancestors of a code, its breadcrumbs are returned", t, func() {
+		ancestors, err := newStore().GetHierarchyAncestors(ctx, "instance", "dimension", "a1")
+		So(err, ShouldBeNil)
+		So(ancestors, ShouldHaveLength, 2)
+		So(ancestors[0].ID, ShouldEqual, "a")
+		So(ancestors[1].ID, ShouldEqual, "root")
+	})
+
+	Convey("When getting the siblings of a code, the other children of its parent are returned", t, func() {
+		siblings, err := newStore().GetHierarchySiblings(ctx, "instance", "dimension", "a")
+		So(err, ShouldBeNil)
+		So(siblings, ShouldHaveLength, 1)
+		So(siblings[0].ID, ShouldEqual, "b")
+	})
+
+	Convey("When getting the siblings of the root, none are returned", t, func() {
+		siblings, err := newStore().GetHierarchySiblings(ctx, "instance", "dimension", "root")
+		So(err, ShouldBeNil)
+		So(siblings, ShouldBeEmpty)
+	})
+
+	Convey("When getting the siblings of an unknown code, then ErrNotFound is returned", t, func() {
+		_, err := newStore().GetHierarchySiblings(ctx, "instance", "dimension", "missing")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestGetHierarchyDescendants(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a graph store", t, func() {
+		store := newTestStore()
+
+		Convey("When the whole subtree is requested, then every level is returned", func() {
+			tree, err := store.GetHierarchyDescendants(ctx, "i", "d", "root", 0)
+			So(err, ShouldBeNil)
+			So(tree.ID, ShouldEqual, "root")
+			So(tree.Children, ShouldHaveLength, 2)
+			So(tree.Children[0].Children, ShouldHaveLength, 1)
+			So(tree.Children[0].Children[0].Children, ShouldHaveLength, 1)
+			So(tree.Children[0].Children[0].Children[0].ID, ShouldEqual, "a11")
+			So(tree.Children[1].Children, ShouldBeEmpty)
+		})
+
+		Convey("When a depth is requested, then only that many levels are returned", func() {
+			tree, err := store.GetHierarchyDescendants(ctx, "i", "d", "root", 2)
+			So(err, ShouldBeNil)
+			So(tree.Children, ShouldHaveLength, 2)
+			So(tree.Children[0].Children, ShouldHaveLength, 1)
+			So(tree.Children[0].Children[0].NoOfChildren, ShouldEqual, 1)
+			So(tree.Children[0].Children[0].Children, ShouldBeEmpty)
+		})
+
+		Convey("When a subtree is requested, then it starts at the given code", func() {
+			tree, err := store.GetHierarchyDescendants(ctx, "i", "d", "a", 1)
+			So(err, ShouldBeNil)
+			So(tree.ID, ShouldEqual, "a")
+			So(tree.Children, ShouldHaveLength, 1)
+			So(tree.Children[0].Children, ShouldBeEmpty)
+		})
+
+		Convey("When the code does not exist, then ErrNotFound is returned", func() {
+			_, err := store.GetHierarchyDescendants(ctx, "i", "d", "missing", 0)
+			So(err, ShouldEqual, driver.ErrNotFound)
+		})
+	})
+
+	Convey("Given a graph containing a cycle, then the walk still completes", t, func() {
+		elements := map[string]*dbmodels.HierarchyResponse{
+			"a": element("a", "b"),
+			"b": element("b", "a"),
+		}
+		elements["a"].Children[0].NoOfChildren = 1
+		elements["b"].Children[0].NoOfChildren = 1
+		store := &Store{Hierarchy: &fakeHierarchy{elements: elements}}
+
+		tree, err := store.GetHierarchyDescendants(ctx, "i", "d", "a", 0)
+		So(err, ShouldBeNil)
+		So(tree.Children[0].ID, ShouldEqual, "b")
+		So(tree.Children[0].Children[0].ID, ShouldEqual, "a")
+		So(tree.Children[0].Children[0].Children, ShouldBeEmpty)
+	})
+
+	Convey("Given a graph that returns an error, then the error is returned", t, func() {
+		errGraph := errors.New("graph error")
+		store := &Store{Hierarchy: &fakeHierarchy{err: errGraph}}
+
+		_, err := store.GetHierarchyDescendants(ctx, "i", "d", "a", 0)
+		So(err, ShouldEqual, errGraph)
+	})
+}
+
+// walkedGraph returns a pool holding a hierarchy of the i/d instance and dimension, each parent listing its
+// children in order. Each vertex claims to have the number of children in stored, or the children listed.
+func walkedGraph(root string, children map[string][]string, stored map[string]int64) *fakePool {
+	noOfChildren := func(code string) int64 {
+		if n, ok := stored[code]; ok {
+			return n
+		}
+		return int64(len(children[code]))
+	}
+
+	pool := &fakePool{
+		vertices: map[string][]graphson.Vertex{
+			"g.V().hasLabel('_hierarchy_node_i_d').not(__.outE('hasParent')).order().by('code',asc).limit(1)": {vertex(root, noOfChildren(root), true)},
+		},
+		edges: map[string][]graphson.Edge{},
+	}
+	for parent, codes := range children {
+		below := "g.V('v-" + parent + "').in('hasParent')"
+		pool.edges[below+".inE('hasParent')"] = nil
+		for _, code := range codes {
+			pool.vertices[below+hierarchyOrder] = append(pool.vertices[below+hierarchyOrder], vertex(code, noOfChildren(code), true))
+			for _, child := range children[code] {
+				pool.edges[below+".inE('hasParent')"] = append(pool.edges[below+".inE('hasParent')"], hasParent(child, code))
+			}
+		}
+	}
+	return pool
+}
+
+func TestWalkHierarchy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	children := map[string][]string{
+		"root": {"a", "b"},
+		"a":    {"a1"},
+		"a1":   {"a11"},
+	}
+
+	Convey("Given a graph store", t, func() {
+		pool := walkedGraph("root", children, nil)
+		store := &Store{pool: pool}
+
+		Convey("When the hierarchy is walked, then every node is visited depth first in order", func() {
+			var visited []string
+			var parents []string
+			err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+				visited = append(visited, node.ID)
+				parents = append(parents, node.ParentID)
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(visited, ShouldResemble, []string{"root", "a", "a1", "a11", "b"})
+			So(parents, ShouldResemble, []string{"", "root", "a", "a1", "root"})
+		})
+
+		Convey("When the visiting function fails, then the walk stops with its error", func() {
+			errStop := errors.New("stop")
+			var visited []string
+			err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+				visited = append(visited, node.ID)
+				if node.ID == "a1" {
+					return errStop
+				}
+				return nil
+			})
+			So(err, ShouldEqual, errStop)
+			So(visited, ShouldResemble, []string{"root", "a", "a1"})
+		})
+
+		Convey("When the walk is limited to a depth, then the nodes below it are neither visited nor looked up", func() {
+			var visited []string
+			err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{Depth: 2}, func(node *datastore.WalkedNode) error {
+				visited = append(visited, node.ID)
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(visited, ShouldResemble, []string{"root", "a", "a1", "b"})
+			So(pool.statements, ShouldNotContain, "g.V('v-a1').in('hasParent')"+hierarchyOrder)
+			So(pool.statements, ShouldNotContain, "g.V('v-a').in('hasParent').inE('hasParent')")
+		})
+
+		Convey("When the walk starts from a code, then its subtree is visited and its root keeps its parent", func() {
+			pool.vertices["g.V().hasLabel('_hierarchy_node_i_d').has('code','a').order().by('code',asc).limit(1)"] = []graphson.Vertex{vertex("a", 1, true)}
+			pool.strings = map[string][]string{"g.V('v-a').out('hasParent').values('code')": {"root"}}
+
+			var visited []*datastore.WalkedNode
+			err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{Code: "a", Depth: 1}, func(node *datastore.WalkedNode) error {
+				visited = append(visited, node)
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(visited, ShouldHaveLength, 2)
+			So(visited[0].ID, ShouldEqual, "a")
+			So(visited[0].ParentID, ShouldEqual, "root")
+			So(visited[0].Depth, ShouldEqual, 0)
+			So(visited[1].ID, ShouldEqual, "a1")
+			So(visited[1].Depth, ShouldEqual, 1)
+		})
+
+		Convey("When the walk starts from a code that is not in the hierarchy, then ErrNotFound is returned", func() {
+			pool.vertices["g.V().hasLabel('_hierarchy_node_i_d').has('code','missing').order().by('code',asc).limit(1)"] = nil
+			err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{Code: "missing"}, func(node *datastore.WalkedNode) error { return nil })
+			So(err, ShouldEqual, driver.ErrNotFound)
+		})
+	})
+
+	Convey("Given a node stored without children that has children in the graph, then its children are walked", t, func() {
+		store := &Store{pool: walkedGraph("root", children, map[string]int64{"a": 0})}
+
+		var visited []string
+		err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+			visited = append(visited, node.ID)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(visited, ShouldResemble, []string{"root", "a", "a1", "a11", "b"})
+	})
+
+	Convey("Given a code held by two vertices, then both are walked with their own children", t, func() {
+		store := &Store{pool: walkedGraph("root", map[string][]string{
+			"root": {"a", "b"},
+			"a":    {"x"},
+			"b":    {"x"},
+		}, nil)}
+		// the second x is a distinct vertex with a child of its own
+		pool := store.pool.(*fakePool)
+		pool.vertices["g.V('v-b').in('hasParent')"+hierarchyOrder][0].Value.ID = "v-x2"
+		pool.edges["g.V('v-b').in('hasParent').inE('hasParent')"] = []graphson.Edge{{Value: graphson.EdgeValue{OutV: "v-y", InV: "v-x2"}}}
+		pool.vertices["g.V('v-x2').in('hasParent')"+hierarchyOrder] = []graphson.Vertex{vertex("y", 0, true)}
+		pool.edges["g.V('v-x2').in('hasParent').inE('hasParent')"] = nil
+
+		var visited []string
+		err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+			visited = append(visited, node.ParentID+">"+node.ID)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(visited, ShouldResemble, []string{">root", "root>a", "a>x", "root>b", "b>x", "x>y"})
+	})
+
+	Convey("Given a graph store without the hierarchy, then ErrNotFound is returned", t, func() {
+		store := &Store{pool: &fakePool{vertices: map[string][]graphson.Vertex{
+			"g.V().hasLabel('_hierarchy_node_i_d').not(__.outE('hasParent')).order().by('code',asc).limit(1)": nil,
+		}}}
+
+		err := store.WalkHierarchy(ctx, "i", "d", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error { return nil })
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestListDuplicateCodes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	duplicates := "g.V().hasLabel('_hierarchy_node_i_d').groupCount().by('code').unfold().where(__.select(values).is(gt(1))).select(keys)"
+
+	Convey("The codes held by more than one node are grouped by the graph and ordered by code", t, func() {
+		store := &Store{pool: &fakePool{strings: map[string][]string{duplicates: {"x", "b"}}}}
+
+		codes, err := store.ListDuplicateCodes(ctx, "i", "d")
+		So(err, ShouldBeNil)
+		So(codes, ShouldResemble, []string{"b", "x"})
+	})
+
+	Convey("When the graph fails, the error is returned", t, func() {
+		store := &Store{pool: &fakePool{err: errMalformed}}
+
+		_, err := store.ListDuplicateCodes(ctx, "i", "d")
+		So(err, ShouldEqual, errMalformed)
+	})
+}
+
+func TestValidateGraph(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	nodes := "g.V().hasLabel('_hierarchy_node_i_d')"
+	duplicates := nodes + ".groupCount().by('code').unfold().where(__.select(values).is(gt(1))).select(keys)"
+
+	// listed adds the statements listing every node of the hierarchy with the edges to their parents
+	listed := func(pool *fakePool, children map[string][]string, codes ...string) {
+		page := nodes + codeOrder + ".range(0,1000)"
+		for _, code := range codes {
+			pool.vertices[page] = append(pool.vertices[page], vertex(code, int64(len(children[code])), true))
+		}
+		for parent, codes := range children {
+			pool.vertices[page+".out('hasParent').dedup()"] = append(pool.vertices[page+".out('hasParent').dedup()"], vertex(parent, 0, true))
+			for _, code := range codes {
+				pool.edges[page+".outE('hasParent')"] = append(pool.edges[page+".outE('hasParent')"], hasParent(code, parent))
+			}
+		}
+	}
+
+	Convey("Given a node stored without children that has children in the graph", t, func() {
+		children := map[string][]string{"root": {"a", "b"}, "a": {"a1"}}
+		pool := walkedGraph("root", children, map[string]int64{"a": 0})
+		pool.strings = map[string][]string{duplicates: nil}
+		listed(pool, children, "a", "a1", "b", "root")
+
+		Convey("When it is validated, then its child count is reported as wrong and its children are not orphaned", func() {
+			report, err := validate.Validate(ctx, &Store{pool: pool}, "i", "d")
+			So(err, ShouldBeNil)
+			So(report.NoOfNodes, ShouldEqual, 4)
+			So(report.Problems, ShouldHaveLength, 1)
+			So(report.Problems[0].Type, ShouldEqual, validate.ChildCountMismatch)
+			So(report.Problems[0].Code, ShouldEqual, "a")
+		})
+	})
+
+	Convey("Given a code held by two nodes under different parents", t, func() {
+		children := map[string][]string{"root": {"a", "b"}, "a": {"x"}, "b": {"x"}}
+		pool := walkedGraph("root", children, nil)
+		pool.vertices["g.V('v-b').in('hasParent')"+hierarchyOrder][0].Value.ID = "v-x2"
+		pool.strings = map[string][]string{duplicates: {"x"}}
+		listed(pool, children, "a", "b", "root", "x", "x")
+
+		Convey("When it is validated, then the code is reported as a duplicate rather than failing", func() {
+			report, err := validate.Validate(ctx, &Store{pool: pool}, "i", "d")
+			So(err, ShouldBeNil)
+			So(report.Problems, ShouldHaveLength, 1)
+			So(report.Problems[0].Type, ShouldEqual, validate.DuplicateCode)
+			So(report.Problems[0].Code, ShouldEqual, "x")
+		})
+	})
+}
+
+func TestGetHierarchyLeaves(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	node := "g.V().hasLabel('_hierarchy_node_i_d').has('code','a')"
+	leaves := node + ".emit(__.not(__.inE('hasParent'))).repeat(__.in('hasParent').simplePath()).dedup()"
+
+	Convey("When the leaves below a node are paged, they are counted and the page is read by code", t, func() {
+		pool := &fakePool{
+			counts:   map[string]int64{leaves + ".count()": 3},
+			vertices: map[string][]graphson.Vertex{leaves + ".order().by('code',asc).range(1,3)": {vertex("a11", 0, true), vertex("a12", 0, false)}},
+		}
+		store := &Store{pool: pool}
+
+		page, total, err := store.GetHierarchyLeaves(ctx, "i", "d", "a", datastore.LeafOptions{Offset: 1, Limit: 2})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 3)
+		So(page, ShouldHaveLength, 2)
+		So(page[0].ID, ShouldEqual, "a11")
+		So(page[1].HasData, ShouldBeFalse)
+	})
+
+	Convey("When the leaves are filtered on has_data, the filter is applied by the graph", t, func() {
+		hasData := true
+		filtered := leaves + ".has('hasData',true)"
+		pool := &fakePool{
+			counts:   map[string]int64{filtered + ".count()": 1},
+			vertices: map[string][]graphson.Vertex{filtered + ".order().by('code',asc).range(0,-1)": {vertex("a11", 0, true)}},
+		}
+		store := &Store{pool: pool}
+
+		page, total, err := store.GetHierarchyLeaves(ctx, "i", "d", "a", datastore.LeafOptions{HasData: &hasData})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(page, ShouldHaveLength, 1)
+	})
+
+	Convey("When the page is past the last leaf, no leaves are read", t, func() {
+		pool := &fakePool{counts: map[string]int64{leaves + ".count()": 3}}
+		store := &Store{pool: pool}
+
+		page, total, err := store.GetHierarchyLeaves(ctx, "i", "d", "a", datastore.LeafOptions{Offset: 3})
+		So(err, ShouldBeNil)
+		So(page, ShouldBeEmpty)
+		So(total, ShouldEqual, 3)
+		So(pool.statements, ShouldHaveLength, 1)
+	})
+
+	Convey("When no leaves match, the node is looked up to tell whether it exists", t, func() {
+		pool := &fakePool{counts: map[string]int64{leaves + ".count()": 0, node + ".count()": 1}}
+		store := &Store{pool: pool}
+
+		page, total, err := store.GetHierarchyLeaves(ctx, "i", "d", "a", datastore.LeafOptions{})
+		So(err, ShouldBeNil)
+		So(page, ShouldBeEmpty)
+		So(total, ShouldEqual, 0)
+
+		pool.counts[node+".count()"] = 0
+		_, _, err = store.GetHierarchyLeaves(ctx, "i", "d", "a", datastore.LeafOptions{})
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestListHierarchyNodes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	page := "g.V().hasLabel('_hierarchy_node_instance_dimension').order().by('code',asc).range(0,1000)"
+
+	Convey("When a hierarchy is listed, each node is listed with the codes of its parents, reachable or not", t, func() {
+		pool := &fakePool{
+			vertices: map[string][]graphson.Vertex{
+				page:                               {vertex("a", 1, false), vertex("b", 0, false), vertex("root", 1, false)},
+				page + ".out('hasParent').dedup()": {vertex("root", 1, false), vertex("a", 1, false)},
+			},
+			edges: map[string][]graphson.Edge{
+				page + ".outE('hasParent')": {hasParent("a", "root"), hasParent("b", "root"), hasParent("b", "a")},
+			},
+		}
+		store := &Store{pool: pool}
+
+		var listed []*datastore.ListedNode
+		err := store.ListHierarchyNodes(ctx, "instance", "dimension", func(node *datastore.ListedNode) error {
+			listed = append(listed, node)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(listed, ShouldHaveLength, 3)
+		So(listed[0].ID, ShouldEqual, "a")
+		So(listed[0].ParentIDs, ShouldResemble, []string{"root"})
+		So(listed[1].ParentIDs, ShouldResemble, []string{"a", "root"})
+		So(listed[2].ParentIDs, ShouldBeNil)
+	})
+
+	Convey("When the hierarchy has no nodes, ErrNotFound is returned", t, func() {
+		store := &Store{pool: &fakePool{vertices: map[string][]graphson.Vertex{page: nil}}}
+
+		err := store.ListHierarchyNodes(ctx, "instance", "dimension", func(node *datastore.ListedNode) error { return nil })
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestSearchHierarchy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	nodes := "g.V().hasLabel('_hierarchy_node_i_d')"
+	prefixed := nodes + `.has('label',TextP.regex('(?iu)^LON')).order().by('label',asc).by('code',asc)`
+	contained := nodes + `.has('label',TextP.regex('(?iu)LON')).not(__.has('label',TextP.regex('(?iu)^LON'))).order().by('label',asc).by('code',asc)`
+	matched := nodes + ".has('code',within('a1','a11'))"
+
+	Convey("When searching, labels starting with the query rank first, and the matches are read with their breadcrumbs", t, func() {
+		pool := &fakePool{
+			strings: map[string][]string{
+				prefixed + ".limit(10).values('code')": {"a1"},
+				contained + ".limit(9).values('code')": {"a11"},
+			},
+			vertices: map[string][]graphson.Vertex{
+				matched + selfAndAncestors: {vertex("a11", 0, true), vertex("a1", 1, false), vertex("a", 1, false), vertex("root", 2, false)},
+			},
+			edges: map[string][]graphson.Edge{
+				matched + ancestorEdges: {hasParent("a11", "a1"), hasParent("a1", "a"), hasParent("a", "root")},
+			},
+		}
+		store := &Store{pool: pool}
+
+		results, err := store.SearchHierarchy(ctx, "i", "d", "LON", 10)
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 2)
+		So(results[0].ID, ShouldEqual, "a1")
+		So(results[0].Children, ShouldBeNil)
+		So(results[0].Breadcrumbs, ShouldHaveLength, 2)
+		So(results[0].Breadcrumbs[0].ID, ShouldEqual, "a")
+		So(results[0].Breadcrumbs[1].ID, ShouldEqual, "root")
+		So(results[1].ID, ShouldEqual, "a11")
+		So(results[1].Breadcrumbs, ShouldHaveLength, 3)
+	})
+
+	Convey("When enough labels start with the query, labels only containing it are not searched", t, func() {
+		one := nodes + ".has('code',within('a1'))"
+		pool := &fakePool{
+			strings:  map[string][]string{prefixed + ".limit(1).values('code')": {"a1"}},
+			vertices: map[string][]graphson.Vertex{one + selfAndAncestors: {vertex("a1", 1, false)}},
+			edges:    map[string][]graphson.Edge{one + ancestorEdges: nil},
+		}
+		store := &Store{pool: pool}
+
+		results, err := store.SearchHierarchy(ctx, "i", "d", "LON", 1)
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 1)
+		So(pool.statements, ShouldHaveLength, 3)
+	})
+
+	Convey("When no labels match, nothing more is read", t, func() {
+		pool := &fakePool{strings: map[string][]string{
+			prefixed + ".limit(10).values('code')":  nil,
+			contained + ".limit(10).values('code')": nil,
+		}}
+		store := &Store{pool: pool}
+
+		results, err := store.SearchHierarchy(ctx, "i", "d", "LON", 10)
+		So(err, ShouldBeNil)
+		So(results, ShouldBeEmpty)
+		So(pool.statements, ShouldHaveLength, 2)
+	})
+
+	Convey("Characters special to a regular expression are matched literally", t, func() {
+		So(startingWith("a.b'(c)"), ShouldEqual, `.has('label',TextP.regex('(?iu)^a\\.b\'\\(c\\)'))`)
+	})
+}
@@ -0,0 +1,403 @@
+package graphstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-graph/v2/retry"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/graphson"
+	gremgo "github.com/ONSdigital/gremgo-neptune"
+)
+
+const (
+	// maxAttempts and retryTime match the retries the graph driver makes of its own statements
+	maxAttempts = 6
+	retryTime   = 20 * time.Millisecond
+)
+
+// gremlinPool is the part of the Neptune connection pool used to run the store's own Gremlin statements
+type gremlinPool interface {
+	GetCtx(ctx context.Context, query string, bindings, rebindings map[string]string) ([]graphson.Vertex, error)
+	GetEdgeCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (interface{}, error)
+	GetCountCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (int64, error)
+	GetStringListCtx(ctx context.Context, query string, bindings, rebindings map[string]string) ([]string, error)
+	ExecuteCtx(ctx context.Context, query string, bindings, rebindings map[string]string) ([]gremgo.Response, error)
+}
+
+var _ gremlinPool = &gremgo.Pool{}
+
+// gremlinEscaper escapes text for a single quoted Gremlin string. Neptune does not support bindings, so
+// every value is written into the statement.
+var gremlinEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`)
+
+// quote returns s as a single quoted Gremlin string
+func quote(s string) string {
+	return "'" + gremlinEscaper.Replace(s) + "'"
+}
+
+// instanceDimensions is a traversal of the names of the dimensions of the instance, as recorded on its
+// instance node when the instance is imported
+func instanceDimensions(instanceID string) string {
+	return "g.V().hasId(" + quote("_"+instanceID+"_Instance") + ").values('dimensions')"
+}
+
+// nodeLabel is the quoted vertex label of the nodes in the hierarchy of dimension in the instance
+func nodeLabel(instanceID, dimension string) string {
+	return quote("_hierarchy_node_" + instanceID + "_" + dimension)
+}
+
+// hierarchyNodes is a traversal of every node in the hierarchy of dimension in the instance
+func hierarchyNodes(instanceID, dimension string) string {
+	return "g.V().hasLabel(" + nodeLabel(instanceID, dimension) + ")"
+}
+
+// codeNode is a traversal of the node for code in the hierarchy of dimension in the instance
+func codeNode(instanceID, dimension, code string) string {
+	return hierarchyNodes(instanceID, dimension) + ".has('code'," + quote(code) + ")"
+}
+
+// rootNode is a traversal of the root of the hierarchy of dimension in the instance, the node without a parent
+func rootNode(instanceID, dimension string) string {
+	return hierarchyNodes(instanceID, dimension) + ".not(__.outE('hasParent'))"
+}
+
+// vertexNode is a traversal of the vertex with the given ID
+func vertexNode(vertexID string) string {
+	return "g.V(" + quote(vertexID) + ")"
+}
+
+// codeNodes is a traversal of the nodes for the given codes in the hierarchy of dimension in the instance
+func codeNodes(instanceID, dimension string, codes []string) string {
+	quoted := make([]string, len(codes))
+	for i, code := range codes {
+		quoted[i] = quote(code)
+	}
+	return hierarchyNodes(instanceID, dimension) + ".has('code',within(" + strings.Join(quoted, ",") + "))"
+}
+
+// startingWith steps to the nodes with labels starting with query, ignoring case as MatchLabel does
+func startingWith(query string) string {
+	return ".has('label',TextP.regex(" + quote("(?iu)^"+regexp.QuoteMeta(query)) + "))"
+}
+
+// containing steps to the nodes with labels that contain query without starting with it, ignoring case
+func containing(query string) string {
+	return ".has('label',TextP.regex(" + quote("(?iu)"+regexp.QuoteMeta(query)) + ")).not(__" + startingWith(query) + ")"
+}
+
+// addNodes is a statement adding a vertex for each of the nodes to the hierarchy of dimension in the instance,
+// without linking them to their parents
+func addNodes(instanceID, dimension, codelistID string, nodes []datastore.Node) string {
+	var b strings.Builder
+	b.WriteString("g")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, ".addV(%s).property(single,'code',%s).property(single,'label',%s).property(single,'code_list',%s).property(single,'hasData',%t)",
+			nodeLabel(instanceID, dimension), quote(n.Code), quote(n.Label), quote(codelistID), n.HasData)
+		if n.Order != nil {
+			fmt.Fprintf(&b, ".property(single,'order',%d)", *n.Order)
+		}
+	}
+	return b.String()
+}
+
+// addParentEdges is a statement adding a hasParent edge from the vertex of each of the nodes to the vertex of
+// its parent, both already in the hierarchy of dimension in the instance
+func addParentEdges(instanceID, dimension string, nodes []datastore.Node) string {
+	label := nodeLabel(instanceID, dimension)
+
+	var b strings.Builder
+	b.WriteString("g")
+	for i, n := range nodes {
+		fmt.Fprintf(&b, ".V().hasLabel(%s).has('code',%s).as('c%d').V().hasLabel(%s).has('code',%s).addE('hasParent').from('c%d')",
+			label, quote(n.Code), i, label, quote(n.Parent), i)
+	}
+	return b.String()
+}
+
+// Steps from the nodes of a traversal to the nodes around them. Repeated steps stop at a node already on
+// the path, so that a cycle in the graph cannot repeat forever.
+const (
+	// selfChildrenAndAncestors steps to each node itself, its children and each of its ancestors
+	selfChildrenAndAncestors = ".union(__.identity(), __.in('hasParent'), __.repeat(__.out('hasParent').simplePath()).emit()).dedup()"
+	// childAndAncestorEdges steps to the hasParent edges from each node's children, and from the node and
+	// each of its ancestors to their parents
+	childAndAncestorEdges = ".union(__.inE('hasParent'), __.emit().repeat(__.out('hasParent').simplePath()).outE('hasParent')).dedup()"
+	// selfAndAncestors steps to each node itself and each of its ancestors
+	selfAndAncestors = ".emit().repeat(__.out('hasParent').simplePath()).dedup()"
+	// ancestorEdges steps to the hasParent edges from each node and each of its ancestors to their parents
+	ancestorEdges = ".emit().repeat(__.out('hasParent').simplePath()).outE('hasParent').dedup()"
+	// leafDepths steps to the number of ancestors of each leaf, which is its depth below the root
+	leafDepths = ".not(__.in('hasParent')).local(__.repeat(__.out('hasParent').simplePath()).emit().count())"
+	// leavesBelow steps to the nodes without children in the subtree below each node, the node itself when it
+	// has none
+	leavesBelow = ".emit(__.not(__.inE('hasParent'))).repeat(__.in('hasParent').simplePath()).dedup()"
+	// duplicateCodes steps from the nodes to the codes held by more than one of them
+	duplicateCodes = ".groupCount().by('code').unfold().where(__.select(values).is(gt(1))).select(keys)"
+	// countChildren sets the numberOfChildren of each node from its hasParent edges, as the graph driver does
+	countChildren = ".property(single,'numberOfChildren',__.in('hasParent').count())"
+)
+
+// Orders of the children of a node. The order of the hierarchy puts the children with an order first, by
+// their order, then the rest by label, which is how the graph driver orders children that all have an order
+// or all have none.
+const (
+	hierarchyOrder = ".order().by(__.values('order').count(),desc).by(__.coalesce(__.values('order'),__.constant(0)),asc).by('label',asc)"
+	labelOrder     = ".order().by('label',asc).by('code',asc)"
+	codeOrder      = ".order().by('code',asc)"
+)
+
+// matchingChildren steps to the children of each node that pass the HasData filter of opts
+func matchingChildren(opts datastore.ChildOptions) string {
+	if opts.HasData == nil {
+		return ".in('hasParent')"
+	}
+	return fmt.Sprintf(".in('hasParent').has('hasData',%t)", *opts.HasData)
+}
+
+// selectedChildren steps to the children of each node selected by opts, filtered, sorted and paged by the graph
+func selectedChildren(opts datastore.ChildOptions) string {
+	stmt := matchingChildren(opts)
+
+	switch opts.Sort {
+	case datastore.SortByLabel:
+		stmt += labelOrder
+	case datastore.SortByCode:
+		stmt += codeOrder
+	default:
+		stmt += hierarchyOrder
+	}
+
+	if opts.Offset > 0 || opts.Limit > 0 {
+		end := -1
+		if opts.Limit > 0 {
+			end = opts.Offset + opts.Limit
+		}
+		stmt += fmt.Sprintf(".range(%d,%d)", opts.Offset, end)
+	}
+
+	return stmt
+}
+
+// matchingLeaves steps to the leaves below each node that pass the HasData filter of opts
+func matchingLeaves(opts datastore.LeafOptions) string {
+	if opts.HasData == nil {
+		return leavesBelow
+	}
+	return fmt.Sprintf("%s.has('hasData',%t)", leavesBelow, *opts.HasData)
+}
+
+// vertices runs a statement returning vertices, retrying transient errors
+func (s *Store) vertices(ctx context.Context, stmt string) ([]graphson.Vertex, error) {
+	return attempt(ctx, func() ([]graphson.Vertex, error) {
+		return s.pool.GetCtx(ctx, stmt, nil, nil)
+	})
+}
+
+// edges runs a statement returning edges, retrying transient errors
+func (s *Store) edges(ctx context.Context, stmt string) ([]graphson.Edge, error) {
+	res, err := attempt(ctx, func() (interface{}, error) {
+		return s.pool.GetEdgeCtx(ctx, stmt, nil, nil)
+	})
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	edges, ok := res.([]graphson.Edge)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %T result for edges", res)
+	}
+	return edges, nil
+}
+
+// count runs a statement returning a number, retrying transient errors
+func (s *Store) count(ctx context.Context, stmt string) (int64, error) {
+	return attempt(ctx, func() (int64, error) {
+		return s.pool.GetCountCtx(ctx, stmt, nil, nil)
+	})
+}
+
+// stringList runs a statement returning strings, retrying transient errors
+func (s *Store) stringList(ctx context.Context, stmt string) ([]string, error) {
+	return attempt(ctx, func() ([]string, error) {
+		return s.pool.GetStringListCtx(ctx, stmt, nil, nil)
+	})
+}
+
+// execute runs a statement for its effect on the graph, retrying transient errors
+func (s *Store) execute(ctx context.Context, stmt string) error {
+	_, err := attempt(ctx, func() ([]gremgo.Response, error) {
+		return s.pool.ExecuteCtx(ctx, stmt, nil, nil)
+	})
+	return err
+}
+
+// attempt calls do until it succeeds, fails with an error that is not transient, or has been called
+// maxAttempts times
+func attempt[T any](ctx context.Context, do func() (T, error)) (T, error) {
+	res, err := retry.Do(ctx, func() (interface{}, error) { return do() }, isTransient, maxAttempts, retryTime)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return res.(T), nil
+}
+
+// isTransient reports whether a statement failed in a way that may succeed if retried, as the graph
+// driver decides for its own statements
+func isTransient(err error) bool {
+	return !strings.Contains(err.Error(), " MALFORMED REQUEST ") &&
+		!strings.Contains(err.Error(), " INVALID REQUEST ARGUMENTS ")
+}
+
+// node is a hierarchy node read from the graph, with the ID of its vertex
+type node struct {
+	dbmodels.HierarchyElement
+	vertexID string
+}
+
+// toNode reads a hierarchy node from the properties of its vertex
+func toNode(v graphson.Vertex) (*node, error) {
+	n := &node{vertexID: v.GetID()}
+
+	var err error
+	if n.ID, err = v.GetProperty("code"); err != nil {
+		return nil, fmt.Errorf("hierarchy node %q has no code: %w", n.vertexID, err)
+	}
+	if n.Label, err = v.GetProperty("label"); err != nil {
+		return nil, fmt.Errorf("hierarchy node %q has no label: %w", n.ID, err)
+	}
+	if n.NoOfChildren, err = v.GetPropertyInt64("numberOfChildren"); err != nil {
+		return nil, fmt.Errorf("hierarchy node %q has no numberOfChildren: %w", n.ID, err)
+	}
+	if n.HasData, err = v.GetPropertyBool("hasData"); err != nil {
+		return nil, fmt.Errorf("hierarchy node %q has no hasData: %w", n.ID, err)
+	}
+
+	order, err := v.GetPropertyInt64("order")
+	switch {
+	case err == nil:
+		n.Order = &order
+	case !errors.Is(err, graphson.ErrorPropertyNotFound):
+		return nil, fmt.Errorf("hierarchy node %q has an invalid order: %w", n.ID, err)
+	}
+
+	return n, nil
+}
+
+// element returns a copy of the node's element, so that responses never share it
+func (n *node) element() *dbmodels.HierarchyElement {
+	element := n.HierarchyElement
+	return &element
+}
+
+// subgraph is a set of hierarchy nodes read from the graph, linked by the hasParent edges read with them
+type subgraph struct {
+	byCode map[string]*node
+	// parents and children are keyed by vertex ID. Children are in the order of the hierarchy.
+	parents  map[string]*node
+	children map[string][]*node
+}
+
+// subgraph reads the nodes returned by one statement and links them with the hasParent edges returned by
+// another. Edges to nodes that were not read are ignored.
+func (s *Store) subgraph(ctx context.Context, verticesStmt, edgesStmt string) (*subgraph, error) {
+	vertices, err := s.vertices(ctx, verticesStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := s.edges(ctx, edgesStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &subgraph{
+		byCode:   make(map[string]*node, len(vertices)),
+		parents:  make(map[string]*node, len(edges)),
+		children: make(map[string][]*node),
+	}
+
+	byVertex := make(map[string]*node, len(vertices))
+	for _, v := range vertices {
+		n, err := toNode(v)
+		if err != nil {
+			return nil, err
+		}
+		byVertex[n.vertexID] = n
+		g.byCode[n.ID] = n
+	}
+
+	for _, e := range edges {
+		child, parent := byVertex[e.Value.OutV], byVertex[e.Value.InV]
+		if child == nil || parent == nil {
+			continue
+		}
+		g.parents[child.vertexID] = parent
+		g.children[parent.vertexID] = append(g.children[parent.vertexID], child)
+	}
+
+	for _, children := range g.children {
+		sortNodes(children)
+	}
+
+	return g, nil
+}
+
+// breadcrumbs returns the ancestors of n from its parent up to the root
+func (g *subgraph) breadcrumbs(n *node) []*dbmodels.HierarchyElement {
+	var breadcrumbs []*dbmodels.HierarchyElement
+	seen := map[string]bool{n.vertexID: true}
+	for parent := g.parents[n.vertexID]; parent != nil && !seen[parent.vertexID]; parent = g.parents[parent.vertexID] {
+		seen[parent.vertexID] = true
+		breadcrumbs = append(breadcrumbs, parent.element())
+	}
+	return breadcrumbs
+}
+
+// response returns n with its children and breadcrumbs
+func (g *subgraph) response(n *node) *dbmodels.HierarchyResponse {
+	res := &dbmodels.HierarchyResponse{
+		ID:           n.ID,
+		Label:        n.Label,
+		NoOfChildren: n.NoOfChildren,
+		Order:        n.Order,
+		HasData:      n.HasData,
+		Breadcrumbs:  g.breadcrumbs(n),
+	}
+
+	for _, child := range g.children[n.vertexID] {
+		res.Children = append(res.Children, child.element())
+	}
+
+	return res
+}
+
+// sortNodes orders nodes the same way as the graph driver orders children: by order when any node has
+// one, otherwise by label
+func sortNodes(nodes []*node) {
+	hasOrder := false
+	for _, n := range nodes {
+		if n.Order != nil {
+			hasOrder = true
+			break
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if hasOrder && (a.Order == nil) != (b.Order == nil) {
+			return a.Order != nil
+		}
+		if hasOrder && a.Order != nil && *a.Order != *b.Order {
+			return *a.Order < *b.Order
+		}
+		return a.Label < b.Label
+	})
+}
@@ -0,0 +1,239 @@
+package graphstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/graphson"
+	gremgo "github.com/ONSdigital/gremgo-neptune"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// errMalformed is a graph error that is not retried
+var errMalformed = errors.New("graph error: MALFORMED REQUEST ")
+
+// fakePool answers the statements it is given with canned results, failing those it does not expect
+type fakePool struct {
+	vertices map[string][]graphson.Vertex
+	edges    map[string][]graphson.Edge
+	counts   map[string]int64
+	strings  map[string][]string
+	// executed holds the statements run for their effect on the graph, which always succeed unless err is set
+	executed []string
+	// err fails every statement, after the first transient statements have failed with a transient error
+	err        error
+	transient  int
+	statements []string
+}
+
+func (f *fakePool) run(stmt string) error {
+	f.statements = append(f.statements, stmt)
+	if f.transient > 0 {
+		f.transient--
+		return errors.New("connection reset")
+	}
+	return f.err
+}
+
+func (f *fakePool) GetCtx(ctx context.Context, query string, bindings, rebindings map[string]string) ([]graphson.Vertex, error) {
+	if err := f.run(query); err != nil {
+		return nil, err
+	}
+	vertices, ok := f.vertices[query]
+	if !ok {
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	return vertices, nil
+}
+
+func (f *fakePool) GetEdgeCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (interface{}, error) {
+	if err := f.run(query); err != nil {
+		return nil, err
+	}
+	edges, ok := f.edges[query]
+	if !ok {
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	return edges, nil
+}
+
+func (f *fakePool) GetCountCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (int64, error) {
+	if err := f.run(query); err != nil {
+		return 0, err
+	}
+	count, ok := f.counts[query]
+	if !ok {
+		return 0, fmt.Errorf("unexpected statement: %s", query)
+	}
+	return count, nil
+}
+
+func (f *fakePool) GetStringListCtx(ctx context.Context, query string, bindings, rebindings map[string]string) ([]string, error) {
+	if err := f.run(query); err != nil {
+		return nil, err
+	}
+	vals, ok := f.strings[query]
+	if !ok {
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	return vals, nil
+}
+
+func (f *fakePool) ExecuteCtx(ctx context.Context, query string, bindings, rebindings map[string]string) ([]gremgo.Response, error) {
+	if err := f.run(query); err != nil {
+		return nil, err
+	}
+	f.executed = append(f.executed, query)
+	return nil, nil
+}
+
+// vertex returns the vertex of a hierarchy node, labelled from labels
+func vertex(code string, noOfChildren int64, hasData bool, order ...int64) graphson.Vertex {
+	properties := map[string][]graphson.VertexProperty{
+		"code":             {property("code", code)},
+		"label":            {property("label", labels[code])},
+		"numberOfChildren": {property("numberOfChildren", map[string]interface{}{"@type": "g:Int64", "@value": float64(noOfChildren)})},
+		"hasData":          {property("hasData", hasData)},
+	}
+	if len(order) > 0 {
+		properties["order"] = []graphson.VertexProperty{property("order", map[string]interface{}{"@type": "g:Int32", "@value": float64(order[0])})}
+	}
+
+	return graphson.Vertex{
+		Type:  "g:Vertex",
+		Value: graphson.VertexValue{ID: "v-" + code, Label: "_hierarchy_node_instance_dimension", Properties: properties},
+	}
+}
+
+func property(key string, value interface{}) graphson.VertexProperty {
+	return graphson.VertexProperty{Type: "g:VertexProperty", Value: graphson.VertexPropertyValue{Label: key, Value: value}}
+}
+
+// hasParent returns the edge from the vertex of a node to the vertex of its parent
+func hasParent(child, parent string) graphson.Edge {
+	return graphson.Edge{
+		Type:  "g:Edge",
+		Value: graphson.EdgeValue{ID: child + "-" + parent, Label: "hasParent", OutV: "v-" + child, InV: "v-" + parent},
+	}
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	Convey("Text is quoted as a Gremlin string, escaping quotes, backslashes and line breaks", t, func() {
+		So(quote("cpih1dim1A0"), ShouldEqual, `'cpih1dim1A0'`)
+		So(quote(`it's a \ "code"`), ShouldEqual, `'it\'s a \\ "code"'`)
+		So(quote("two\nlines\r"), ShouldEqual, `'two\nlines\r'`)
+	})
+
+	Convey("Codes are written into a within predicate on the hierarchy's nodes", t, func() {
+		So(codeNodes("instance", "dimension", []string{"a", "b'c"}), ShouldEqual,
+			`g.V().hasLabel('_hierarchy_node_instance_dimension').has('code',within('a','b\'c'))`)
+	})
+}
+
+func TestSelectedChildren(t *testing.T) {
+	t.Parallel()
+	hasData := true
+
+	Convey("Without options, every child is selected in the order of the hierarchy", t, func() {
+		So(selectedChildren(datastore.ChildOptions{}), ShouldEqual, ".in('hasParent')"+hierarchyOrder)
+	})
+
+	Convey("Children are filtered by has_data, sorted and paged by the graph", t, func() {
+		So(selectedChildren(datastore.ChildOptions{HasData: &hasData, Sort: datastore.SortByLabel, Offset: 10, Limit: 5}), ShouldEqual,
+			".in('hasParent').has('hasData',true).order().by('label',asc).by('code',asc).range(10,15)")
+		So(selectedChildren(datastore.ChildOptions{Sort: datastore.SortByCode, Offset: 10}), ShouldEqual,
+			".in('hasParent').order().by('code',asc).range(10,-1)")
+	})
+}
+
+func TestStatements(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("A statement failing with a transient error is retried", t, func() {
+		pool := &fakePool{transient: 2, vertices: map[string][]graphson.Vertex{"g.V()": {vertex("root", 0, true)}}}
+		store := &Store{pool: pool}
+
+		vertices, err := store.vertices(ctx, "g.V()")
+		So(err, ShouldBeNil)
+		So(vertices, ShouldHaveLength, 1)
+		So(pool.statements, ShouldHaveLength, 3)
+	})
+
+	Convey("A statement that is malformed is not retried", t, func() {
+		pool := &fakePool{err: errMalformed}
+		store := &Store{pool: pool}
+
+		_, err := store.vertices(ctx, "g.V(")
+		So(err, ShouldEqual, errMalformed)
+		So(pool.statements, ShouldHaveLength, 1)
+	})
+}
+
+func TestToNode(t *testing.T) {
+	t.Parallel()
+
+	Convey("A node is read from the properties of its vertex", t, func() {
+		n, err := toNode(vertex("a", 1, true, 3))
+		So(err, ShouldBeNil)
+		So(n.vertexID, ShouldEqual, "v-a")
+		So(n.ID, ShouldEqual, "a")
+		So(n.Label, ShouldEqual, "England")
+		So(n.NoOfChildren, ShouldEqual, 1)
+		So(n.HasData, ShouldBeTrue)
+		So(*n.Order, ShouldEqual, 3)
+	})
+
+	Convey("A node without an order is read without one", t, func() {
+		n, err := toNode(vertex("a", 1, true))
+		So(err, ShouldBeNil)
+		So(n.Order, ShouldBeNil)
+	})
+
+	Convey("A vertex without a code is not a node", t, func() {
+		v := vertex("a", 1, true)
+		delete(v.Value.Properties, "code")
+
+		_, err := toNode(v)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSubgraph(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Children are linked in the order of the hierarchy, and edges to nodes not read are ignored", t, func() {
+		pool := &fakePool{
+			vertices: map[string][]graphson.Vertex{"nodes": {vertex("root", 2, false), vertex("b", 0, true, 1), vertex("a", 1, false, 2)}},
+			edges:    map[string][]graphson.Edge{"edges": {hasParent("a", "root"), hasParent("b", "root"), hasParent("a1", "a")}},
+		}
+		store := &Store{pool: pool}
+
+		g, err := store.subgraph(ctx, "nodes", "edges")
+		So(err, ShouldBeNil)
+
+		res := g.response(g.byCode["root"])
+		So(res.Children, ShouldHaveLength, 2)
+		So(res.Children[0].ID, ShouldEqual, "b")
+		So(res.Children[1].ID, ShouldEqual, "a")
+		So(g.response(g.byCode["a"]).Children, ShouldBeEmpty)
+	})
+
+	Convey("Breadcrumbs stop at a cycle", t, func() {
+		pool := &fakePool{
+			vertices: map[string][]graphson.Vertex{"nodes": {vertex("a", 1, false), vertex("b", 1, false)}},
+			edges:    map[string][]graphson.Edge{"edges": {hasParent("a", "b"), hasParent("b", "a")}},
+		}
+		store := &Store{pool: pool}
+
+		g, err := store.subgraph(ctx, "nodes", "edges")
+		So(err, ShouldBeNil)
+		So(g.breadcrumbs(g.byCode["a"]), ShouldHaveLength, 1)
+	})
+}
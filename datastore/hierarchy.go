@@ -0,0 +1,162 @@
+package datastore
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//go:generate moq -out datastoretest/writer.go -pkg datastoretest . Writer
+
+// ErrInvalidHierarchy is wrapped by the errors returned for a hierarchy that is not a single tree
+var ErrInvalidHierarchy = errors.New("invalid hierarchy")
+
+// ErrReadOnly is returned when writing through a store whose underlying store cannot be written to
+var ErrReadOnly = errors.New("datastore is read only")
+
+// Writer is the interface for stores whose hierarchies can be created and replaced
+type Writer interface {
+	// PutHierarchy creates or replaces the hierarchy of the dimension of the instance, reporting whether
+	// it was created. A hierarchy that is not a single tree is rejected with ErrInvalidHierarchy.
+	PutHierarchy(ctx context.Context, instanceID, dimension string, h *Hierarchy) (created bool, err error)
+}
+
+// Hierarchy is a complete hierarchy described as a flat list of nodes
+type Hierarchy struct {
+	CodelistID string
+	Nodes      []Node
+}
+
+// Node is a single code in a hierarchy. The root node is the only node without a parent
+type Node struct {
+	Code    string
+	Label   string
+	Parent  string
+	Order   *int64
+	HasData bool
+}
+
+// Validate reports why the hierarchy is not a single tree: a node without a code, a duplicated code, other
+// than one root, a parent that is not in the hierarchy, or nodes that cannot be reached from the root
+func (h *Hierarchy) Validate() error {
+	codes := make(map[string]bool, len(h.Nodes))
+	children := make(map[string][]string, len(h.Nodes))
+	var root string
+	for _, n := range h.Nodes {
+		if n.Code == "" {
+			return errors.New("node without a code")
+		}
+		if codes[n.Code] {
+			return fmt.Errorf("duplicate code %q", n.Code)
+		}
+		codes[n.Code] = true
+
+		if n.Parent == "" {
+			if root != "" {
+				return fmt.Errorf("multiple root nodes %q and %q", root, n.Code)
+			}
+			root = n.Code
+			continue
+		}
+		children[n.Parent] = append(children[n.Parent], n.Code)
+	}
+
+	if root == "" {
+		return errors.New("no root node")
+	}
+
+	for _, n := range h.Nodes {
+		if n.Parent != "" && !codes[n.Parent] {
+			return fmt.Errorf("code %q has unknown parent %q", n.Code, n.Parent)
+		}
+	}
+
+	// every node but the root has one parent, so the nodes reached from the root form a tree
+	reached := 0
+	for stack := []string{root}; len(stack) > 0; {
+		code := stack[len(stack)-1]
+		stack = append(stack[:len(stack)-1], children[code]...)
+		reached++
+	}
+	if reached != len(h.Nodes) {
+		return fmt.Errorf("%d nodes are not reachable from the root", len(h.Nodes)-reached)
+	}
+
+	return nil
+}
+
+// ReadNodesCSV reads the nodes of a hierarchy from CSV. The header row names the columns, which are
+// code, label, parent (or parent_code), order and has_data in any order. Only code and label are required.
+func ReadNodesCSV(r io.Reader) ([]Node, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "parent_code" {
+			name = "parent"
+		}
+		switch name {
+		case "code", "label", "parent", "order", "has_data":
+		default:
+			return nil, fmt.Errorf("unknown column %q", header[i])
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"code", "label"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var nodes []Node
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nodes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		node := Node{Code: field("code"), Label: field("label"), Parent: field("parent")}
+		if order := field("order"); order != "" {
+			value, err := strconv.ParseInt(order, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid order %q", line, order)
+			}
+			node.Order = &value
+		}
+		if hasData := field("has_data"); hasData != "" {
+			if node.HasData, err = strconv.ParseBool(hasData); err != nil {
+				return nil, fmt.Errorf("line %d: invalid has_data %q", line, hasData)
+			}
+		}
+
+		nodes = append(nodes, node)
+	}
+}
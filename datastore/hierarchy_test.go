@@ -0,0 +1,89 @@
+package datastore
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	Convey("A single tree is valid", t, func() {
+		h := &Hierarchy{Nodes: []Node{{Code: "root"}, {Code: "a", Parent: "root"}, {Code: "a1", Parent: "a"}, {Code: "b", Parent: "root"}}}
+		So(h.Validate(), ShouldBeNil)
+	})
+
+	Convey("A hierarchy that is not a single tree is invalid", t, func() {
+		for _, tc := range []struct {
+			nodes []Node
+			err   string
+		}{
+			{[]Node{{Code: "root"}, {Label: "A", Parent: "root"}}, "node without a code"},
+			{[]Node{{Code: "root"}, {Code: "a", Parent: "root"}, {Code: "a", Parent: "root"}}, `duplicate code "a"`},
+			{[]Node{{Code: "root"}, {Code: "other"}}, `multiple root nodes "root" and "other"`},
+			{[]Node{{Code: "a", Parent: "b"}, {Code: "b", Parent: "a"}}, "no root node"},
+			{[]Node{{Code: "root"}, {Code: "a", Parent: "missing"}}, `code "a" has unknown parent "missing"`},
+			{[]Node{{Code: "root"}, {Code: "a", Parent: "b"}, {Code: "b", Parent: "a"}}, "2 nodes are not reachable from the root"},
+		} {
+			err := (&Hierarchy{Nodes: tc.nodes}).Validate()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, tc.err)
+		}
+	})
+}
+
+func TestReadNodesCSV(t *testing.T) {
+	t.Parallel()
+
+	Convey("Nodes are read from the columns named in the header, in any order", t, func() {
+		nodes, err := ReadNodesCSV(strings.NewReader("label,code,parent_code,order,has_data\n" +
+			"Root,root,,,true\n" +
+			`"A, first",a,root,2,false` + "\n" +
+			"B,b,root,,\n"))
+		So(err, ShouldBeNil)
+		So(nodes, ShouldHaveLength, 3)
+
+		So(nodes[0], ShouldResemble, Node{Code: "root", Label: "Root", HasData: true})
+		So(nodes[1].Code, ShouldEqual, "a")
+		So(nodes[1].Label, ShouldEqual, "A, first")
+		So(nodes[1].Parent, ShouldEqual, "root")
+		So(*nodes[1].Order, ShouldEqual, 2)
+		So(nodes[2], ShouldResemble, Node{Code: "b", Label: "B", Parent: "root"})
+	})
+
+	Convey("Only the code and label columns are required", t, func() {
+		nodes, err := ReadNodesCSV(strings.NewReader("\ufeffcode,label\nroot,Root\n"))
+		So(err, ShouldBeNil)
+		So(nodes, ShouldResemble, []Node{{Code: "root", Label: "Root"}})
+	})
+
+	Convey("A header without a required column is rejected", t, func() {
+		_, err := ReadNodesCSV(strings.NewReader("code,parent\nroot,\n"))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `missing column "label"`)
+	})
+
+	Convey("Unknown and duplicate columns are rejected", t, func() {
+		_, err := ReadNodesCSV(strings.NewReader("code,label,colour\n"))
+		So(err, ShouldNotBeNil)
+
+		_, err = ReadNodesCSV(strings.NewReader("code,label,parent,parent_code\n"))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("An order or has_data that cannot be parsed is rejected with its line", t, func() {
+		_, err := ReadNodesCSV(strings.NewReader("code,label,order\nroot,Root,\na,A,first\n"))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `line 3: invalid order "first"`)
+
+		_, err = ReadNodesCSV(strings.NewReader("code,label,has_data\nroot,Root,maybe\n"))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Empty input is rejected", t, func() {
+		_, err := ReadNodesCSV(strings.NewReader(""))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,132 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+var (
+	_ datastore.Storer = &Store{}
+	_ datastore.Writer = &Store{}
+)
+
+// Observer records the outcome of calls to a datastore
+type Observer interface {
+	ObserveDatastore(method string, duration time.Duration, err error)
+}
+
+// Store is a datastore.Storer that reports the duration and outcome of every call made
+// through it to an Observer
+type Store struct {
+	store    datastore.Storer
+	observer Observer
+}
+
+// New creates a Store that reports calls to the given store
+func New(store datastore.Storer, observer Observer) *Store {
+	return &Store{store: store, observer: observer}
+}
+
+// Close closes the underlying store
+func (s *Store) Close(ctx context.Context) error {
+	return s.store.Close(ctx)
+}
+
+// GetHierarchyCodelist returns the code list ID for the given instance and dimension
+func (s *Store) GetHierarchyCodelist(ctx context.Context, instanceID, dimension string) (codelistID string, err error) {
+	defer s.observe("GetHierarchyCodelist", time.Now(), &err)
+	return s.store.GetHierarchyCodelist(ctx, instanceID, dimension)
+}
+
+// GetHierarchies summarises the hierarchy of every dimension of the instance that has one
+func (s *Store) GetHierarchies(ctx context.Context, instanceID string) (res []*datastore.HierarchySummary, err error) {
+	defer s.observe("GetHierarchies", time.Now(), &err)
+	return s.store.GetHierarchies(ctx, instanceID)
+}
+
+// GetHierarchyRoot returns the root node of the hierarchy with the children selected by opts
+func (s *Store) GetHierarchyRoot(ctx context.Context, instanceID, dimension string, opts datastore.ChildOptions) (res *dbmodels.HierarchyResponse, total int, err error) {
+	defer s.observe("GetHierarchyRoot", time.Now(), &err)
+	return s.store.GetHierarchyRoot(ctx, instanceID, dimension, opts)
+}
+
+// GetHierarchyElement returns the node for the given code with its breadcrumbs and the children selected by opts
+func (s *Store) GetHierarchyElement(ctx context.Context, instanceID, dimension, code string, opts datastore.ChildOptions) (res *dbmodels.HierarchyResponse, total int, err error) {
+	defer s.observe("GetHierarchyElement", time.Now(), &err)
+	return s.store.GetHierarchyElement(ctx, instanceID, dimension, code, opts)
+}
+
+// GetHierarchyElements returns the nodes for the given codes with their children and breadcrumbs
+func (s *Store) GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (res map[string]*dbmodels.HierarchyResponse, err error) {
+	defer s.observe("GetHierarchyElements", time.Now(), &err)
+	return s.store.GetHierarchyElements(ctx, instanceID, dimension, codes)
+}
+
+// GetHierarchyDescendants returns the node for the given code with its descendants, limited to depth levels
+func (s *Store) GetHierarchyDescendants(ctx context.Context, instanceID, dimension, code string, depth int) (res *datastore.HierarchyNode, err error) {
+	defer s.observe("GetHierarchyDescendants", time.Now(), &err)
+	return s.store.GetHierarchyDescendants(ctx, instanceID, dimension, code, depth)
+}
+
+// GetHierarchyLeaves returns the leaves below the node for the given code selected by opts
+func (s *Store) GetHierarchyLeaves(ctx context.Context, instanceID, dimension, code string, opts datastore.LeafOptions) (res []*dbmodels.HierarchyElement, total int, err error) {
+	defer s.observe("GetHierarchyLeaves", time.Now(), &err)
+	return s.store.GetHierarchyLeaves(ctx, instanceID, dimension, code, opts)
+}
+
+// GetHierarchyAncestors returns the ancestors of the node for the given code, from its parent up to the root
+func (s *Store) GetHierarchyAncestors(ctx context.Context, instanceID, dimension, code string) (res []*dbmodels.HierarchyElement, err error) {
+	defer s.observe("GetHierarchyAncestors", time.Now(), &err)
+	return s.store.GetHierarchyAncestors(ctx, instanceID, dimension, code)
+}
+
+// GetHierarchySiblings returns the other children of the parent of the node for the given code
+func (s *Store) GetHierarchySiblings(ctx context.Context, instanceID, dimension, code string) (res []*dbmodels.HierarchyElement, err error) {
+	defer s.observe("GetHierarchySiblings", time.Now(), &err)
+	return s.store.GetHierarchySiblings(ctx, instanceID, dimension, code)
+}
+
+// WalkHierarchy visits every node in the hierarchy depth first. The duration reported includes
+// the time spent in fn.
+func (s *Store) WalkHierarchy(ctx context.Context, instanceID, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) (err error) {
+	defer s.observe("WalkHierarchy", time.Now(), &err)
+	return s.store.WalkHierarchy(ctx, instanceID, dimension, opts, fn)
+}
+
+// ListHierarchyNodes lists every node in the hierarchy by code. The duration reported includes the time
+// spent in fn.
+func (s *Store) ListHierarchyNodes(ctx context.Context, instanceID, dimension string, fn datastore.ListFunc) (err error) {
+	defer s.observe("ListHierarchyNodes", time.Now(), &err)
+	return s.store.ListHierarchyNodes(ctx, instanceID, dimension, fn)
+}
+
+// ListDuplicateCodes returns the codes held by more than one node in the hierarchy
+func (s *Store) ListDuplicateCodes(ctx context.Context, instanceID, dimension string) (codes []string, err error) {
+	defer s.observe("ListDuplicateCodes", time.Now(), &err)
+	return s.store.ListDuplicateCodes(ctx, instanceID, dimension)
+}
+
+// SearchHierarchy returns the nodes with labels matching the query, with their breadcrumbs
+func (s *Store) SearchHierarchy(ctx context.Context, instanceID, dimension, query string, limit int) (res []*dbmodels.HierarchyResponse, err error) {
+	defer s.observe("SearchHierarchy", time.Now(), &err)
+	return s.store.SearchHierarchy(ctx, instanceID, dimension, query, limit)
+}
+
+// PutHierarchy creates or replaces the hierarchy for the given instance and dimension, or returns
+// datastore.ErrReadOnly if the underlying store cannot be written to
+func (s *Store) PutHierarchy(ctx context.Context, instanceID, dimension string, h *datastore.Hierarchy) (created bool, err error) {
+	defer s.observe("PutHierarchy", time.Now(), &err)
+
+	writer, ok := s.store.(datastore.Writer)
+	if !ok {
+		return false, datastore.ErrReadOnly
+	}
+	return writer.PutHierarchy(ctx, instanceID, dimension, h)
+}
+
+func (s *Store) observe(method string, start time.Time, err *error) {
+	s.observer.ObserveDatastore(method, time.Since(start), *err)
+}
@@ -0,0 +1,107 @@
+package instrumented
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type observation struct {
+	method string
+	err    error
+}
+
+type fakeObserver struct {
+	observations []observation
+}
+
+func (o *fakeObserver) ObserveDatastore(method string, _ time.Duration, err error) {
+	o.observations = append(o.observations, observation{method: method, err: err})
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errGraph := errors.New("graph error")
+
+	Convey("Given an instrumented datastore", t, func() {
+		mock := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(_ context.Context, _, _ string) (string, error) {
+				return "codelistID", nil
+			},
+			GetHierarchyElementFunc: func(_ context.Context, _, _, _ string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+				return nil, 0, errGraph
+			},
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, _ datastore.WalkFunc) error {
+				return nil
+			},
+			ListHierarchyNodesFunc: func(_ context.Context, _, _ string, _ datastore.ListFunc) error {
+				return errGraph
+			},
+		}
+		observer := &fakeObserver{}
+		store := New(mock, observer)
+
+		Convey("When a call succeeds, its result is returned and observed", func() {
+			codelistID, err := store.GetHierarchyCodelist(ctx, "instance", "dimension")
+			So(err, ShouldBeNil)
+			So(codelistID, ShouldEqual, "codelistID")
+			So(observer.observations, ShouldResemble, []observation{{method: "GetHierarchyCodelist"}})
+		})
+
+		Convey("When a call fails, its error is returned and observed", func() {
+			_, _, err := store.GetHierarchyElement(ctx, "instance", "dimension", "code", datastore.ChildOptions{})
+			So(err, ShouldEqual, errGraph)
+			So(observer.observations, ShouldResemble, []observation{{method: "GetHierarchyElement", err: errGraph}})
+		})
+
+		Convey("When the hierarchy is walked, the walk is observed", func() {
+			So(store.WalkHierarchy(ctx, "instance", "dimension", datastore.WalkOptions{}, nil), ShouldBeNil)
+			So(mock.WalkHierarchyCalls(), ShouldHaveLength, 1)
+			So(observer.observations, ShouldHaveLength, 1)
+			So(observer.observations[0].method, ShouldEqual, "WalkHierarchy")
+		})
+
+		Convey("When listing the hierarchy fails, the error is observed", func() {
+			So(store.ListHierarchyNodes(ctx, "instance", "dimension", nil), ShouldEqual, errGraph)
+			So(observer.observations, ShouldResemble, []observation{{method: "ListHierarchyNodes", err: errGraph}})
+		})
+	})
+
+	Convey("Given an instrumented datastore that can be written to", t, func() {
+		mock := &datastoretest.WritableStorerMock{
+			StorerMock: &datastoretest.StorerMock{},
+			WriterMock: &datastoretest.WriterMock{
+				PutHierarchyFunc: func(_ context.Context, _, _ string, _ *datastore.Hierarchy) (bool, error) {
+					return false, nil
+				},
+			},
+		}
+		observer := &fakeObserver{}
+		store := New(mock, observer)
+
+		Convey("When a hierarchy is written, the write is passed on and observed", func() {
+			created, err := store.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{})
+			So(err, ShouldBeNil)
+			So(created, ShouldBeFalse)
+			So(mock.PutHierarchyCalls(), ShouldHaveLength, 1)
+			So(observer.observations, ShouldResemble, []observation{{method: "PutHierarchy"}})
+		})
+	})
+
+	Convey("Given an instrumented datastore that cannot be written to, writes are rejected and observed", t, func() {
+		observer := &fakeObserver{}
+		store := New(&datastoretest.StorerMock{}, observer)
+
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{})
+		So(err, ShouldEqual, datastore.ErrReadOnly)
+		So(observer.observations, ShouldResemble, []observation{{method: "PutHierarchy", err: datastore.ErrReadOnly}})
+	})
+}
@@ -0,0 +1,21 @@
+package datastore
+
+import (
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+)
+
+// LeafOptions selects which of the leaves below a node are returned. Leaves are always ordered by code, so
+// that every store pages them the same way. The zero value selects every leaf.
+type LeafOptions struct {
+	// HasData, when set, only selects leaves whose has_data flag matches
+	HasData *bool
+	Offset  int
+	// Limit is the maximum number of leaves to select, or 0 for no limit
+	Limit int
+}
+
+// Page filters, orders and pages the given leaves as selected by the options, returning the page and the
+// number of leaves that passed the filter. The leaves given are not modified.
+func (o LeafOptions) Page(leaves []*dbmodels.HierarchyElement) ([]*dbmodels.HierarchyElement, int) {
+	return ChildOptions{HasData: o.HasData, Sort: SortByCode, Offset: o.Offset, Limit: o.Limit}.Page(leaves)
+}
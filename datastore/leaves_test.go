@@ -0,0 +1,39 @@
+package datastore
+
+import (
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLeafOptionsPage(t *testing.T) {
+	t.Parallel()
+
+	leaves := []*dbmodels.HierarchyElement{
+		{ID: "E09000033", HasData: true},
+		{ID: "E12000001", HasData: false},
+		{ID: "E09000001", HasData: true},
+	}
+
+	ids := func(elements []*dbmodels.HierarchyElement) []string {
+		var codes []string
+		for _, element := range elements {
+			codes = append(codes, element.ID)
+		}
+		return codes
+	}
+
+	Convey("The zero value selects every leaf ordered by code", t, func() {
+		page, total := LeafOptions{}.Page(leaves)
+		So(ids(page), ShouldResemble, []string{"E09000001", "E09000033", "E12000001"})
+		So(total, ShouldEqual, 3)
+	})
+
+	Convey("Leaves are filtered by has_data before they are paged", t, func() {
+		hasData := true
+		page, total := LeafOptions{HasData: &hasData, Offset: 1, Limit: 5}.Page(leaves)
+		So(ids(page), ShouldResemble, []string{"E09000033"})
+		So(total, ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+// Fixture is the on-disk representation of a set of hierarchies served by the in-memory store
+type Fixture struct {
+	Hierarchies []Hierarchy `json:"hierarchies"`
+}
+
+// Hierarchy is a single instance/dimension hierarchy described as a flat list of nodes
+type Hierarchy struct {
+	InstanceID string `json:"instance_id"`
+	Dimension  string `json:"dimension"`
+	CodelistID string `json:"code_list_id"`
+	Nodes      []Node `json:"nodes"`
+}
+
+// Node is a single code in a hierarchy. The root node is the only node without a parent
+type Node struct {
+	Code    string `json:"code"`
+	Label   string `json:"label"`
+	Parent  string `json:"parent,omitempty"`
+	Order   *int64 `json:"order,omitempty"`
+	HasData bool   `json:"has_data"`
+}
+
+// ReadFixture reads and decodes the fixture file at the given path, or the fixture directory if the
+// path is a directory
+func ReadFixture(path string) (*Fixture, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadFixtureDir(path)
+	}
+
+	b, err := os.ReadFile(path) //nolint:gosec // path is provided by service configuration
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err = json.Unmarshal(b, &fixture); err != nil {
+		return nil, fmt.Errorf("error decoding fixture file %s: %w", path, err)
+	}
+
+	return &fixture, nil
+}
+
+// ReadFixtureDir reads a directory holding a hierarchy per file, at {instance}/{dimension}.json or
+// {instance}/{dimension}.csv. A JSON file has the code_list_id and nodes of a Hierarchy. A CSV file has
+// a node per row, as read by datastore.ReadNodesCSV, and the dimension as its code list. Other files
+// are ignored.
+func ReadFixtureDir(dir string) (*Fixture, error) {
+	paths, err := fixtureDirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &Fixture{Hierarchies: make([]Hierarchy, 0, len(paths))}
+	for _, path := range paths {
+		h, err := readHierarchyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding fixture file %s: %w", path, err)
+		}
+		fixture.Hierarchies = append(fixture.Hierarchies, *h)
+	}
+
+	return fixture, nil
+}
+
+// fixtureDirFiles lists the hierarchy files in a fixture directory, in order
+func fixtureDirFiles(dir string) ([]string, error) {
+	var paths []string
+	for _, pattern := range []string{"*/*.json", "*/*.csv"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readHierarchyFile reads the hierarchy file at path, naming it after the directory and file it is in
+func readHierarchyFile(path string) (*Hierarchy, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // path is in the directory provided by service configuration
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(path)
+	h := &Hierarchy{
+		InstanceID: filepath.Base(filepath.Dir(path)),
+		Dimension:  strings.TrimSuffix(filepath.Base(path), ext),
+	}
+
+	if ext == ".csv" {
+		nodes, err := datastore.ReadNodesCSV(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		h.CodelistID = h.Dimension
+		h.Nodes = make([]Node, len(nodes))
+		for i, n := range nodes {
+			h.Nodes[i] = Node(n)
+		}
+		return h, nil
+	}
+
+	var file struct {
+		CodelistID string `json:"code_list_id"`
+		Nodes      []Node `json:"nodes"`
+	}
+	if err = json.Unmarshal(b, &file); err != nil {
+		return nil, err
+	}
+	h.CodelistID = file.CodelistID
+	h.Nodes = file.Nodes
+	return h, nil
+}
@@ -0,0 +1,541 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+var (
+	_ datastore.Storer = &Store{}
+	_ datastore.Writer = &Store{}
+)
+
+// Store is an in-memory implementation of datastore.Storer, used for local runs and component tests
+type Store struct {
+	mu          sync.RWMutex
+	hierarchies map[hierarchyKey]*hierarchy
+	// written holds the hierarchies put through PutHierarchy rather than loaded from fixtures, which are
+	// kept when the fixtures are reloaded
+	written map[hierarchyKey]bool
+}
+
+type hierarchyKey struct {
+	instanceID string
+	dimension  string
+}
+
+type hierarchy struct {
+	codelistID string
+	root       *node
+	nodes      map[string]*node
+}
+
+type node struct {
+	Node
+	parent   *node
+	children []*node
+}
+
+// New creates a store holding the hierarchies described by the given fixture
+func New(fixture *Fixture) (*Store, error) {
+	s := &Store{hierarchies: make(map[hierarchyKey]*hierarchy), written: make(map[hierarchyKey]bool)}
+
+	for i := range fixture.Hierarchies {
+		h := &fixture.Hierarchies[i]
+		key := hierarchyKey{instanceID: h.InstanceID, dimension: h.Dimension}
+		if _, ok := s.hierarchies[key]; ok {
+			return nil, fmt.Errorf("duplicate hierarchy for instance %q dimension %q", h.InstanceID, h.Dimension)
+		}
+
+		built, err := buildHierarchy(h)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hierarchy for instance %q dimension %q: %w", h.InstanceID, h.Dimension, err)
+		}
+		s.hierarchies[key] = built
+	}
+
+	return s, nil
+}
+
+// NewFromFile creates a store holding the hierarchies in the fixture file or directory at the given path
+func NewFromFile(path string) (*Store, error) {
+	fixture, err := ReadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(fixture)
+}
+
+// Close is a no-op for the in-memory store
+func (s *Store) Close(ctx context.Context) error {
+	return nil
+}
+
+// GetHierarchyCodelist returns the code list ID for the given instance and dimension
+func (s *Store) GetHierarchyCodelist(ctx context.Context, instanceID, dimension string) (string, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return "", err
+	}
+
+	return h.codelistID, nil
+}
+
+// GetHierarchies summarises every hierarchy loaded for the given instance
+func (s *Store) GetHierarchies(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var summaries []*datastore.HierarchySummary
+	for key, h := range s.hierarchies {
+		if key.instanceID != instanceID {
+			continue
+		}
+		summaries = append(summaries, &datastore.HierarchySummary{
+			Dimension:  key.dimension,
+			CodelistID: h.codelistID,
+			NoOfNodes:  int64(len(h.nodes)),
+			Depth:      h.root.depth(),
+		})
+	}
+
+	if len(summaries) == 0 {
+		return nil, driver.ErrNotFound
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Dimension < summaries[j].Dimension })
+	return summaries, nil
+}
+
+// GetHierarchyRoot returns the root node of the hierarchy with the children selected by opts
+func (s *Store) GetHierarchyRoot(ctx context.Context, instanceID, dimension string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, total := h.root.page(false, opts)
+	return res, total, nil
+}
+
+// GetHierarchyElement returns the node for the given code with its breadcrumbs and the children selected by opts
+func (s *Store) GetHierarchyElement(ctx context.Context, instanceID, dimension, code string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, ok := h.nodes[code]
+	if !ok {
+		return nil, 0, driver.ErrNotFound
+	}
+
+	res, total := n.page(true, opts)
+	return res, total, nil
+}
+
+// GetHierarchyElements returns the nodes for the given codes with their children and breadcrumbs
+func (s *Store) GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make(map[string]*dbmodels.HierarchyResponse, len(codes))
+	for _, code := range codes {
+		if n, ok := h.nodes[code]; ok {
+			elements[code], _ = n.page(true, datastore.ChildOptions{})
+		}
+	}
+
+	return elements, nil
+}
+
+// GetHierarchyDescendants returns the node for the given code with its descendants, limited to depth levels
+func (s *Store) GetHierarchyDescendants(ctx context.Context, instanceID, dimension, code string, depth int) (*datastore.HierarchyNode, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := h.nodes[code]
+	if !ok {
+		return nil, driver.ErrNotFound
+	}
+
+	levels := depth
+	if depth == 0 {
+		levels = -1
+	}
+
+	return n.tree(levels), nil
+}
+
+// GetHierarchyLeaves returns the leaves below the node for the given code selected by opts, and the number
+// of leaves matching opts before they are paged
+func (s *Store) GetHierarchyLeaves(ctx context.Context, instanceID, dimension, code string, opts datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, ok := h.nodes[code]
+	if !ok {
+		return nil, 0, driver.ErrNotFound
+	}
+
+	page, total := opts.Page(n.leaves(nil))
+	return page, total, nil
+}
+
+// GetHierarchyAncestors returns the ancestors of the node for the given code, from its parent up to the root
+func (s *Store) GetHierarchyAncestors(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := h.nodes[code]
+	if !ok {
+		return nil, driver.ErrNotFound
+	}
+
+	return n.response(true).Breadcrumbs, nil
+}
+
+// GetHierarchySiblings returns the other children of the parent of the node for the given code
+func (s *Store) GetHierarchySiblings(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := h.nodes[code]
+	if !ok {
+		return nil, driver.ErrNotFound
+	}
+
+	if n.parent == nil {
+		return nil, nil
+	}
+
+	siblings := make([]*dbmodels.HierarchyElement, 0, len(n.parent.children)-1)
+	for _, sibling := range n.parent.children {
+		if sibling != n {
+			siblings = append(siblings, sibling.element())
+		}
+	}
+
+	return siblings, nil
+}
+
+// WalkHierarchy visits every node in the hierarchy depth first
+func (s *Store) WalkHierarchy(ctx context.Context, instanceID, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) error {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return err
+	}
+
+	start := h.root
+	if opts.Code != "" {
+		var ok bool
+		if start, ok = h.nodes[opts.Code]; !ok {
+			return driver.ErrNotFound
+		}
+	}
+
+	return start.walk(0, opts.Depth, fn)
+}
+
+// ListHierarchyNodes lists every node in the hierarchy by code. Every node held is reachable from the root,
+// as hierarchies that are not a single tree are never loaded.
+func (s *Store) ListHierarchyNodes(ctx context.Context, instanceID, dimension string, fn datastore.ListFunc) error {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range slices.Sorted(maps.Keys(h.nodes)) {
+		n := h.nodes[code]
+		listed := &datastore.ListedNode{HierarchyElement: *n.element()}
+		if n.Parent != "" {
+			listed.ParentIDs = []string{n.Parent}
+		}
+		if err := fn(listed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListDuplicateCodes returns no codes, as nodes are held by code and hierarchies with duplicated codes are never
+// loaded
+func (s *Store) ListDuplicateCodes(ctx context.Context, instanceID, dimension string) ([]string, error) {
+	if _, err := s.hierarchy(instanceID, dimension); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// SearchHierarchy returns the nodes with labels matching the query, with their breadcrumbs
+func (s *Store) SearchHierarchy(ctx context.Context, instanceID, dimension, query string, limit int) ([]*dbmodels.HierarchyResponse, error) {
+	h, err := s.hierarchy(instanceID, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	// matches are ranked, then ordered by label and code within each rank, as the graph store orders them
+	var prefixed, contained []*node
+	for _, n := range h.nodes {
+		switch matches, isPrefix := datastore.MatchLabel(n.Label, query); {
+		case matches && isPrefix:
+			prefixed = append(prefixed, n)
+		case matches:
+			contained = append(contained, n)
+		}
+	}
+	slices.SortFunc(prefixed, byLabel)
+	slices.SortFunc(contained, byLabel)
+
+	matched := append(prefixed, contained...)
+	results := make([]*dbmodels.HierarchyResponse, 0, min(limit, len(matched)))
+	for _, n := range matched[:min(limit, len(matched))] {
+		results = append(results, n.searchResult())
+	}
+
+	return results, nil
+}
+
+// PutHierarchy creates or replaces the hierarchy for the given instance and dimension. Lookups already
+// in progress keep reading the hierarchy that was replaced.
+func (s *Store) PutHierarchy(ctx context.Context, instanceID, dimension string, h *datastore.Hierarchy) (bool, error) {
+	nodes := make([]Node, len(h.Nodes))
+	for i, n := range h.Nodes {
+		nodes[i] = Node(n)
+	}
+
+	built, err := buildHierarchy(&Hierarchy{InstanceID: instanceID, Dimension: dimension, CodelistID: h.CodelistID, Nodes: nodes})
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", datastore.ErrInvalidHierarchy, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hierarchyKey{instanceID: instanceID, dimension: dimension}
+	_, exists := s.hierarchies[key]
+	s.hierarchies[key] = built
+	s.written[key] = true
+	return !exists, nil
+}
+
+func (s *Store) hierarchy(instanceID, dimension string) (*hierarchy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	h, ok := s.hierarchies[hierarchyKey{instanceID: instanceID, dimension: dimension}]
+	if !ok {
+		return nil, driver.ErrNotFound
+	}
+
+	return h, nil
+}
+
+// buildHierarchy links a flat list of nodes into a tree, rejecting the nodes when they are
+// not a single tree as datastore.Hierarchy.Validate does for every store
+func buildHierarchy(h *Hierarchy) (*hierarchy, error) {
+	nodes := make([]datastore.Node, len(h.Nodes))
+	for i, n := range h.Nodes {
+		nodes[i] = datastore.Node(n)
+	}
+	if err := (&datastore.Hierarchy{CodelistID: h.CodelistID, Nodes: nodes}).Validate(); err != nil {
+		return nil, err
+	}
+
+	built := &hierarchy{
+		codelistID: h.CodelistID,
+		nodes:      make(map[string]*node, len(h.Nodes)),
+	}
+
+	for i := range h.Nodes {
+		built.nodes[h.Nodes[i].Code] = &node{Node: h.Nodes[i]}
+	}
+
+	// the hierarchy is a valid tree, so every parent is known and there is exactly one root
+	for i := range h.Nodes {
+		n := built.nodes[h.Nodes[i].Code]
+		if n.Parent == "" {
+			built.root = n
+			continue
+		}
+
+		n.parent = built.nodes[n.Parent]
+		n.parent.children = append(n.parent.children, n)
+	}
+
+	for _, n := range built.nodes {
+		sortChildren(n.children)
+	}
+
+	return built, nil
+}
+
+// sortChildren orders children the same way as the graph database: by order when
+// any child has one, otherwise alphabetically by label
+func sortChildren(children []*node) {
+	hasOrder := false
+	for _, child := range children {
+		if child.Order != nil {
+			hasOrder = true
+			break
+		}
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		a, b := children[i], children[j]
+		if hasOrder && (a.Order == nil) != (b.Order == nil) {
+			return a.Order != nil
+		}
+		if hasOrder && a.Order != nil && *a.Order != *b.Order {
+			return *a.Order < *b.Order
+		}
+		return a.Label < b.Label
+	})
+}
+
+// depth returns the number of levels of descendants below the node
+func (n *node) depth() int {
+	deepest := 0
+	for _, child := range n.children {
+		deepest = max(deepest, 1+child.depth())
+	}
+	return deepest
+}
+
+// response returns the node, with its breadcrumbs if asked for, but without its children
+func (n *node) response(withBreadcrumbs bool) *dbmodels.HierarchyResponse {
+	res := &dbmodels.HierarchyResponse{
+		ID:           n.Code,
+		Label:        n.Label,
+		NoOfChildren: int64(len(n.children)),
+		Order:        copyOrder(n.Order),
+		HasData:      n.HasData,
+	}
+
+	if withBreadcrumbs {
+		for ancestor := n.parent; ancestor != nil; ancestor = ancestor.parent {
+			res.Breadcrumbs = append(res.Breadcrumbs, ancestor.element())
+		}
+	}
+
+	return res
+}
+
+// page returns the node with only the children selected by opts, and the number of children matching
+// opts before they are paged. Children outside the page are never converted to elements.
+func (n *node) page(withBreadcrumbs bool, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int) {
+	selected := make([]*node, 0, len(n.children))
+	for _, child := range n.children {
+		if opts.Matches(child.HasData) {
+			selected = append(selected, child)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return opts.Less(selected[i].Label, selected[i].Code, selected[j].Label, selected[j].Code)
+	})
+
+	start, end := opts.PageBounds(len(selected))
+
+	res := n.response(withBreadcrumbs)
+	for _, child := range selected[start:end] {
+		res.Children = append(res.Children, child.element())
+	}
+
+	return res, len(selected)
+}
+
+// tree returns the node with the given number of levels of descendants below it, or all of them when levels is negative
+func (n *node) tree(levels int) *datastore.HierarchyNode {
+	res := &datastore.HierarchyNode{HierarchyElement: *n.element()}
+	if levels == 0 {
+		return res
+	}
+
+	for _, child := range n.children {
+		res.Children = append(res.Children, child.tree(levels-1))
+	}
+
+	return res
+}
+
+// byLabel orders nodes by label, then by code
+func byLabel(a, b *node) int {
+	return cmp.Or(cmp.Compare(a.Label, b.Label), cmp.Compare(a.Code, b.Code))
+}
+
+func (n *node) searchResult() *dbmodels.HierarchyResponse {
+	return n.response(true)
+}
+
+func (n *node) walk(depth, maxDepth int, fn datastore.WalkFunc) error {
+	if err := fn(&datastore.WalkedNode{HierarchyElement: *n.element(), ParentID: n.Parent, Depth: depth}); err != nil {
+		return err
+	}
+
+	if maxDepth > 0 && depth >= maxDepth {
+		return nil
+	}
+
+	for _, child := range n.children {
+		if err := child.walk(depth+1, maxDepth, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leaves appends the nodes without children in the subtree below the node, the node itself when it has
+// none, to leaves
+func (n *node) leaves(leaves []*dbmodels.HierarchyElement) []*dbmodels.HierarchyElement {
+	if len(n.children) == 0 {
+		return append(leaves, n.element())
+	}
+
+	for _, child := range n.children {
+		leaves = child.leaves(leaves)
+	}
+
+	return leaves
+}
+
+func (n *node) element() *dbmodels.HierarchyElement {
+	return &dbmodels.HierarchyElement{
+		ID:           n.Code,
+		Label:        n.Label,
+		NoOfChildren: int64(len(n.children)),
+		Order:        copyOrder(n.Order),
+		HasData:      n.HasData,
+	}
+}
+
+func copyOrder(order *int64) *int64 {
+	if order == nil {
+		return nil
+	}
+	o := *order
+	return &o
+}
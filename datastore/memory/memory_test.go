@@ -0,0 +1,534 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	fixturePath = "testdata/hierarchies.json"
+	fixtureDir  = "testdata/fixtures"
+)
+
+func TestNewFromFile(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given the example fixture file", t, func() {
+		Convey("When the store is created, then every hierarchy is loaded", func() {
+			store, err := NewFromFile(fixturePath)
+			So(err, ShouldBeNil)
+			So(store.hierarchies, ShouldHaveLength, 2)
+		})
+	})
+
+	Convey("Given the example fixture directory", t, func() {
+		Convey("When the store is created, then the hierarchy in every JSON and CSV file is loaded", func() {
+			fromDir, err := NewFromFile(fixtureDir)
+			So(err, ShouldBeNil)
+			fromFile, err := NewFromFile(fixturePath)
+			So(err, ShouldBeNil)
+
+			So(fromDir.hierarchies, ShouldHaveLength, 2)
+			for key, h := range fromFile.hierarchies {
+				So(fromDir.hierarchies, ShouldContainKey, key)
+				So(fromDir.hierarchies[key].nodes, ShouldHaveLength, len(h.nodes))
+			}
+			So(fromDir.hierarchies[hierarchyKey{"cpih01-instance", "aggregate"}].codelistID, ShouldEqual, "cpih1dim1aggid")
+			So(fromDir.hierarchies[hierarchyKey{"mid-year-pop-instance", "geography"}].codelistID, ShouldEqual, "geography")
+		})
+	})
+
+	Convey("Given a fixture directory with an invalid file, then an error naming it is returned", t, func() {
+		dir := t.TempDir()
+		So(os.Mkdir(filepath.Join(dir, "instance"), 0o755), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "instance", "dimension.csv"), []byte("label\nRoot\n"), 0o600), ShouldBeNil)
+
+		_, err := NewFromFile(dir)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, filepath.Join(dir, "instance", "dimension.csv"))
+	})
+
+	Convey("Given a fixture file that does not exist, then an error is returned", t, func() {
+		store, err := NewFromFile("testdata/missing.json")
+		So(err, ShouldNotBeNil)
+		So(store, ShouldBeNil)
+	})
+}
+
+func TestNewRejectsInvalidHierarchies(t *testing.T) {
+	t.Parallel()
+
+	hierarchyWith := func(nodes ...Node) *Fixture {
+		return &Fixture{Hierarchies: []Hierarchy{{InstanceID: "i", Dimension: "d", CodelistID: "c", Nodes: nodes}}}
+	}
+
+	Convey("A hierarchy with duplicate codes is rejected", t, func() {
+		_, err := New(hierarchyWith(Node{Code: "root"}, Node{Code: "a", Parent: "root"}, Node{Code: "a", Parent: "root"}))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, `duplicate code "a"`)
+	})
+
+	Convey("A hierarchy with an unknown parent is rejected", t, func() {
+		_, err := New(hierarchyWith(Node{Code: "root"}, Node{Code: "a", Parent: "missing"}))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, `unknown parent "missing"`)
+	})
+
+	Convey("A hierarchy with more than one root is rejected", t, func() {
+		_, err := New(hierarchyWith(Node{Code: "root"}, Node{Code: "other"}))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "multiple root nodes")
+	})
+
+	Convey("A hierarchy with a cycle is rejected", t, func() {
+		_, err := New(hierarchyWith(Node{Code: "root"}, Node{Code: "a", Parent: "b"}, Node{Code: "b", Parent: "a"}))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "2 nodes are not reachable from the root")
+	})
+
+	Convey("The same instance and dimension cannot be loaded twice", t, func() {
+		fixture := hierarchyWith(Node{Code: "root"})
+		fixture.Hierarchies = append(fixture.Hierarchies, fixture.Hierarchies[0])
+		_, err := New(fixture)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "duplicate hierarchy")
+	})
+}
+
+func TestStoreLookups(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFromFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("When getting the code list of a known hierarchy, then its ID is returned", t, func() {
+		codelistID, err := store.GetHierarchyCodelist(ctx, "cpih01-instance", "aggregate")
+		So(err, ShouldBeNil)
+		So(codelistID, ShouldEqual, "cpih1dim1aggid")
+	})
+
+	Convey("When listing the hierarchies of an instance, then each is summarised", t, func() {
+		summaries, err := store.GetHierarchies(ctx, "mid-year-pop-instance")
+		So(err, ShouldBeNil)
+		So(summaries, ShouldResemble, []*datastore.HierarchySummary{
+			{Dimension: "geography", CodelistID: "admin-geography", NoOfNodes: 10, Depth: 3},
+		})
+	})
+
+	Convey("When listing the hierarchies of an unknown instance, then ErrNotFound is returned", t, func() {
+		_, err := store.GetHierarchies(ctx, "unknown")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When getting the code list of an unknown hierarchy, then ErrNotFound is returned", t, func() {
+		_, err := store.GetHierarchyCodelist(ctx, "cpih01-instance", "time")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When getting a hierarchy root, then its children are returned in order without breadcrumbs", t, func() {
+		root, _, err := store.GetHierarchyRoot(ctx, "cpih01-instance", "aggregate", datastore.ChildOptions{})
+		So(err, ShouldBeNil)
+		So(root.ID, ShouldEqual, "cpih1dim1A0")
+		So(root.Label, ShouldEqual, "Overall Index")
+		So(root.NoOfChildren, ShouldEqual, 3)
+		So(root.Children, ShouldHaveLength, 3)
+		So(root.Children[0].ID, ShouldEqual, "cpih1dim1G10000")
+		So(root.Children[2].ID, ShouldEqual, "cpih1dim1G30000")
+		So(root.Breadcrumbs, ShouldBeEmpty)
+	})
+
+	Convey("When getting an element, then its children and breadcrumbs from the parent up are returned", t, func() {
+		element, _, err := store.GetHierarchyElement(ctx, "cpih01-instance", "aggregate", "cpih1dim1G10100", datastore.ChildOptions{})
+		So(err, ShouldBeNil)
+		So(element.Label, ShouldEqual, "01.1 Food")
+		So(*element.Order, ShouldEqual, 0)
+		So(element.Children, ShouldHaveLength, 2)
+		So(element.Children[1].HasData, ShouldBeFalse)
+		So(element.Breadcrumbs, ShouldHaveLength, 2)
+		So(element.Breadcrumbs[0].ID, ShouldEqual, "cpih1dim1G10000")
+		So(element.Breadcrumbs[1].ID, ShouldEqual, "cpih1dim1A0")
+	})
+
+	Convey("When children have no order, then they are sorted by label", t, func() {
+		root, _, err := store.GetHierarchyRoot(ctx, "mid-year-pop-instance", "geography", datastore.ChildOptions{})
+		So(err, ShouldBeNil)
+		So(root.Children, ShouldHaveLength, 4)
+		So(root.Children[0].Label, ShouldEqual, "England")
+		So(root.Children[1].Label, ShouldEqual, "Northern Ireland")
+		So(root.Children[3].Label, ShouldEqual, "Wales")
+	})
+
+	Convey("When getting a node with child options, then only the selected children are returned with the matching total", t, func() {
+		hasData := true
+		opts := datastore.ChildOptions{HasData: &hasData, Sort: datastore.SortByCode, Offset: 1, Limit: 2}
+		root, total, err := store.GetHierarchyRoot(ctx, "mid-year-pop-instance", "geography", opts)
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 4)
+		So(root.NoOfChildren, ShouldEqual, 4)
+		So(root.Children, ShouldHaveLength, 2)
+		So(root.Children[0].ID, ShouldEqual, "N92000002")
+		So(root.Children[1].ID, ShouldEqual, "S92000003")
+
+		hasData = false
+		element, total, err := store.GetHierarchyElement(ctx, "cpih01-instance", "aggregate", "cpih1dim1G10100", datastore.ChildOptions{HasData: &hasData})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(element.Children, ShouldHaveLength, 1)
+		So(element.Children[0].ID, ShouldEqual, "cpih1dim1S10102")
+		So(element.Breadcrumbs, ShouldHaveLength, 2)
+	})
+
+	Convey("When getting several elements, then those found are returned by code with their breadcrumbs", t, func() {
+		elements, err := store.GetHierarchyElements(ctx, "cpih01-instance", "aggregate", []string{"cpih1dim1G10100", "unknown", "cpih1dim1A0"})
+		So(err, ShouldBeNil)
+		So(elements, ShouldHaveLength, 2)
+		So(elements["cpih1dim1G10100"].Breadcrumbs, ShouldHaveLength, 2)
+		So(elements["cpih1dim1A0"].Breadcrumbs, ShouldBeEmpty)
+		So(elements, ShouldNotContainKey, "unknown")
+	})
+
+	Convey("When getting several elements of an unknown hierarchy, then ErrNotFound is returned", t, func() {
+		_, err := store.GetHierarchyElements(ctx, "cpih01-instance", "time", []string{"cpih1dim1A0"})
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When getting an unknown element, then ErrNotFound is returned", t, func() {
+		_, _, err := store.GetHierarchyElement(ctx, "cpih01-instance", "aggregate", "unknown", datastore.ChildOptions{})
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestStoreRelatives(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFromFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("When getting the ancestors of a code, then they are returned from the parent up to the root", t, func() {
+		ancestors, err := store.GetHierarchyAncestors(ctx, "mid-year-pop-instance", "geography", "E09000001")
+		So(err, ShouldBeNil)
+		So(ancestors, ShouldHaveLength, 3)
+		So(ancestors[0].ID, ShouldEqual, "E12000007")
+		So(ancestors[2].ID, ShouldEqual, "K02000001")
+	})
+
+	Convey("When getting the siblings of a code, then the other children of its parent are returned in order", t, func() {
+		siblings, err := store.GetHierarchySiblings(ctx, "mid-year-pop-instance", "geography", "E92000001")
+		So(err, ShouldBeNil)
+		So(siblings, ShouldHaveLength, 3)
+		So(siblings[0].Label, ShouldEqual, "Northern Ireland")
+		So(siblings[2].Label, ShouldEqual, "Wales")
+	})
+
+	Convey("When getting the relatives of the root, then it has no ancestors or siblings", t, func() {
+		ancestors, err := store.GetHierarchyAncestors(ctx, "mid-year-pop-instance", "geography", "K02000001")
+		So(err, ShouldBeNil)
+		So(ancestors, ShouldBeEmpty)
+
+		siblings, err := store.GetHierarchySiblings(ctx, "mid-year-pop-instance", "geography", "K02000001")
+		So(err, ShouldBeNil)
+		So(siblings, ShouldBeEmpty)
+	})
+
+	Convey("When getting the relatives of an unknown code, then ErrNotFound is returned", t, func() {
+		_, err := store.GetHierarchyAncestors(ctx, "mid-year-pop-instance", "geography", "unknown")
+		So(err, ShouldEqual, driver.ErrNotFound)
+
+		_, err = store.GetHierarchySiblings(ctx, "mid-year-pop-instance", "geography", "unknown")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestStoreDescendants(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFromFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("When getting all descendants of the root, then the whole tree is returned", t, func() {
+		tree, err := store.GetHierarchyDescendants(ctx, "cpih01-instance", "aggregate", "cpih1dim1A0", 0)
+		So(err, ShouldBeNil)
+		So(tree.ID, ShouldEqual, "cpih1dim1A0")
+		So(tree.Children, ShouldHaveLength, 3)
+		So(tree.Children[0].Children, ShouldHaveLength, 2)
+		So(tree.Children[0].Children[0].Children, ShouldHaveLength, 2)
+	})
+
+	Convey("When getting descendants to a depth, then deeper levels are omitted", t, func() {
+		tree, err := store.GetHierarchyDescendants(ctx, "cpih01-instance", "aggregate", "cpih1dim1A0", 1)
+		So(err, ShouldBeNil)
+		So(tree.Children, ShouldHaveLength, 3)
+		So(tree.Children[0].NoOfChildren, ShouldEqual, 2)
+		So(tree.Children[0].Children, ShouldBeEmpty)
+	})
+
+	Convey("When getting descendants of an unknown code, then ErrNotFound is returned", t, func() {
+		_, err := store.GetHierarchyDescendants(ctx, "cpih01-instance", "aggregate", "unknown", 0)
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestStoreLeaves(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFromFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("When getting the leaves below a node, then every leaf in its subtree is returned by code", t, func() {
+		leaves, total, err := store.GetHierarchyLeaves(ctx, "cpih01-instance", "aggregate", "cpih1dim1G10000", datastore.LeafOptions{})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 3)
+		So(leaves, ShouldHaveLength, 3)
+		So(leaves[0].ID, ShouldEqual, "cpih1dim1G10200")
+		So(leaves[1].ID, ShouldEqual, "cpih1dim1S10101")
+		So(leaves[2].ID, ShouldEqual, "cpih1dim1S10102")
+	})
+
+	Convey("When filtering the leaves on has_data, then only matching leaves are returned", t, func() {
+		hasData := false
+		leaves, total, err := store.GetHierarchyLeaves(ctx, "cpih01-instance", "aggregate", "cpih1dim1A0", datastore.LeafOptions{HasData: &hasData})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(leaves[0].ID, ShouldEqual, "cpih1dim1S10102")
+	})
+
+	Convey("When paging the leaves, then the page is returned with the number of leaves", t, func() {
+		all, _, err := store.GetHierarchyLeaves(ctx, "cpih01-instance", "aggregate", "cpih1dim1A0", datastore.LeafOptions{})
+		So(err, ShouldBeNil)
+
+		page, total, err := store.GetHierarchyLeaves(ctx, "cpih01-instance", "aggregate", "cpih1dim1A0", datastore.LeafOptions{Offset: 1, Limit: 2})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 6)
+		So(page, ShouldResemble, all[1:3])
+	})
+
+	Convey("When getting the leaves below a node without children, then the node is its own leaf", t, func() {
+		leaves, total, err := store.GetHierarchyLeaves(ctx, "mid-year-pop-instance", "geography", "E09000001", datastore.LeafOptions{})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(leaves[0].ID, ShouldEqual, "E09000001")
+	})
+
+	Convey("When getting the leaves below an unknown code, then ErrNotFound is returned", t, func() {
+		_, _, err := store.GetHierarchyLeaves(ctx, "cpih01-instance", "aggregate", "unknown", datastore.LeafOptions{})
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestStoreWalk(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFromFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("When walking a hierarchy, every node is visited depth first with its parent", t, func() {
+		var visited []*datastore.WalkedNode
+		err := store.WalkHierarchy(ctx, "mid-year-pop-instance", "geography", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+			visited = append(visited, node)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(visited, ShouldHaveLength, 10)
+		So(visited[0].ID, ShouldEqual, "K02000001")
+		So(visited[0].ParentID, ShouldBeEmpty)
+		So(visited[1].ID, ShouldEqual, "E92000001")
+		So(visited[2].ID, ShouldEqual, "E12000007")
+		So(visited[3].ID, ShouldEqual, "E09000001")
+		So(visited[3].ParentID, ShouldEqual, "E12000007")
+		So(visited[3].Depth, ShouldEqual, 3)
+	})
+
+	Convey("When the visiting function fails, the walk stops with its error", t, func() {
+		errStop := errors.New("stop")
+		calls := 0
+		err := store.WalkHierarchy(ctx, "mid-year-pop-instance", "geography", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+			calls++
+			return errStop
+		})
+		So(err, ShouldEqual, errStop)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("When walking the subtree below a code to a depth, only its nodes down to that depth are visited", t, func() {
+		var visited []*datastore.WalkedNode
+		err := store.WalkHierarchy(ctx, "mid-year-pop-instance", "geography", datastore.WalkOptions{Code: "E92000001", Depth: 1}, func(node *datastore.WalkedNode) error {
+			visited = append(visited, node)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(visited[0].ID, ShouldEqual, "E92000001")
+		So(visited[0].ParentID, ShouldEqual, "K02000001")
+		So(visited[0].Depth, ShouldEqual, 0)
+		for _, node := range visited[1:] {
+			So(node.ParentID, ShouldEqual, "E92000001")
+			So(node.Depth, ShouldEqual, 1)
+		}
+	})
+
+	Convey("When walking from a code that is not in the hierarchy, then ErrNotFound is returned", t, func() {
+		err := store.WalkHierarchy(ctx, "mid-year-pop-instance", "geography", datastore.WalkOptions{Code: "missing"}, func(node *datastore.WalkedNode) error { return nil })
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When walking an unknown hierarchy, then ErrNotFound is returned", t, func() {
+		err := store.WalkHierarchy(ctx, "unknown", "geography", datastore.WalkOptions{}, func(node *datastore.WalkedNode) error { return nil })
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When listing a hierarchy, every node is listed by code with its parent", t, func() {
+		var listed []*datastore.ListedNode
+		err := store.ListHierarchyNodes(ctx, "mid-year-pop-instance", "geography", func(node *datastore.ListedNode) error {
+			listed = append(listed, node)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(listed, ShouldHaveLength, 10)
+		So(listed[0].ID, ShouldEqual, "E09000001")
+		So(listed[0].ParentIDs, ShouldResemble, []string{"E12000007"})
+		So(slices.IsSortedFunc(listed, func(a, b *datastore.ListedNode) int { return strings.Compare(a.ID, b.ID) }), ShouldBeTrue)
+
+		root := slices.IndexFunc(listed, func(node *datastore.ListedNode) bool { return node.ID == "K02000001" })
+		So(listed[root].ParentIDs, ShouldBeNil)
+	})
+
+	Convey("When listing an unknown hierarchy, then ErrNotFound is returned", t, func() {
+		err := store.ListHierarchyNodes(ctx, "unknown", "geography", func(node *datastore.ListedNode) error { return nil })
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestStoreSearch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFromFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("When searching for a label, then labels starting with the query rank first, ignoring case", t, func() {
+		results, err := store.SearchHierarchy(ctx, "mid-year-pop-instance", "geography", "lON", 10)
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 2)
+		So(results[0].Label, ShouldEqual, "London")
+		So(results[0].Children, ShouldBeEmpty)
+		So(results[0].Breadcrumbs, ShouldHaveLength, 2)
+		So(results[0].Breadcrumbs[0].Label, ShouldEqual, "England")
+		So(results[1].Label, ShouldEqual, "City of London")
+		So(results[1].Breadcrumbs, ShouldHaveLength, 3)
+	})
+
+	Convey("When searching with a limit, then no more than the limit are returned", t, func() {
+		results, err := store.SearchHierarchy(ctx, "mid-year-pop-instance", "geography", "n", 3)
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 3)
+		So(results[0].Label, ShouldEqual, "North East")
+		So(results[1].Label, ShouldEqual, "Northern Ireland")
+	})
+
+	Convey("When searching, then each rank is ordered by label as in the graph store", t, func() {
+		results, err := store.SearchHierarchy(ctx, "mid-year-pop-instance", "geography", "n", 10)
+		So(err, ShouldBeNil)
+		labels := make([]string, len(results))
+		for i, res := range results {
+			labels[i] = res.Label
+		}
+		So(labels, ShouldResemble, []string{
+			"North East", "Northern Ireland",
+			"City of London", "England", "London", "Scotland", "United Kingdom", "Westminster",
+		})
+	})
+
+	Convey("When nothing matches, then no results are returned", t, func() {
+		results, err := store.SearchHierarchy(ctx, "mid-year-pop-instance", "geography", "Paris", 10)
+		So(err, ShouldBeNil)
+		So(results, ShouldBeEmpty)
+	})
+
+	Convey("When searching an unknown hierarchy, then ErrNotFound is returned", t, func() {
+		_, err := store.SearchHierarchy(ctx, "unknown", "geography", "London", 10)
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+}
+
+func TestStorePutHierarchy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	order := int64(1)
+	hierarchy := &datastore.Hierarchy{
+		CodelistID: "new-codelist",
+		Nodes: []datastore.Node{
+			{Code: "root", Label: "Root"},
+			{Code: "b", Label: "B", Parent: "root", HasData: true},
+			{Code: "a", Label: "A", Parent: "root", Order: &order},
+		},
+	}
+
+	Convey("Given a store", t, func() {
+		store, err := NewFromFile(fixturePath)
+		So(err, ShouldBeNil)
+
+		Convey("When a hierarchy is put for a new dimension, then it is created and can be read", func() {
+			created, err := store.PutHierarchy(ctx, "cpih01-instance", "new", hierarchy)
+			So(err, ShouldBeNil)
+			So(created, ShouldBeTrue)
+
+			codelistID, err := store.GetHierarchyCodelist(ctx, "cpih01-instance", "new")
+			So(err, ShouldBeNil)
+			So(codelistID, ShouldEqual, "new-codelist")
+
+			root, total, err := store.GetHierarchyRoot(ctx, "cpih01-instance", "new", datastore.ChildOptions{})
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 2)
+			So(root.Children[0].ID, ShouldEqual, "a")
+			So(root.Children[1].HasData, ShouldBeTrue)
+		})
+
+		Convey("When a hierarchy is put for an existing dimension, then it replaces the hierarchy", func() {
+			created, err := store.PutHierarchy(ctx, "cpih01-instance", "aggregate", hierarchy)
+			So(err, ShouldBeNil)
+			So(created, ShouldBeFalse)
+
+			root, _, err := store.GetHierarchyRoot(ctx, "cpih01-instance", "aggregate", datastore.ChildOptions{})
+			So(err, ShouldBeNil)
+			So(root.ID, ShouldEqual, "root")
+		})
+
+		Convey("When a hierarchy that is not a single tree is put, then it is rejected and nothing changes", func() {
+			_, err := store.PutHierarchy(ctx, "cpih01-instance", "aggregate", &datastore.Hierarchy{
+				Nodes: []datastore.Node{{Code: "root"}, {Code: "other"}},
+			})
+			So(errors.Is(err, datastore.ErrInvalidHierarchy), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "multiple root nodes")
+
+			codelistID, err := store.GetHierarchyCodelist(ctx, "cpih01-instance", "aggregate")
+			So(err, ShouldBeNil)
+			So(codelistID, ShouldNotEqual, "new-codelist")
+		})
+	})
+}
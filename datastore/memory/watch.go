@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileState is what is compared to tell whether a fixture file has changed
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watch reloads the store from the fixture file or directory at path whenever a fixture is added, changed
+// or removed, checking every interval. reloaded is called after each reload with any error reading the
+// fixtures, in which case the hierarchies already loaded are kept. The returned function stops watching.
+func (s *Store) Watch(path string, interval time.Duration, reloaded func(err error)) func() {
+	last := fixtureState(path)
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				state := fixtureState(path)
+				if maps.Equal(state, last) {
+					continue
+				}
+				last = state
+				reloaded(s.reload(path))
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// reload replaces the hierarchies loaded from fixtures with those in the fixture file or directory at path,
+// or leaves the store as it is if any of them cannot be read. Hierarchies written with PutHierarchy are kept,
+// unless a fixture now holds the same hierarchy, which then takes their place.
+func (s *Store) reload(path string) error {
+	loaded, err := NewFromFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.written {
+		if _, ok := loaded.hierarchies[key]; ok {
+			delete(s.written, key)
+			continue
+		}
+		loaded.hierarchies[key] = s.hierarchies[key]
+	}
+	s.hierarchies = loaded.hierarchies
+	return nil
+}
+
+// fixtureState returns the state of the fixture file at path, or of each file in the fixture directory.
+// Files that cannot be read are left out, so that they are reloaded once they can be.
+func fixtureState(path string) map[string]fileState {
+	paths := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		paths, _ = fixtureDirFiles(path)
+	}
+
+	state := make(map[string]fileState, len(paths))
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			state[filepath.Clean(p)] = fileState{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return state
+}
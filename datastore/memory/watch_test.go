@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	writeFile := func(path, content string) {
+		So(os.MkdirAll(filepath.Dir(path), 0o755), ShouldBeNil)
+		So(os.WriteFile(path, []byte(content), 0o600), ShouldBeNil)
+	}
+
+	Convey("Given a store watching a fixture directory", t, func() {
+		dir := t.TempDir()
+		writeFile(filepath.Join(dir, "instance", "dimension.csv"), "code,label,parent\nroot,Root,\n")
+
+		store, err := NewFromFile(dir)
+		So(err, ShouldBeNil)
+
+		reloaded := make(chan error, 10)
+		stop := store.Watch(dir, 5*time.Millisecond, func(err error) { reloaded <- err })
+		defer stop()
+
+		waitForReload := func() error {
+			select {
+			case err := <-reloaded:
+				return err
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for fixtures to be reloaded")
+				return nil
+			}
+		}
+
+		Convey("When a fixture is changed, the store is reloaded with it", func() {
+			writeFile(filepath.Join(dir, "instance", "dimension.csv"), "code,label,parent\nroot,Root,\na,A,root\n")
+			So(waitForReload(), ShouldBeNil)
+
+			res, _, err := store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{})
+			So(err, ShouldBeNil)
+			So(res.NoOfChildren, ShouldEqual, 1)
+		})
+
+		Convey("When a fixture is added, its hierarchy is served", func() {
+			writeFile(filepath.Join(dir, "other", "geography.json"), `{"code_list_id": "cl", "nodes": [{"code": "uk", "label": "UK"}]}`)
+			So(waitForReload(), ShouldBeNil)
+
+			codelistID, err := store.GetHierarchyCodelist(ctx, "other", "geography")
+			So(err, ShouldBeNil)
+			So(codelistID, ShouldEqual, "cl")
+		})
+
+		Convey("When a fixture is removed, its hierarchy is no longer served", func() {
+			So(os.Remove(filepath.Join(dir, "instance", "dimension.csv")), ShouldBeNil)
+			So(waitForReload(), ShouldBeNil)
+
+			_, err := store.GetHierarchyCodelist(ctx, "instance", "dimension")
+			So(err, ShouldEqual, driver.ErrNotFound)
+		})
+
+		Convey("When a fixture is changed to be invalid, the error is reported and the hierarchies already loaded are kept", func() {
+			writeFile(filepath.Join(dir, "instance", "dimension.csv"), "code,label,parent\nroot,Root,\na,A,missing\n")
+			So(waitForReload(), ShouldNotBeNil)
+
+			codelistID, err := store.GetHierarchyCodelist(ctx, "instance", "dimension")
+			So(err, ShouldBeNil)
+			So(codelistID, ShouldEqual, "dimension")
+		})
+
+		Convey("When a hierarchy is put and the fixtures are then reloaded, the hierarchy put is still served", func() {
+			created, err := store.PutHierarchy(ctx, "written", "geography", &datastore.Hierarchy{
+				CodelistID: "cl",
+				Nodes:      []datastore.Node{{Code: "uk", Label: "UK"}, {Code: "wales", Label: "Wales", Parent: "uk"}},
+			})
+			So(err, ShouldBeNil)
+			So(created, ShouldBeTrue)
+
+			writeFile(filepath.Join(dir, "instance", "dimension.csv"), "code,label,parent\nroot,Root,\na,A,root\n")
+			So(waitForReload(), ShouldBeNil)
+
+			res, _, err := store.GetHierarchyRoot(ctx, "written", "geography", datastore.ChildOptions{})
+			So(err, ShouldBeNil)
+			So(res.Label, ShouldEqual, "UK")
+			So(res.NoOfChildren, ShouldEqual, 1)
+
+			res, _, err = store.GetHierarchyRoot(ctx, "instance", "dimension", datastore.ChildOptions{})
+			So(err, ShouldBeNil)
+			So(res.NoOfChildren, ShouldEqual, 1)
+		})
+
+		Convey("When a hierarchy is put and a fixture is then added for it, the fixture takes its place", func() {
+			_, err := store.PutHierarchy(ctx, "other", "geography", &datastore.Hierarchy{
+				CodelistID: "written",
+				Nodes:      []datastore.Node{{Code: "uk", Label: "UK"}},
+			})
+			So(err, ShouldBeNil)
+
+			writeFile(filepath.Join(dir, "other", "geography.json"), `{"code_list_id": "fixture", "nodes": [{"code": "uk", "label": "UK"}]}`)
+			So(waitForReload(), ShouldBeNil)
+
+			codelistID, err := store.GetHierarchyCodelist(ctx, "other", "geography")
+			So(err, ShouldBeNil)
+			So(codelistID, ShouldEqual, "fixture")
+		})
+	})
+
+	Convey("Given a store watching a fixture directory that has not changed, it is not reloaded", t, func() {
+		store, err := NewFromFile(fixtureDir)
+		So(err, ShouldBeNil)
+
+		reloaded := make(chan error, 1)
+		stop := store.Watch(fixtureDir, time.Millisecond, func(err error) { reloaded <- err })
+		time.Sleep(20 * time.Millisecond)
+		stop()
+
+		So(reloaded, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,266 @@
+package traced
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	_ datastore.Storer = &Store{}
+	_ datastore.Writer = &Store{}
+)
+
+var tracer = otel.Tracer("github.com/ONSdigital/dp-hierarchy-api/datastore/traced")
+
+// Store is a datastore.Storer that records a span for every call made through it. Each span has
+// the hierarchy and code looked up and the number of nodes in the result.
+type Store struct {
+	store datastore.Storer
+}
+
+// New creates a Store that traces calls to the given store
+func New(store datastore.Storer) *Store {
+	return &Store{store: store}
+}
+
+// Close closes the underlying store
+func (s *Store) Close(ctx context.Context) error {
+	return s.store.Close(ctx)
+}
+
+// GetHierarchyCodelist returns the code list ID for the given instance and dimension
+func (s *Store) GetHierarchyCodelist(ctx context.Context, instanceID, dimension string) (string, error) {
+	ctx, span := start(ctx, "GetHierarchyCodelist", instanceID, dimension)
+	defer span.End()
+
+	codelistID, err := s.store.GetHierarchyCodelist(ctx, instanceID, dimension)
+	end(span, 1, err)
+	return codelistID, err
+}
+
+// GetHierarchies summarises the hierarchy of every dimension of the instance that has one
+func (s *Store) GetHierarchies(ctx context.Context, instanceID string) ([]*datastore.HierarchySummary, error) {
+	ctx, span := start(ctx, "GetHierarchies", instanceID, "")
+	defer span.End()
+
+	res, err := s.store.GetHierarchies(ctx, instanceID)
+	end(span, len(res), err)
+	return res, err
+}
+
+// GetHierarchyRoot returns the root node of the hierarchy with the children selected by opts
+func (s *Store) GetHierarchyRoot(ctx context.Context, instanceID, dimension string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	ctx, span := start(ctx, "GetHierarchyRoot", instanceID, dimension, childAttributes(opts)...)
+	defer span.End()
+
+	res, total, err := s.store.GetHierarchyRoot(ctx, instanceID, dimension, opts)
+	end(span, responseSize(res), err)
+	return res, total, err
+}
+
+// GetHierarchyElement returns the node for the given code with its breadcrumbs and the children selected by opts
+func (s *Store) GetHierarchyElement(ctx context.Context, instanceID, dimension, code string, opts datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+	ctx, span := start(ctx, "GetHierarchyElement", instanceID, dimension, append(childAttributes(opts), attribute.String("code", code))...)
+	defer span.End()
+
+	res, total, err := s.store.GetHierarchyElement(ctx, instanceID, dimension, code, opts)
+	end(span, responseSize(res), err)
+	return res, total, err
+}
+
+// GetHierarchyElements returns the nodes for the given codes with their children and breadcrumbs
+func (s *Store) GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+	ctx, span := start(ctx, "GetHierarchyElements", instanceID, dimension, attribute.Int("codes", len(codes)))
+	defer span.End()
+
+	res, err := s.store.GetHierarchyElements(ctx, instanceID, dimension, codes)
+	end(span, len(res), err)
+	return res, err
+}
+
+// GetHierarchyDescendants returns the node for the given code with its descendants, limited to depth levels
+func (s *Store) GetHierarchyDescendants(ctx context.Context, instanceID, dimension, code string, depth int) (*datastore.HierarchyNode, error) {
+	ctx, span := start(ctx, "GetHierarchyDescendants", instanceID, dimension, attribute.String("code", code), attribute.Int("depth", depth))
+	defer span.End()
+
+	res, err := s.store.GetHierarchyDescendants(ctx, instanceID, dimension, code, depth)
+	end(span, treeSize(res), err)
+	return res, err
+}
+
+// GetHierarchyLeaves returns the leaves below the node for the given code selected by opts
+func (s *Store) GetHierarchyLeaves(ctx context.Context, instanceID, dimension, code string, opts datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+	ctx, span := start(ctx, "GetHierarchyLeaves", instanceID, dimension, append(leafAttributes(opts), attribute.String("code", code))...)
+	defer span.End()
+
+	res, total, err := s.store.GetHierarchyLeaves(ctx, instanceID, dimension, code, opts)
+	end(span, len(res), err)
+	return res, total, err
+}
+
+// GetHierarchyAncestors returns the ancestors of the node for the given code, from its parent up to the root
+func (s *Store) GetHierarchyAncestors(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	ctx, span := start(ctx, "GetHierarchyAncestors", instanceID, dimension, attribute.String("code", code))
+	defer span.End()
+
+	res, err := s.store.GetHierarchyAncestors(ctx, instanceID, dimension, code)
+	end(span, len(res), err)
+	return res, err
+}
+
+// GetHierarchySiblings returns the other children of the parent of the node for the given code
+func (s *Store) GetHierarchySiblings(ctx context.Context, instanceID, dimension, code string) ([]*dbmodels.HierarchyElement, error) {
+	ctx, span := start(ctx, "GetHierarchySiblings", instanceID, dimension, attribute.String("code", code))
+	defer span.End()
+
+	res, err := s.store.GetHierarchySiblings(ctx, instanceID, dimension, code)
+	end(span, len(res), err)
+	return res, err
+}
+
+// WalkHierarchy visits every node in the hierarchy depth first. The span includes the time spent in fn.
+func (s *Store) WalkHierarchy(ctx context.Context, instanceID, dimension string, opts datastore.WalkOptions, fn datastore.WalkFunc) error {
+	ctx, span := start(ctx, "WalkHierarchy", instanceID, dimension, attribute.String("code", opts.Code), attribute.Int("depth", opts.Depth))
+	defer span.End()
+
+	visited := 0
+	err := s.store.WalkHierarchy(ctx, instanceID, dimension, opts, func(node *datastore.WalkedNode) error {
+		visited++
+		return fn(node)
+	})
+	end(span, visited, err)
+	return err
+}
+
+// ListHierarchyNodes lists every node in the hierarchy by code. The span includes the time spent in fn.
+func (s *Store) ListHierarchyNodes(ctx context.Context, instanceID, dimension string, fn datastore.ListFunc) error {
+	ctx, span := start(ctx, "ListHierarchyNodes", instanceID, dimension)
+	defer span.End()
+
+	listed := 0
+	err := s.store.ListHierarchyNodes(ctx, instanceID, dimension, func(node *datastore.ListedNode) error {
+		listed++
+		return fn(node)
+	})
+	end(span, listed, err)
+	return err
+}
+
+// ListDuplicateCodes returns the codes held by more than one node in the hierarchy
+func (s *Store) ListDuplicateCodes(ctx context.Context, instanceID, dimension string) ([]string, error) {
+	ctx, span := start(ctx, "ListDuplicateCodes", instanceID, dimension)
+	defer span.End()
+
+	res, err := s.store.ListDuplicateCodes(ctx, instanceID, dimension)
+	end(span, len(res), err)
+	return res, err
+}
+
+// SearchHierarchy returns the nodes with labels matching the query, with their breadcrumbs
+func (s *Store) SearchHierarchy(ctx context.Context, instanceID, dimension, query string, limit int) ([]*dbmodels.HierarchyResponse, error) {
+	ctx, span := start(ctx, "SearchHierarchy", instanceID, dimension, attribute.String("query", query), attribute.Int("limit", limit))
+	defer span.End()
+
+	res, err := s.store.SearchHierarchy(ctx, instanceID, dimension, query, limit)
+	end(span, len(res), err)
+	return res, err
+}
+
+// PutHierarchy creates or replaces the hierarchy for the given instance and dimension, or returns
+// datastore.ErrReadOnly if the underlying store cannot be written to
+func (s *Store) PutHierarchy(ctx context.Context, instanceID, dimension string, h *datastore.Hierarchy) (bool, error) {
+	ctx, span := start(ctx, "PutHierarchy", instanceID, dimension, attribute.Int("nodes", len(h.Nodes)))
+	defer span.End()
+
+	writer, ok := s.store.(datastore.Writer)
+	if !ok {
+		end(span, 0, datastore.ErrReadOnly)
+		return false, datastore.ErrReadOnly
+	}
+
+	created, err := writer.PutHierarchy(ctx, instanceID, dimension, h)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("created", created))
+	}
+	end(span, len(h.Nodes), err)
+	return created, err
+}
+
+func start(ctx context.Context, method, instanceID, dimension string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("instance_id", instanceID), attribute.String("dimension", dimension))
+	return tracer.Start(ctx, "datastore."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end records the number of nodes in the result of a call, or its error. Hierarchies or codes
+// that do not exist are not treated as errors.
+func end(span trace.Span, size int, err error) {
+	switch {
+	case errors.Is(err, driver.ErrNotFound):
+		span.SetAttributes(attribute.Bool("not_found", true))
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	default:
+		span.SetAttributes(attribute.Int("result.size", size))
+	}
+}
+
+// childAttributes describes the children selected by opts, leaving out those that select every child
+func childAttributes(opts datastore.ChildOptions) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if opts.HasData != nil {
+		attrs = append(attrs, attribute.Bool("children.has_data", *opts.HasData))
+	}
+	if opts.Sort != "" {
+		attrs = append(attrs, attribute.String("children.sort", string(opts.Sort)))
+	}
+	if opts.Offset > 0 {
+		attrs = append(attrs, attribute.Int("children.offset", opts.Offset))
+	}
+	if opts.Limit > 0 {
+		attrs = append(attrs, attribute.Int("children.limit", opts.Limit))
+	}
+	return attrs
+}
+
+func leafAttributes(opts datastore.LeafOptions) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if opts.HasData != nil {
+		attrs = append(attrs, attribute.Bool("leaves.has_data", *opts.HasData))
+	}
+	if opts.Offset > 0 {
+		attrs = append(attrs, attribute.Int("leaves.offset", opts.Offset))
+	}
+	if opts.Limit > 0 {
+		attrs = append(attrs, attribute.Int("leaves.limit", opts.Limit))
+	}
+	return attrs
+}
+
+// responseSize counts the node and its children
+func responseSize(res *dbmodels.HierarchyResponse) int {
+	if res == nil {
+		return 0
+	}
+	return 1 + len(res.Children)
+}
+
+func treeSize(node *datastore.HierarchyNode) int {
+	if node == nil {
+		return 0
+	}
+
+	size := 1
+	for _, child := range node.Children {
+		size += treeSize(child)
+	}
+	return size
+}
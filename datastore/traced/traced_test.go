@@ -0,0 +1,182 @@
+package traced
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStore(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+
+	lastSpan := func() (sdktrace.ReadOnlySpan, map[attribute.Key]attribute.Value) {
+		ended := recorder.Ended()
+		span := ended[len(ended)-1]
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range span.Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+		return span, attrs
+	}
+
+	mock := &datastoretest.StorerMock{
+		GetHierarchyElementFunc: func(_ context.Context, _, _, code string, _ datastore.ChildOptions) (*dbmodels.HierarchyResponse, int, error) {
+			switch code {
+			case "missing":
+				return nil, 0, driver.ErrNotFound
+			case "broken":
+				return nil, 0, errors.New("graph error")
+			}
+			return &dbmodels.HierarchyResponse{ID: code, Children: []*dbmodels.HierarchyElement{{ID: "a"}, {ID: "b"}}}, 0, nil
+		},
+		GetHierarchyDescendantsFunc: func(_ context.Context, _, _, code string, _ int) (*datastore.HierarchyNode, error) {
+			leaf := &datastore.HierarchyNode{}
+			return &datastore.HierarchyNode{Children: []*datastore.HierarchyNode{{Children: []*datastore.HierarchyNode{leaf}}, {}}}, nil
+		},
+		GetHierarchyLeavesFunc: func(_ context.Context, _, _, _ string, _ datastore.LeafOptions) ([]*dbmodels.HierarchyElement, int, error) {
+			return []*dbmodels.HierarchyElement{{ID: "a"}, {ID: "b"}}, 5, nil
+		},
+		WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+			for i := 0; i < 3; i++ {
+				if err := fn(&datastore.WalkedNode{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ListHierarchyNodesFunc: func(_ context.Context, _, _ string, fn datastore.ListFunc) error {
+			for i := 0; i < 2; i++ {
+				if err := fn(&datastore.ListedNode{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	store := New(mock)
+
+	Convey("When a node is looked up, a span with the lookup and the size of the result is recorded", t, func() {
+		res, _, err := store.GetHierarchyElement(ctx, "instance", "dimension", "code", datastore.ChildOptions{})
+		So(err, ShouldBeNil)
+		So(res.ID, ShouldEqual, "code")
+
+		span, attrs := lastSpan()
+		So(span.Name(), ShouldEqual, "datastore.GetHierarchyElement")
+		So(attrs["instance_id"].AsString(), ShouldEqual, "instance")
+		So(attrs["dimension"].AsString(), ShouldEqual, "dimension")
+		So(attrs["code"].AsString(), ShouldEqual, "code")
+		So(attrs["result.size"].AsInt64(), ShouldEqual, 3)
+		So(span.Status().Code, ShouldEqual, codes.Unset)
+	})
+
+	Convey("When a node is not found, the span is not marked as an error", t, func() {
+		_, _, err := store.GetHierarchyElement(ctx, "instance", "dimension", "missing", datastore.ChildOptions{})
+		So(err, ShouldEqual, driver.ErrNotFound)
+
+		span, attrs := lastSpan()
+		So(attrs["not_found"].AsBool(), ShouldBeTrue)
+		So(span.Status().Code, ShouldEqual, codes.Unset)
+	})
+
+	Convey("When a lookup fails, the span records the error", t, func() {
+		_, _, err := store.GetHierarchyElement(ctx, "instance", "dimension", "broken", datastore.ChildOptions{})
+		So(err, ShouldNotBeNil)
+
+		span, _ := lastSpan()
+		So(span.Status().Code, ShouldEqual, codes.Error)
+		So(span.Status().Description, ShouldEqual, "graph error")
+	})
+
+	Convey("When descendants are looked up, every node in the subtree is counted", t, func() {
+		_, err := store.GetHierarchyDescendants(ctx, "instance", "dimension", "code", 0)
+		So(err, ShouldBeNil)
+
+		_, attrs := lastSpan()
+		So(attrs["result.size"].AsInt64(), ShouldEqual, 4)
+		So(attrs["depth"].AsInt64(), ShouldEqual, 0)
+	})
+
+	Convey("When leaves are looked up, the leaf options and the leaves on the page are recorded", t, func() {
+		hasData := false
+		_, total, err := store.GetHierarchyLeaves(ctx, "instance", "dimension", "code", datastore.LeafOptions{HasData: &hasData, Offset: 2, Limit: 2})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 5)
+
+		span, attrs := lastSpan()
+		So(span.Name(), ShouldEqual, "datastore.GetHierarchyLeaves")
+		So(attrs["code"].AsString(), ShouldEqual, "code")
+		So(attrs["leaves.has_data"].AsBool(), ShouldBeFalse)
+		So(attrs["leaves.offset"].AsInt64(), ShouldEqual, 2)
+		So(attrs["leaves.limit"].AsInt64(), ShouldEqual, 2)
+		So(attrs["result.size"].AsInt64(), ShouldEqual, 2)
+	})
+
+	Convey("When the hierarchy is walked, the nodes visited are counted", t, func() {
+		visited := 0
+		err := store.WalkHierarchy(ctx, "instance", "dimension", datastore.WalkOptions{}, func(*datastore.WalkedNode) error {
+			visited++
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(visited, ShouldEqual, 3)
+
+		_, attrs := lastSpan()
+		So(attrs["result.size"].AsInt64(), ShouldEqual, 3)
+	})
+
+	Convey("When the hierarchy is listed, the nodes listed are counted", t, func() {
+		listed := 0
+		err := store.ListHierarchyNodes(ctx, "instance", "dimension", func(*datastore.ListedNode) error {
+			listed++
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(listed, ShouldEqual, 2)
+
+		span, attrs := lastSpan()
+		So(span.Name(), ShouldEqual, "datastore.ListHierarchyNodes")
+		So(attrs["result.size"].AsInt64(), ShouldEqual, 2)
+	})
+
+	Convey("When a hierarchy is written, a span with the number of nodes and whether it was created is recorded", t, func() {
+		writable := New(&datastoretest.WritableStorerMock{
+			StorerMock: mock,
+			WriterMock: &datastoretest.WriterMock{
+				PutHierarchyFunc: func(_ context.Context, _, _ string, _ *datastore.Hierarchy) (bool, error) {
+					return true, nil
+				},
+			},
+		})
+
+		created, err := writable.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{Nodes: []datastore.Node{{Code: "root"}, {Code: "a"}}})
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+
+		span, attrs := lastSpan()
+		So(span.Name(), ShouldEqual, "datastore.PutHierarchy")
+		So(attrs["nodes"].AsInt64(), ShouldEqual, 2)
+		So(attrs["created"].AsBool(), ShouldBeTrue)
+	})
+
+	Convey("When a hierarchy is written to a datastore that cannot be written to, the span records the error", t, func() {
+		_, err := store.PutHierarchy(ctx, "instance", "dimension", &datastore.Hierarchy{})
+		So(err, ShouldEqual, datastore.ErrReadOnly)
+
+		span, _ := lastSpan()
+		So(span.Status().Code, ShouldEqual, codes.Error)
+	})
+}
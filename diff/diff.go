@@ -0,0 +1,117 @@
+// Package diff compares the hierarchies of a dimension in two instances, such as two editions of a dataset.
+package diff
+
+import (
+	"context"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"golang.org/x/sync/errgroup"
+)
+
+// ChangeSet describes how a hierarchy changed from one instance to another. Nodes are listed in the
+// order they are walked, parents before their children, from the hierarchy they are found in.
+type ChangeSet struct {
+	// Added are the nodes whose codes are only in the later hierarchy
+	Added []*datastore.WalkedNode
+	// Removed are the nodes whose codes are only in the earlier hierarchy
+	Removed []*datastore.WalkedNode
+	// Relabelled are the nodes whose labels changed
+	Relabelled []*Change
+	// Moved are the nodes whose parents changed
+	Moved []*Change
+	// Reordered are the nodes that kept their parent but changed their order amongst its children
+	Reordered []*Change
+}
+
+// Change is a node in both hierarchies as it was before and after the change
+type Change struct {
+	Before *datastore.WalkedNode
+	After  *datastore.WalkedNode
+}
+
+// IsEmpty reports whether the hierarchies are the same
+func (c *ChangeSet) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Relabelled) == 0 && len(c.Moved) == 0 && len(c.Reordered) == 0
+}
+
+// Compare walks the hierarchies of dimension in both instances and returns the changes from the one in
+// fromInstanceID to the one in toInstanceID. The hierarchies are walked concurrently and held in memory
+// while they are compared. driver.ErrNotFound is returned if either instance has no hierarchy for dimension.
+func Compare(ctx context.Context, store datastore.Storer, dimension, fromInstanceID, toInstanceID string) (*ChangeSet, error) {
+	var from, to *snapshot
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		from, err = take(ctx, store, fromInstanceID, dimension)
+		return err
+	})
+	g.Go(func() (err error) {
+		to, err = take(ctx, store, toInstanceID, dimension)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return compare(from, to), nil
+}
+
+// snapshot is every node of a hierarchy, by code and in the order they were walked
+type snapshot struct {
+	nodes map[string]*datastore.WalkedNode
+	order []*datastore.WalkedNode
+}
+
+func take(ctx context.Context, store datastore.Storer, instanceID, dimension string) (*snapshot, error) {
+	s := &snapshot{nodes: make(map[string]*datastore.WalkedNode)}
+
+	err := store.WalkHierarchy(ctx, instanceID, dimension, datastore.WalkOptions{}, func(node *datastore.WalkedNode) error {
+		n := *node
+		s.nodes[n.ID] = &n
+		s.order = append(s.order, &n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func compare(from, to *snapshot) *ChangeSet {
+	changes := &ChangeSet{}
+
+	for _, after := range to.order {
+		before, ok := from.nodes[after.ID]
+		if !ok {
+			changes.Added = append(changes.Added, after)
+			continue
+		}
+
+		change := &Change{Before: before, After: after}
+		if before.Label != after.Label {
+			changes.Relabelled = append(changes.Relabelled, change)
+		}
+		switch {
+		case before.ParentID != after.ParentID:
+			changes.Moved = append(changes.Moved, change)
+		case !sameOrder(before.Order, after.Order):
+			changes.Reordered = append(changes.Reordered, change)
+		}
+	}
+
+	for _, before := range from.order {
+		if _, ok := to.nodes[before.ID]; !ok {
+			changes.Removed = append(changes.Removed, before)
+		}
+	}
+
+	return changes
+}
+
+func sameOrder(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func order(o int64) *int64 {
+	return &o
+}
+
+func newStore(t *testing.T) *memory.Store {
+	store, err := memory.New(&memory.Fixture{Hierarchies: []memory.Hierarchy{
+		{
+			InstanceID: "2022",
+			Dimension:  "geography",
+			Nodes: []memory.Node{
+				{Code: "K02000001", Label: "United Kingdom"},
+				{Code: "E92000001", Label: "England", Parent: "K02000001", Order: order(0)},
+				{Code: "W92000004", Label: "Wales", Parent: "K02000001", Order: order(1)},
+				{Code: "E12000007", Label: "London", Parent: "E92000001"},
+				{Code: "E09000001", Label: "City of London", Parent: "E12000007"},
+				{Code: "W06000015", Label: "Cardiff", Parent: "W92000004"},
+			},
+		},
+		{
+			InstanceID: "2023",
+			Dimension:  "geography",
+			Nodes: []memory.Node{
+				{Code: "K02000001", Label: "United Kingdom"},
+				{Code: "E92000001", Label: "England", Parent: "K02000001", Order: order(1)},
+				{Code: "W92000004", Label: "Wales / Cymru", Parent: "K02000001", Order: order(0)},
+				{Code: "E12000007", Label: "London", Parent: "E92000001"},
+				{Code: "E09000001", Label: "City of London", Parent: "E92000001"},
+				{Code: "E09000033", Label: "Westminster", Parent: "E12000007"},
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func codes(nodes []*datastore.WalkedNode) []string {
+	var ids []string
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newStore(t)
+
+	Convey("When two editions of a hierarchy are compared, every kind of change is found", t, func() {
+		changes, err := Compare(ctx, store, "geography", "2022", "2023")
+		So(err, ShouldBeNil)
+		So(changes.IsEmpty(), ShouldBeFalse)
+
+		So(codes(changes.Added), ShouldResemble, []string{"E09000033"})
+		So(codes(changes.Removed), ShouldResemble, []string{"W06000015"})
+
+		So(changes.Relabelled, ShouldHaveLength, 1)
+		So(changes.Relabelled[0].Before.Label, ShouldEqual, "Wales")
+		So(changes.Relabelled[0].After.Label, ShouldEqual, "Wales / Cymru")
+
+		So(changes.Moved, ShouldHaveLength, 1)
+		So(changes.Moved[0].After.ID, ShouldEqual, "E09000001")
+		So(changes.Moved[0].Before.ParentID, ShouldEqual, "E12000007")
+		So(changes.Moved[0].After.ParentID, ShouldEqual, "E92000001")
+
+		So(changes.Reordered, ShouldHaveLength, 2)
+		So(changes.Reordered[0].After.ID, ShouldEqual, "W92000004")
+		So(*changes.Reordered[0].Before.Order, ShouldEqual, 1)
+		So(*changes.Reordered[0].After.Order, ShouldEqual, 0)
+		So(changes.Reordered[1].After.ID, ShouldEqual, "E92000001")
+	})
+
+	Convey("When the comparison is reversed, additions and removals swap", t, func() {
+		changes, err := Compare(ctx, store, "geography", "2023", "2022")
+		So(err, ShouldBeNil)
+		So(codes(changes.Added), ShouldResemble, []string{"W06000015"})
+		So(codes(changes.Removed), ShouldResemble, []string{"E09000033"})
+	})
+
+	Convey("When a hierarchy is compared with itself, there are no changes", t, func() {
+		changes, err := Compare(ctx, store, "geography", "2022", "2022")
+		So(err, ShouldBeNil)
+		So(changes.IsEmpty(), ShouldBeTrue)
+	})
+
+	Convey("When either instance has no hierarchy for the dimension, ErrNotFound is returned", t, func() {
+		_, err := Compare(ctx, store, "geography", "2022", "2024")
+		So(err, ShouldEqual, driver.ErrNotFound)
+
+		_, err = Compare(ctx, store, "geography", "2024", "2023")
+		So(err, ShouldEqual, driver.ErrNotFound)
+	})
+
+	Convey("When walking a hierarchy fails, the error is returned", t, func() {
+		errGraph := errors.New("graph error")
+		mock := &datastoretest.StorerMock{
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, _ datastore.WalkFunc) error {
+				return errGraph
+			},
+		}
+
+		changes, err := Compare(ctx, mock, "geography", "2022", "2023")
+		So(err, ShouldEqual, errGraph)
+		So(changes, ShouldBeNil)
+	})
+}
@@ -0,0 +1,115 @@
+// Package graphqlapi serves queries over any part of a hierarchy in a single request, for clients
+// that need shapes of the tree the REST endpoints do not provide
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-net/v2/links"
+	"github.com/ONSdigital/log.go/v2/log"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	gqlotel "github.com/graph-gophers/graphql-go/trace/otel"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// maxQueryDepth is the deepest nesting of fields allowed in a query
+	maxQueryDepth = 12
+	// maxQueryNodes is the most nodes a query may resolve. Each page of children can hold up to
+	// maxChildrenLimit nodes, so a query nested within maxQueryDepth could otherwise ask for far more.
+	maxQueryNodes = 10000
+	// maxBodyBytes is the largest query request body accepted
+	maxBodyBytes = 1 << 20
+)
+
+// Handler serves GraphQL queries posted as JSON
+type Handler struct {
+	schema             *graphql.Schema
+	store              datastore.Storer
+	codeListAPIURL     *url.URL
+	enableURLRewriting bool
+	maxNodes           int
+}
+
+// queryRequest is the body of a GraphQL request
+type queryRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler creates a Handler resolving queries against the given store. Code links are rewritten
+// from the forwarded headers of each request when enableURLRewriting is true.
+func NewHandler(store datastore.Storer, codeListAPIURL *url.URL, enableURLRewriting bool) *Handler {
+	tracer := &gqlotel.Tracer{Tracer: otel.Tracer("github.com/ONSdigital/dp-hierarchy-api/graphqlapi")}
+
+	return &Handler{
+		schema:             graphql.MustParseSchema(schema, &queryResolver{}, graphql.MaxDepth(maxQueryDepth), graphql.Tracer(tracer)),
+		store:              store,
+		codeListAPIURL:     codeListAPIURL,
+		enableURLRewriting: enableURLRewriting,
+		maxNodes:           maxQueryNodes,
+	}
+}
+
+// ServeHTTP executes the query in the request body. As is usual for GraphQL, errors resolving the
+// query are returned in the errors of a successful response, alongside whatever could be resolved.
+// A query that resolves more nodes than the budget allows is stopped, and nothing but the error is
+// returned for it.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	var body queryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodyBytes)).Decode(&body); err != nil {
+		log.Error(ctx, "invalid graphql request", err)
+		writeResponse(w, req, http.StatusBadRequest, &graphql.Response{
+			Errors: []*errors.QueryError{errors.Errorf("failed to parse request body: %s", err)},
+		})
+		return
+	}
+
+	codeListURL := h.codeListAPIURL.String()
+	if h.enableURLRewriting {
+		codeListURL = links.FromHeadersOrDefault(&req.Header, req, h.codeListAPIURL).URL.String()
+	}
+
+	r := &request{
+		store:       h.store,
+		codeListURL: codeListURL,
+		maxNodes:    h.maxNodes,
+		cancel:      cancel,
+		hierarchies: make(map[hierarchyKey]*hierarchyEntry),
+	}
+
+	res := h.schema.Exec(withRequest(ctx, r), body.Query, body.OperationName, body.Variables)
+	if r.overBudget() {
+		res = &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("%s", errTooManyNodes)}}
+	}
+
+	logData := log.Data{"operation_name": body.OperationName, "num_errors": len(res.Errors), "nodes": r.nodes}
+	log.Info(ctx, "graphql query executed", logData)
+
+	writeResponse(w, req, http.StatusOK, res)
+}
+
+func writeResponse(w http.ResponseWriter, req *http.Request, status int, res *graphql.Response) {
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Error(req.Context(), "error marshalling graphql response", err)
+		http.Error(w, fmt.Sprintf("error marshalling response: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Error(req.Context(), "graphql endpoint: error writing bytes to response", err)
+	}
+}
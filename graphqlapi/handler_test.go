@@ -0,0 +1,253 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var codeListAPIURL, _ = url.Parse("http://localhost:22400")
+
+func order(o int64) *int64 {
+	return &o
+}
+
+// countingStore counts the lookups of batches of nodes made through a Storer
+type countingStore struct {
+	datastore.Storer
+	batches atomic.Int32
+}
+
+func (s *countingStore) GetHierarchyElements(ctx context.Context, instanceID, dimension string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+	s.batches.Add(1)
+	return s.Storer.GetHierarchyElements(ctx, instanceID, dimension, codes)
+}
+
+func newStore() *countingStore {
+	store, err := memory.New(&memory.Fixture{Hierarchies: []memory.Hierarchy{
+		{
+			InstanceID: "2022",
+			Dimension:  "geography",
+			CodelistID: "admin-geography",
+			Nodes: []memory.Node{
+				{Code: "K02000001", Label: "United Kingdom", HasData: true},
+				{Code: "E92000001", Label: "England", Parent: "K02000001", Order: order(0), HasData: true},
+				{Code: "W92000004", Label: "Wales", Parent: "K02000001", Order: order(1)},
+				{Code: "E12000007", Label: "London", Parent: "E92000001", HasData: true},
+				{Code: "E12000008", Label: "South East", Parent: "E92000001", HasData: true},
+				{Code: "E09000001", Label: "City of London", Parent: "E12000007"},
+				{Code: "W06000015", Label: "Cardiff", Parent: "W92000004"},
+			},
+		},
+	}})
+	if err != nil {
+		panic(err)
+	}
+	return &countingStore{Storer: store}
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func query(handler http.Handler, q string, headers ...string) (*httptest.ResponseRecorder, *response) {
+	body, _ := json.Marshal(queryRequest{Query: q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var res response
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	return w, &res
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	Convey("When querying a node with its parent's siblings and two levels of children, they are returned in one response", t, func() {
+		store := newStore()
+		handler := NewHandler(store, codeListAPIURL, false)
+
+		w, res := query(handler, `{
+			node(instanceId: "2022", dimension: "geography", code: "E92000001") {
+				label
+				order
+				hasData
+				codeLink { id href }
+				parent { parent { code } children { label } }
+				children { label children { label } }
+			}
+		}`)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+		So(res.Errors, ShouldBeEmpty)
+		So(string(res.Data["node"]), ShouldEqual, `{"label":"England","order":0,"hasData":true,`+
+			`"codeLink":{"id":"E92000001","href":"http://localhost:22400/code-lists/admin-geography/codes/E92000001"},`+
+			`"parent":{"parent":null,"children":[{"label":"England"},{"label":"Wales"}]},`+
+			`"children":[{"label":"London","children":[{"label":"City of London"}]},{"label":"South East","children":[]}]}`)
+
+		Convey("And the children are looked up as a batch rather than one at a time", func() {
+			// the node itself, its ancestors, and its children
+			So(store.batches.Load(), ShouldEqual, 3)
+		})
+	})
+
+	Convey("When querying the root of a hierarchy with a selection of its children, only those children are returned", t, func() {
+		w, res := query(NewHandler(newStore(), codeListAPIURL, false), `{
+			hierarchy(instanceId: "2022", dimension: "geography") {
+				code
+				noOfChildren
+				breadcrumbs { code }
+				children(sort: LABEL, offset: 1, limit: 1) { code }
+			}
+		}`)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(res.Errors, ShouldBeEmpty)
+		So(string(res.Data["hierarchy"]), ShouldEqual, `{"code":"K02000001","noOfChildren":2,"breadcrumbs":[],"children":[{"code":"W92000004"}]}`)
+	})
+
+	Convey("When querying the breadcrumbs of every grandchild, each level is looked up once however many lists it is in", t, func() {
+		store := newStore()
+
+		_, res := query(NewHandler(store, codeListAPIURL, false), `{
+			hierarchy(instanceId: "2022", dimension: "geography") {
+				children { children { breadcrumbs { label } } }
+			}
+		}`)
+
+		So(res.Errors, ShouldBeEmpty)
+		So(string(res.Data["hierarchy"]), ShouldContainSubstring, `{"breadcrumbs":[{"label":"Wales"},{"label":"United Kingdom"}]}`)
+		// the root's children, then the children of both of them together
+		So(store.batches.Load(), ShouldEqual, 2)
+	})
+
+	Convey("When the hierarchy or code does not exist, null is returned", t, func() {
+		_, res := query(NewHandler(newStore(), codeListAPIURL, false), `{
+			hierarchy(instanceId: "2022", dimension: "aggregate") { label }
+			node(instanceId: "2022", dimension: "geography", code: "X") { label }
+		}`)
+
+		So(res.Errors, ShouldBeEmpty)
+		So(string(res.Data["hierarchy"]), ShouldEqual, "null")
+		So(string(res.Data["node"]), ShouldEqual, "null")
+	})
+
+	Convey("When URL rewriting is enabled, code links are rewritten from the forwarded headers", t, func() {
+		_, res := query(NewHandler(newStore(), codeListAPIURL, true), `{
+			node(instanceId: "2022", dimension: "geography", code: "W92000004") { codeLink { href } }
+		}`, "X-Forwarded-Proto", "https", "X-Forwarded-Host", "api.example.com", "X-Forwarded-Path-Prefix", "v1")
+
+		So(res.Errors, ShouldBeEmpty)
+		So(string(res.Data["node"]), ShouldEqual, `{"codeLink":{"href":"https://api.example.com/v1/code-lists/admin-geography/codes/W92000004"}}`)
+	})
+
+	Convey("When the children limit is out of range, an error is returned for the field", t, func() {
+		for _, limit := range []string{"0", "1001"} {
+			_, res := query(NewHandler(newStore(), codeListAPIURL, false), `{
+				hierarchy(instanceId: "2022", dimension: "geography") { label children(limit: `+limit+`) { label } }
+			}`)
+
+			So(res.Errors, ShouldHaveLength, 1)
+			So(res.Errors[0].Message, ShouldEqual, errInvalidLimit.Error())
+		}
+	})
+
+	Convey("When no children limit is given, a page of 100 children is returned", t, func() {
+		nodes := []memory.Node{{Code: "root", Label: "Root"}}
+		for i := range 150 {
+			nodes = append(nodes, memory.Node{Code: fmt.Sprintf("c%03d", i), Label: "Child", Parent: "root"})
+		}
+		store, err := memory.New(&memory.Fixture{Hierarchies: []memory.Hierarchy{
+			{InstanceID: "2022", Dimension: "wide", CodelistID: "wide", Nodes: nodes},
+		}})
+		So(err, ShouldBeNil)
+
+		_, res := query(NewHandler(store, codeListAPIURL, false), `{
+			hierarchy(instanceId: "2022", dimension: "wide") { children { code } }
+		}`)
+
+		So(res.Errors, ShouldBeEmpty)
+		var hierarchy struct{ Children []struct{ Code string } }
+		So(json.Unmarshal(res.Data["hierarchy"], &hierarchy), ShouldBeNil)
+		So(hierarchy.Children, ShouldHaveLength, 100)
+	})
+
+	Convey("When the datastore fails, an internal error is returned without its cause", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(context.Context, string, string) (string, error) {
+				return "", errors.New("graph error")
+			},
+		}
+
+		w, res := query(NewHandler(store, codeListAPIURL, false), `{ hierarchy(instanceId: "2022", dimension: "geography") { label } }`)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(res.Errors, ShouldHaveLength, 1)
+		So(res.Errors[0].Message, ShouldEqual, errInternal.Error())
+	})
+
+	Convey("When the query is nested too deeply, it is rejected", t, func() {
+		q := "{ hierarchy(instanceId: \"2022\", dimension: \"geography\") { " +
+			strings.Repeat("children { ", maxQueryDepth) + "label" + strings.Repeat(" }", maxQueryDepth) + " } }"
+
+		_, res := query(NewHandler(newStore(), codeListAPIURL, false), q)
+		So(res.Errors, ShouldNotBeEmpty)
+		So(res.Data, ShouldBeNil)
+	})
+
+	Convey("When a query resolves more nodes than its budget, it is rejected without data", t, func() {
+		store := newStore()
+		handler := NewHandler(store, codeListAPIURL, false)
+		handler.maxNodes = 5
+
+		q := `{ hierarchy(instanceId: "2022", dimension: "geography") { children(limit: 1000) { children(limit: 1000) { children(limit: 1000) { code } } } } }`
+		w, res := query(handler, q)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(res.Data, ShouldBeNil)
+		So(res.Errors, ShouldHaveLength, 1)
+		So(res.Errors[0].Message, ShouldEqual, errTooManyNodes.Error())
+		// the root and its two children fit the budget, and their children are looked up to count theirs
+		So(store.batches.Load(), ShouldEqual, 1)
+
+		Convey("And the same query within the budget is resolved", func() {
+			handler.maxNodes = 7
+
+			_, res := query(handler, q)
+			So(res.Errors, ShouldBeEmpty)
+			So(string(res.Data["hierarchy"]), ShouldContainSubstring, `"code":"E09000001"`)
+		})
+	})
+
+	Convey("When the request body is not JSON, a 400 response is returned", t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("{"))
+		w := httptest.NewRecorder()
+
+		NewHandler(newStore(), codeListAPIURL, false).ServeHTTP(w, req)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, "failed to parse request body")
+	})
+}
@@ -0,0 +1,285 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+type requestKey struct{}
+
+// request holds the hierarchies looked up while resolving a single query, so that each node is only
+// looked up once however many times the query refers to it
+type request struct {
+	store       datastore.Storer
+	codeListURL string
+	// maxNodes is the most nodes the query may resolve, and cancel stops resolving it once it has tried to
+	// resolve more
+	maxNodes int
+	cancel   context.CancelFunc
+
+	mu          sync.Mutex
+	hierarchies map[hierarchyKey]*hierarchyEntry
+	nodes       int
+	exceeded    bool
+}
+
+type hierarchyKey struct {
+	instanceID string
+	dimension  string
+}
+
+type hierarchyEntry struct {
+	once      sync.Once
+	hierarchy *hierarchy
+	err       error
+}
+
+func withRequest(ctx context.Context, r *request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+func getRequest(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// charge adds n nodes to those resolved by the query, returning errTooManyNodes if that takes it over its
+// budget. The query's context is cancelled at the same time, so that lookups still running are stopped.
+func (r *request) charge(n int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nodes += n
+	if r.nodes > r.maxNodes {
+		r.exceeded = true
+		r.cancel()
+	}
+	if r.exceeded {
+		return errTooManyNodes
+	}
+
+	return nil
+}
+
+// overBudget reports whether the query tried to resolve more nodes than its budget allows
+func (r *request) overBudget() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exceeded
+}
+
+// hierarchy returns the hierarchy for the instance and dimension, or nil if there is none
+func (r *request) hierarchy(ctx context.Context, instanceID, dimension string) (*hierarchy, error) {
+	key := hierarchyKey{instanceID: instanceID, dimension: dimension}
+
+	r.mu.Lock()
+	entry, ok := r.hierarchies[key]
+	if !ok {
+		entry = &hierarchyEntry{}
+		r.hierarchies[key] = entry
+	}
+	r.mu.Unlock()
+
+	entry.once.Do(func() {
+		codelistID, err := r.store.GetHierarchyCodelist(ctx, instanceID, dimension)
+		if err != nil && err != driver.ErrNotFound {
+			entry.err = err
+			return
+		}
+		if err == driver.ErrNotFound || codelistID == "" {
+			return
+		}
+
+		entry.hierarchy = &hierarchy{
+			store:       r.store,
+			codeListURL: r.codeListURL,
+			instanceID:  instanceID,
+			dimension:   dimension,
+			codelistID:  codelistID,
+			nodes:       make(map[string]*dbmodels.HierarchyResponse),
+		}
+	})
+
+	return entry.hierarchy, entry.err
+}
+
+// hierarchy holds the nodes of one hierarchy looked up so far, with their children and breadcrumbs
+type hierarchy struct {
+	store       datastore.Storer
+	codeListURL string
+	instanceID  string
+	dimension   string
+	codelistID  string
+
+	mu    sync.Mutex
+	nodes map[string]*dbmodels.HierarchyResponse
+}
+
+// add records the node that has been looked up for code
+func (h *hierarchy) add(code string, res *dbmodels.HierarchyResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nodes[code] = res
+}
+
+// get returns the node for code if it has been looked up
+func (h *hierarchy) get(code string) (*dbmodels.HierarchyResponse, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	res, ok := h.nodes[code]
+	return res, ok
+}
+
+// load looks up the nodes for the codes that have not been looked up already, in a single call
+func (h *hierarchy) load(ctx context.Context, codes []string) error {
+	h.mu.Lock()
+	var missing []string
+	for _, code := range codes {
+		if _, ok := h.nodes[code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	found, err := h.store.GetHierarchyElements(ctx, h.instanceID, h.dimension, missing)
+	if err != nil {
+		return err
+	}
+
+	for code, res := range found {
+		h.add(code, res)
+	}
+
+	return nil
+}
+
+// batch is a list of nodes that are looked up together. Every node in a list is resolved with the
+// same selection of fields, so when one of them needs its children, parent or breadcrumbs, all of
+// them will: looking them up together costs one call to the datastore per list rather than per node.
+// The lists of nodes under every node in a batch make up a single batch in turn, so each level of a
+// query is looked up in one call however many lists it is split into.
+type batch struct {
+	hierarchy *hierarchy
+	codes     []string
+	once      sync.Once
+	err       error
+
+	mu    sync.Mutex
+	lists map[listKey]*listEntry
+}
+
+// listKey identifies a selection of nodes from each node in a batch, such as a page of its children
+type listKey struct {
+	field    string
+	hasData  bool
+	filtered bool
+	sort     datastore.ChildSort
+	offset   int
+	limit    int
+}
+
+type listEntry struct {
+	once  sync.Once
+	nodes map[string][]*nodeResolver
+	err   error
+}
+
+// load looks up every node in the batch the first time it is called
+func (b *batch) load(ctx context.Context) error {
+	b.once.Do(func() {
+		b.err = b.hierarchy.load(ctx, b.codes)
+	})
+	return b.err
+}
+
+// details returns the node for code, with its children and breadcrumbs, looking up every node in
+// the batch the first time it is called
+func (b *batch) details(ctx context.Context, code string) (*dbmodels.HierarchyResponse, error) {
+	if err := b.load(ctx); err != nil {
+		return nil, err
+	}
+
+	res, ok := b.hierarchy.get(code)
+	if !ok {
+		return nil, fmt.Errorf("code %q not found in the hierarchy", code)
+	}
+
+	return res, nil
+}
+
+// list returns the nodes selected by list from the node for code. The first time a key is asked for, the
+// nodes are selected from every node in the batch, charged to the budget of the request, and put in a
+// single batch of their own.
+func (b *batch) list(ctx context.Context, code string, key listKey, list func(*dbmodels.HierarchyResponse) []*dbmodels.HierarchyElement) ([]*nodeResolver, error) {
+	b.mu.Lock()
+	if b.lists == nil {
+		b.lists = make(map[listKey]*listEntry)
+	}
+	entry, ok := b.lists[key]
+	if !ok {
+		entry = &listEntry{}
+		b.lists[key] = entry
+	}
+	b.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.nodes, entry.err = b.listAll(ctx, list)
+	})
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	return entry.nodes[code], nil
+}
+
+func (b *batch) listAll(ctx context.Context, list func(*dbmodels.HierarchyResponse) []*dbmodels.HierarchyElement) (map[string][]*nodeResolver, error) {
+	if err := b.load(ctx); err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string][]*dbmodels.HierarchyElement, len(b.codes))
+	size := 0
+	for _, code := range b.codes {
+		if _, ok := listed[code]; !ok {
+			res, ok := b.hierarchy.get(code)
+			if !ok {
+				return nil, fmt.Errorf("code %q not found in the hierarchy", code)
+			}
+			listed[code] = list(res)
+		}
+		// a code in the batch more than once is resolved, and so charged for, each time
+		size += len(listed[code])
+	}
+
+	if err := getRequest(ctx).charge(size); err != nil {
+		return nil, err
+	}
+
+	next := &batch{hierarchy: b.hierarchy}
+	seen := make(map[string]bool)
+	nodes := make(map[string][]*nodeResolver, len(listed))
+	for _, code := range b.codes {
+		if _, ok := nodes[code]; ok {
+			continue
+		}
+		resolvers := make([]*nodeResolver, 0, len(listed[code]))
+		for _, element := range listed[code] {
+			if !seen[element.ID] {
+				seen[element.ID] = true
+				next.codes = append(next.codes, element.ID)
+			}
+			resolvers = append(resolvers, &nodeResolver{hierarchy: b.hierarchy, element: element, batch: next})
+		}
+		nodes[code] = resolvers
+	}
+
+	return nodes, nil
+}
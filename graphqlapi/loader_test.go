@@ -0,0 +1,122 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	newHierarchy := func(err error) (*hierarchy, *datastoretest.StorerMock) {
+		store := &datastoretest.StorerMock{
+			GetHierarchyElementsFunc: func(_ context.Context, _, _ string, codes []string) (map[string]*dbmodels.HierarchyResponse, error) {
+				if err != nil {
+					return nil, err
+				}
+				found := make(map[string]*dbmodels.HierarchyResponse)
+				for _, code := range codes {
+					if code != "missing" {
+						found[code] = &dbmodels.HierarchyResponse{ID: code, Label: "label " + code}
+					}
+				}
+				return found, nil
+			},
+		}
+
+		return &hierarchy{
+			store:      store,
+			instanceID: "2022",
+			dimension:  "geography",
+			codelistID: "admin-geography",
+			nodes:      map[string]*dbmodels.HierarchyResponse{"known": {ID: "known"}},
+		}, store
+	}
+
+	Convey("When the nodes in a batch are looked up concurrently, the codes not already known are looked up once", t, func() {
+		h, store := newHierarchy(nil)
+		b := &batch{hierarchy: h, codes: []string{"a", "known", "b"}}
+
+		var wg sync.WaitGroup
+		labels := make([]string, len(b.codes))
+		for i, code := range b.codes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := b.details(ctx, code)
+				if err == nil {
+					labels[i] = res.Label
+				}
+			}()
+		}
+		wg.Wait()
+
+		So(labels, ShouldResemble, []string{"label a", "", "label b"})
+		So(store.GetHierarchyElementsCalls(), ShouldHaveLength, 1)
+		So(store.GetHierarchyElementsCalls()[0].Codes, ShouldResemble, []string{"a", "b"})
+	})
+
+	Convey("When a code in the batch is not found, an error is returned for it alone", t, func() {
+		h, _ := newHierarchy(nil)
+		b := &batch{hierarchy: h, codes: []string{"a", "missing"}}
+
+		_, err := b.details(ctx, "missing")
+		So(err, ShouldNotBeNil)
+
+		res, err := b.details(ctx, "a")
+		So(err, ShouldBeNil)
+		So(res.ID, ShouldEqual, "a")
+	})
+
+	Convey("When looking up the batch fails, every node in it returns the error", t, func() {
+		h, store := newHierarchy(errors.New("graph error"))
+		b := &batch{hierarchy: h, codes: []string{"a", "b"}}
+
+		_, errA := b.details(ctx, "a")
+		_, errB := b.details(ctx, "b")
+		So(errA, ShouldEqual, errB)
+		So(errA.Error(), ShouldEqual, "graph error")
+		So(store.GetHierarchyElementsCalls(), ShouldHaveLength, 1)
+	})
+
+	Convey("When the children of every node in a batch are listed, they make up a single batch charged to the request", t, func() {
+		h, store := newHierarchy(nil)
+		h.nodes["a"] = &dbmodels.HierarchyResponse{ID: "a", Children: []*dbmodels.HierarchyElement{{ID: "a1"}, {ID: "a2"}}}
+		h.nodes["b"] = &dbmodels.HierarchyResponse{ID: "b", Children: []*dbmodels.HierarchyElement{{ID: "b1"}}}
+		b := &batch{hierarchy: h, codes: []string{"a", "b"}}
+
+		r := &request{maxNodes: 10, cancel: func() {}}
+		ctx := withRequest(ctx, r)
+		children := func(res *dbmodels.HierarchyResponse) []*dbmodels.HierarchyElement { return res.Children }
+
+		listA, err := b.list(ctx, "a", listKey{field: "children"}, children)
+		So(err, ShouldBeNil)
+		listB, err := b.list(ctx, "b", listKey{field: "children"}, children)
+		So(err, ShouldBeNil)
+
+		So(listA, ShouldHaveLength, 2)
+		So(listB, ShouldHaveLength, 1)
+		So(listA[0].batch, ShouldEqual, listB[0].batch)
+		So(listA[0].batch.codes, ShouldResemble, []string{"a1", "a2", "b1"})
+		So(r.nodes, ShouldEqual, 3)
+
+		_, err = listB[0].batch.details(ctx, "b1")
+		So(err, ShouldBeNil)
+		So(store.GetHierarchyElementsCalls(), ShouldHaveLength, 1)
+		So(store.GetHierarchyElementsCalls()[0].Codes, ShouldResemble, []string{"a1", "a2", "b1"})
+
+		Convey("And listing more than the request's budget fails", func() {
+			r.maxNodes = 4
+			_, err := b.list(ctx, "a", listKey{field: "breadcrumbs"}, children)
+			So(err, ShouldEqual, errTooManyNodes)
+			So(r.overBudget(), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,243 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// maxChildrenLimit is the most children that can be returned with a node. Without a limit, the schema
+// returns the first 100.
+const maxChildrenLimit = 1000
+
+var (
+	errInternal      = errors.New("failed to process the request due to an internal error")
+	errTooManyNodes  = fmt.Errorf("query resolves more than %d nodes", maxQueryNodes)
+	errInvalidOffset = errors.New("offset must not be negative")
+	errInvalidLimit  = fmt.Errorf("limit must be between 1 and %d", maxChildrenLimit)
+)
+
+// queryResolver resolves the fields of the Query type
+type queryResolver struct{}
+
+type hierarchyArgs struct {
+	InstanceID string
+	Dimension  string
+}
+
+// Hierarchy resolves the root of a hierarchy
+func (*queryResolver) Hierarchy(ctx context.Context, args hierarchyArgs) (*nodeResolver, error) {
+	logData := log.Data{"instance_id": args.InstanceID, "dimension": args.Dimension}
+
+	h, err := getRequest(ctx).hierarchy(ctx, args.InstanceID, args.Dimension)
+	if err != nil {
+		log.Error(ctx, "graphql: error getting hierarchy code list", err, logData)
+		return nil, errInternal
+	}
+	if h == nil {
+		return nil, nil
+	}
+
+	if err = getRequest(ctx).charge(1); err != nil {
+		return nil, err
+	}
+
+	res, _, err := h.store.GetHierarchyRoot(ctx, args.InstanceID, args.Dimension, datastore.ChildOptions{})
+	if err != nil {
+		log.Error(ctx, "graphql: error getting hierarchy root", err, logData)
+		return nil, errInternal
+	}
+	h.add(res.ID, res)
+
+	return &nodeResolver{hierarchy: h, element: toElement(res), batch: &batch{hierarchy: h, codes: []string{res.ID}}}, nil
+}
+
+type nodeArgs struct {
+	InstanceID string
+	Dimension  string
+	Code       string
+}
+
+// Node resolves the node for a code in a hierarchy
+func (*queryResolver) Node(ctx context.Context, args nodeArgs) (*nodeResolver, error) {
+	logData := log.Data{"instance_id": args.InstanceID, "dimension": args.Dimension, "code": args.Code}
+
+	h, err := getRequest(ctx).hierarchy(ctx, args.InstanceID, args.Dimension)
+	if err != nil {
+		log.Error(ctx, "graphql: error getting hierarchy code list", err, logData)
+		return nil, errInternal
+	}
+	if h == nil {
+		return nil, nil
+	}
+
+	if err = getRequest(ctx).charge(1); err != nil {
+		return nil, err
+	}
+
+	if err = h.load(ctx, []string{args.Code}); err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "graphql: error getting hierarchy element", err, logData)
+		return nil, errInternal
+	}
+
+	res, ok := h.get(args.Code)
+	if !ok {
+		return nil, nil
+	}
+
+	return &nodeResolver{hierarchy: h, element: toElement(res), batch: &batch{hierarchy: h, codes: []string{args.Code}}}, nil
+}
+
+// nodeResolver resolves the fields of a HierarchyNode. The fields of its element are known when it is
+// created; its children, parent and breadcrumbs are looked up along with the rest of its batch.
+type nodeResolver struct {
+	hierarchy *hierarchy
+	element   *dbmodels.HierarchyElement
+	batch     *batch
+}
+
+// Code resolves the code of the node
+func (n *nodeResolver) Code() string {
+	return n.element.ID
+}
+
+// Label resolves the label of the node
+func (n *nodeResolver) Label() string {
+	return n.element.Label
+}
+
+// Order resolves the position of the node amongst its siblings
+func (n *nodeResolver) Order() *int32 {
+	if n.element.Order == nil {
+		return nil
+	}
+	order := int32(*n.element.Order)
+	return &order
+}
+
+// HasData resolves whether the instance has observations for the node's code
+func (n *nodeResolver) HasData() bool {
+	return n.element.HasData
+}
+
+// NoOfChildren resolves the number of children of the node
+func (n *nodeResolver) NoOfChildren() int32 {
+	return int32(n.element.NoOfChildren)
+}
+
+type childrenArgs struct {
+	HasData *bool
+	Sort    *string
+	Offset  int32
+	Limit   int32
+}
+
+// Children resolves the children of the node selected by the arguments
+func (n *nodeResolver) Children(ctx context.Context, args childrenArgs) ([]*nodeResolver, error) {
+	opts, err := getChildOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	key := listKey{field: "children", hasData: opts.HasData != nil && *opts.HasData, filtered: opts.HasData != nil, sort: opts.Sort, offset: opts.Offset, limit: opts.Limit}
+	return n.list(ctx, key, func(res *dbmodels.HierarchyResponse) []*dbmodels.HierarchyElement {
+		page, _ := opts.Apply(res)
+		return page.Children
+	})
+}
+
+// Parent resolves the parent of the node, or nil for the root
+func (n *nodeResolver) Parent(ctx context.Context) (*nodeResolver, error) {
+	// the parent is resolved as part of the list of all ancestors, so that they are looked up
+	// together if the query walks further up the hierarchy
+	ancestors, err := n.Breadcrumbs(ctx)
+	if err != nil || len(ancestors) == 0 {
+		return nil, err
+	}
+	return ancestors[0], nil
+}
+
+// Breadcrumbs resolves the ancestors of the node, from its parent up to the root
+func (n *nodeResolver) Breadcrumbs(ctx context.Context) ([]*nodeResolver, error) {
+	return n.list(ctx, listKey{field: "breadcrumbs"}, func(res *dbmodels.HierarchyResponse) []*dbmodels.HierarchyElement {
+		return res.Breadcrumbs
+	})
+}
+
+// CodeLink resolves the link to the node's code in the code list of the hierarchy
+func (n *nodeResolver) CodeLink() *linkResolver {
+	return &linkResolver{link: models.GetCodeLink(n.hierarchy.codeListURL, n.hierarchy.codelistID, n.element.ID)}
+}
+
+// list returns resolvers for the nodes selected by list from the node with its children and breadcrumbs.
+// They are selected along with the same nodes of the rest of the node's batch, and looked up together.
+func (n *nodeResolver) list(ctx context.Context, key listKey, list func(*dbmodels.HierarchyResponse) []*dbmodels.HierarchyElement) ([]*nodeResolver, error) {
+	nodes, err := n.batch.list(ctx, n.element.ID, key, list)
+	// lookups still running when the query went over its budget fail as they are cancelled
+	if err != nil && getRequest(ctx).overBudget() {
+		return nil, errTooManyNodes
+	}
+	if err != nil {
+		log.Error(ctx, "graphql: error getting hierarchy element", err, log.Data{
+			"instance_id": n.hierarchy.instanceID,
+			"dimension":   n.hierarchy.dimension,
+			"code":        n.element.ID,
+		})
+		return nil, errInternal
+	}
+
+	return nodes, nil
+}
+
+// linkResolver resolves the fields of a Link
+type linkResolver struct {
+	link *models.Link
+}
+
+// ID resolves the ID of the linked resource
+func (l *linkResolver) ID() string {
+	return l.link.ID
+}
+
+// Href resolves the URL of the linked resource
+func (l *linkResolver) Href() string {
+	return l.link.HRef
+}
+
+// getChildOptions returns the selection of a node's children asked for by the arguments of its children field
+func getChildOptions(args childrenArgs) (datastore.ChildOptions, error) {
+	opts := datastore.ChildOptions{HasData: args.HasData}
+
+	if args.Sort != nil {
+		opts.Sort = datastore.ChildSort(strings.ToLower(*args.Sort))
+	}
+
+	if args.Offset < 0 {
+		return opts, errInvalidOffset
+	}
+	opts.Offset = int(args.Offset)
+
+	if args.Limit < 1 || args.Limit > maxChildrenLimit {
+		return opts, errInvalidLimit
+	}
+	opts.Limit = int(args.Limit)
+
+	return opts, nil
+}
+
+func toElement(res *dbmodels.HierarchyResponse) *dbmodels.HierarchyElement {
+	return &dbmodels.HierarchyElement{
+		ID:           res.ID,
+		Label:        res.Label,
+		NoOfChildren: res.NoOfChildren,
+		HasData:      res.HasData,
+		Order:        res.Order,
+	}
+}
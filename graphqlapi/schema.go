@@ -0,0 +1,47 @@
+package graphqlapi
+
+// schema describes the hierarchies that can be queried. Comments are used as descriptions.
+const schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# The root of the hierarchy for a dimension of an instance, or null if it has none
+	hierarchy(instanceId: String!, dimension: String!): HierarchyNode
+	# The node for a code in the hierarchy for a dimension of an instance, or null if the code is not in it
+	node(instanceId: String!, dimension: String!, code: String!): HierarchyNode
+}
+
+# A node in a hierarchy
+type HierarchyNode {
+	code: String!
+	label: String!
+	# The position of the node amongst its siblings, if the hierarchy is ordered
+	order: Int
+	# Whether the instance has observations for the code
+	hasData: Boolean!
+	noOfChildren: Int!
+	# The children of the node, selected as the has_data, sort, offset and limit query parameters of the REST API do.
+	# A page holds 100 children unless a limit of up to 1000 is given.
+	children(hasData: Boolean, sort: ChildSort, offset: Int = 0, limit: Int = 100): [HierarchyNode!]!
+	# The parent of the node, or null for the root
+	parent: HierarchyNode
+	# The ancestors of the node, from its parent up to the root
+	breadcrumbs: [HierarchyNode!]!
+	# The code in the code list of the hierarchy
+	codeLink: Link!
+}
+
+# The order in which the children of a node are returned. ORDER keeps the order of the hierarchy.
+enum ChildSort {
+	ORDER
+	LABEL
+	CODE
+}
+
+type Link {
+	id: String!
+	href: String!
+}
+`
@@ -0,0 +1,65 @@
+package grpcapi
+
+import (
+	"github.com/ONSdigital/dp-hierarchy-api/hierarchypb"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+)
+
+// Nodes are mapped to the HTTP API's models first, so that their links are built the same way
+
+func toNode(res *models.Response) *hierarchypb.Node {
+	return &hierarchypb.Node{
+		Id:           res.ID,
+		Label:        res.Label,
+		Children:     toElements(res.Children),
+		NoOfChildren: res.NoOfChildren,
+		TotalCount:   int32(res.TotalCount),
+		Order:        res.Order,
+		Links:        toLinks(res.Links),
+		HasData:      res.HasData,
+		Breadcrumbs:  toElements(res.Breadcrumbs),
+	}
+}
+
+func toElements(elements []*models.Element) []*hierarchypb.Element {
+	if len(elements) == 0 {
+		return nil
+	}
+
+	items := make([]*hierarchypb.Element, 0, len(elements))
+	for _, element := range elements {
+		items = append(items, toElement(element))
+	}
+	return items
+}
+
+func toElement(element *models.Element) *hierarchypb.Element {
+	return &hierarchypb.Element{
+		Id:           element.ID,
+		Label:        element.Label,
+		NoOfChildren: element.NoOfChildren,
+		Order:        element.Order,
+		Links:        toLinks(element.Links),
+		HasData:      element.HasData,
+	}
+}
+
+func toTreeNode(node *models.Node) *hierarchypb.TreeNode {
+	treeNode := &hierarchypb.TreeNode{Element: toElement(&node.Element)}
+	for _, child := range node.Children {
+		treeNode.Children = append(treeNode.Children, toTreeNode(child))
+	}
+	return treeNode
+}
+
+func toLinks(links map[string]models.Link) map[string]*hierarchypb.Link {
+	if len(links) == 0 {
+		return nil
+	}
+
+	pbLinks := make(map[string]*hierarchypb.Link, len(links))
+	for name, link := range links {
+		pbLinks[name] = &hierarchypb.Link{Id: link.ID, Href: link.HRef}
+	}
+	return pbLinks
+}
@@ -0,0 +1,297 @@
+// Package grpcapi serves the hierarchy read model over gRPC, alongside the HTTP API
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/hierarchypb"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	"github.com/ONSdigital/log.go/v2/log"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxChildrenLimit is the most children that can be returned with a node
+	maxChildrenLimit = 1000
+)
+
+var (
+	errMissingHierarchy = errors.New("instance_id and dimension must be provided")
+	errMissingCode      = errors.New("code must be provided")
+	errInvalidSort      = errors.New("children sort is not recognised")
+	errInvalidOffset    = errors.New("children offset must not be negative")
+	errInvalidLimit     = fmt.Errorf("children limit must be between 0 and %d", maxChildrenLimit)
+	errInvalidDepth     = errors.New("depth must not be negative")
+
+	errInternal = status.Error(grpcCodes.Internal, "failed to process the request due to an internal error")
+)
+
+var _ hierarchypb.HierarchyServer = &Server{}
+
+// Server implements the Hierarchy gRPC service from the same datastore as the HTTP API. Links in its
+// responses always use the configured URLs, as there are no forwarded headers to rewrite them from.
+type Server struct {
+	hierarchypb.UnimplementedHierarchyServer
+	store          datastore.Storer
+	host           string
+	codeListAPIURL string
+}
+
+// New creates a Server looking up hierarchies in the given store
+func New(store datastore.Storer, hierarchyAPIURL, codeListAPIURL *url.URL) *Server {
+	return &Server{
+		store:          store,
+		host:           hierarchyAPIURL.String(),
+		codeListAPIURL: codeListAPIURL.String(),
+	}
+}
+
+// GetRoot returns the root of the hierarchy of a dimension, with the children selected by the request
+func (s *Server) GetRoot(ctx context.Context, req *hierarchypb.GetRootRequest) (*hierarchypb.Node, error) {
+	instance, dimension := req.GetInstanceId(), req.GetDimension()
+	logData := log.Data{"instance_id": instance, "dimension": dimension}
+
+	opts, err := getChildOptions(req.GetChildren())
+	if err == nil && (instance == "" || dimension == "") {
+		err = errMissingHierarchy
+	}
+	if err != nil {
+		log.Error(ctx, "grpc: invalid get root request", err, logData)
+		return nil, status.Error(grpcCodes.InvalidArgument, err.Error())
+	}
+	logData["children"] = opts
+
+	log.Info(ctx, "grpc: attempting to get hierarchy root", logData)
+
+	codelistID, err := s.getCodelistID(ctx, instance, dimension, logData)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRes, total, err := s.store.GetHierarchyRoot(ctx, instance, dimension, opts)
+	if err != nil {
+		log.Error(ctx, "grpc: error getting hierarchy root", err, logData)
+		return nil, errInternal
+	}
+
+	res := models.MapHierarchyResponse(dbRes)
+	res.TotalCount = total
+	res.AddLinksWithRewriting(s.host, s.codeListAPIURL, instance, dimension, codelistID, true)
+
+	log.Info(ctx, "grpc: get hierarchy root successful", logData)
+	return toNode(&res), nil
+}
+
+// GetNode returns the node for a code with its breadcrumbs and the children selected by the request
+func (s *Server) GetNode(ctx context.Context, req *hierarchypb.GetNodeRequest) (*hierarchypb.Node, error) {
+	instance, dimension, code := req.GetInstanceId(), req.GetDimension(), req.GetCode()
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code}
+
+	opts, err := getChildOptions(req.GetChildren())
+	if err == nil {
+		err = checkCode(instance, dimension, code)
+	}
+	if err != nil {
+		log.Error(ctx, "grpc: invalid get node request", err, logData)
+		return nil, status.Error(grpcCodes.InvalidArgument, err.Error())
+	}
+	logData["children"] = opts
+
+	log.Info(ctx, "grpc: attempting to get hierarchy node for code", logData)
+
+	codelistID, err := s.getCodelistID(ctx, instance, dimension, logData)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRes, total, err := s.store.GetHierarchyElement(ctx, instance, dimension, code, opts)
+	if err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "grpc: error getting hierarchy element", err, logData)
+		return nil, errInternal
+	}
+
+	if err == driver.ErrNotFound || dbRes.Label == "" {
+		log.Error(ctx, "grpc: code not found", driver.ErrNotFound, logData)
+		return nil, codeNotFound(code)
+	}
+
+	res := models.MapHierarchyResponse(dbRes)
+	res.TotalCount = total
+	res.AddLinksWithRewriting(s.host, s.codeListAPIURL, instance, dimension, codelistID, false)
+
+	log.Info(ctx, "grpc: get hierarchy node for code successful", logData)
+	return toNode(&res), nil
+}
+
+// GetDescendants returns the subtree below a code, limited to the requested depth
+func (s *Server) GetDescendants(ctx context.Context, req *hierarchypb.GetDescendantsRequest) (*hierarchypb.TreeNode, error) {
+	instance, dimension, code := req.GetInstanceId(), req.GetDimension(), req.GetCode()
+	depth := int(req.GetDepth())
+	logData := log.Data{"instance_id": instance, "dimension": dimension, "code": code, "depth": depth}
+
+	err := checkCode(instance, dimension, code)
+	if err == nil && depth < 0 {
+		err = errInvalidDepth
+	}
+	if err != nil {
+		log.Error(ctx, "grpc: invalid get descendants request", err, logData)
+		return nil, status.Error(grpcCodes.InvalidArgument, err.Error())
+	}
+
+	log.Info(ctx, "grpc: attempting to get hierarchy descendants for code", logData)
+
+	codelistID, err := s.getCodelistID(ctx, instance, dimension, logData)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRes, err := s.store.GetHierarchyDescendants(ctx, instance, dimension, code, depth)
+	if err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "grpc: error getting hierarchy descendants", err, logData)
+		return nil, errInternal
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "grpc: code not found", err, logData)
+		return nil, codeNotFound(code)
+	}
+
+	res := models.MapHierarchyNode(dbRes)
+	res.AddRewrittenLinks(s.host, s.codeListAPIURL, instance, dimension, codelistID)
+
+	log.Info(ctx, "grpc: get hierarchy descendants for code successful", logData)
+	return toTreeNode(res), nil
+}
+
+// BatchGetNodes returns the nodes for the requested codes. Codes that are not in the hierarchy are
+// given an error code rather than failing the whole batch.
+func (s *Server) BatchGetNodes(ctx context.Context, req *hierarchypb.BatchGetNodesRequest) (*hierarchypb.BatchGetNodesResponse, error) {
+	instance, dimension := req.GetInstanceId(), req.GetDimension()
+	logData := log.Data{"instance_id": instance, "dimension": dimension}
+
+	codes, err := models.BatchCodes(req.GetCodes())
+	if err == nil && (instance == "" || dimension == "") {
+		err = errMissingHierarchy
+	}
+	if err != nil {
+		log.Error(ctx, "grpc: invalid batch get nodes request", err, logData)
+		return nil, status.Error(grpcCodes.InvalidArgument, err.Error())
+	}
+	logData["num_codes"] = len(codes)
+
+	log.Info(ctx, "grpc: attempting to get hierarchy nodes for codes", logData)
+
+	codelistID, err := s.getCodelistID(ctx, instance, dimension, logData)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbRes map[string]*dbmodels.HierarchyResponse
+	if dbRes, err = s.store.GetHierarchyElements(ctx, instance, dimension, codes); err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "grpc: error getting hierarchy elements", err, logData)
+		return nil, errInternal
+	}
+
+	if err == driver.ErrNotFound {
+		log.Error(ctx, "grpc: hierarchy not found", err, logData)
+		return nil, hierarchyNotFound(instance, dimension)
+	}
+
+	res := &hierarchypb.BatchGetNodesResponse{
+		Count: int32(len(codes)),
+		Items: make(map[string]*hierarchypb.CodeResult, len(codes)),
+	}
+
+	for _, code := range codes {
+		dbItem, found := dbRes[code]
+		if !found {
+			res.Items[code] = &hierarchypb.CodeResult{Result: &hierarchypb.CodeResult_ErrorCode{ErrorCode: models.ErrorCodeCodeNotFound}}
+			continue
+		}
+
+		item := models.MapHierarchyResponse(dbItem)
+		item.AddLinksWithRewriting(s.host, s.codeListAPIURL, instance, dimension, codelistID, len(item.Breadcrumbs) == 0)
+		res.Items[code] = &hierarchypb.CodeResult{Result: &hierarchypb.CodeResult_Node{Node: toNode(&item)}}
+	}
+
+	logData["num_found"] = len(dbRes)
+	log.Info(ctx, "grpc: get hierarchy nodes for codes successful", logData)
+	return res, nil
+}
+
+// getCodelistID returns the code list of the hierarchy for the instance and dimension, or the status
+// error to return when it cannot be found
+func (s *Server) getCodelistID(ctx context.Context, instance, dimension string, logData log.Data) (string, error) {
+	codelistID, err := s.store.GetHierarchyCodelist(ctx, instance, dimension)
+	if err != nil && err != driver.ErrNotFound {
+		log.Error(ctx, "grpc: error getting hierarchy code list", err, logData)
+		return "", errInternal
+	}
+
+	if err == driver.ErrNotFound || codelistID == "" {
+		log.Error(ctx, "grpc: hierarchy not found", err, logData)
+		return "", hierarchyNotFound(instance, dimension)
+	}
+
+	return codelistID, nil
+}
+
+func hierarchyNotFound(instance, dimension string) error {
+	return status.Errorf(grpcCodes.NotFound, "no hierarchy found for dimension %q of instance %q", dimension, instance)
+}
+
+func codeNotFound(code string) error {
+	return status.Errorf(grpcCodes.NotFound, "code %q not found in the hierarchy", code)
+}
+
+// checkCode returns an error unless the instance, dimension and code of a request are all provided
+func checkCode(instance, dimension, code string) error {
+	if instance == "" || dimension == "" {
+		return errMissingHierarchy
+	}
+	if code == "" {
+		return errMissingCode
+	}
+	return nil
+}
+
+// getChildOptions converts the selection of children in a request, which selects every child when nil
+func getChildOptions(req *hierarchypb.ChildOptions) (datastore.ChildOptions, error) {
+	var opts datastore.ChildOptions
+	if req == nil {
+		return opts, nil
+	}
+
+	opts.HasData = req.HasData
+
+	switch req.GetSort() {
+	case hierarchypb.ChildSort_CHILD_SORT_UNSPECIFIED:
+	case hierarchypb.ChildSort_CHILD_SORT_ORDER:
+		opts.Sort = datastore.SortByOrder
+	case hierarchypb.ChildSort_CHILD_SORT_LABEL:
+		opts.Sort = datastore.SortByLabel
+	case hierarchypb.ChildSort_CHILD_SORT_CODE:
+		opts.Sort = datastore.SortByCode
+	default:
+		return opts, errInvalidSort
+	}
+
+	if req.GetOffset() < 0 {
+		return opts, errInvalidOffset
+	}
+	opts.Offset = int(req.GetOffset())
+
+	if req.GetLimit() < 0 || req.GetLimit() > maxChildrenLimit {
+		return opts, errInvalidLimit
+	}
+	opts.Limit = int(req.GetLimit())
+
+	return opts, nil
+}
@@ -0,0 +1,254 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	"github.com/ONSdigital/dp-hierarchy-api/hierarchypb"
+	"github.com/ONSdigital/dp-hierarchy-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	hierarchyAPIURL, _ = url.Parse("http://localhost:22600")
+	codeListAPIURL, _  = url.Parse("http://localhost:22400")
+)
+
+func order(o int64) *int64 {
+	return &o
+}
+
+func newMemoryStore() datastore.Storer {
+	store, err := memory.New(&memory.Fixture{Hierarchies: []memory.Hierarchy{
+		{
+			InstanceID: "2022",
+			Dimension:  "geography",
+			CodelistID: "admin-geography",
+			Nodes: []memory.Node{
+				{Code: "K02000001", Label: "United Kingdom", HasData: true},
+				{Code: "E92000001", Label: "England", Parent: "K02000001", Order: order(0), HasData: true},
+				{Code: "W92000004", Label: "Wales", Parent: "K02000001", Order: order(1)},
+				{Code: "E12000007", Label: "London", Parent: "E92000001", HasData: true},
+				{Code: "W06000015", Label: "Cardiff", Parent: "W92000004"},
+			},
+		},
+	}})
+	if err != nil {
+		panic(err)
+	}
+	return store
+}
+
+// newClient serves the store over an in-memory connection and returns a client connected to it
+func newClient(t *testing.T, store datastore.Storer) hierarchypb.HierarchyClient {
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(TracingInterceptor))
+	hierarchypb.RegisterHierarchyServer(srv, New(store, hierarchyAPIURL, codeListAPIURL))
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+	})
+
+	return hierarchypb.NewHierarchyClient(conn)
+}
+
+func TestGetRoot(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	client := newClient(t, newMemoryStore())
+
+	Convey("When getting the root of a hierarchy, it is returned with its children and links", t, func() {
+		res, err := client.GetRoot(ctx, &hierarchypb.GetRootRequest{InstanceId: "2022", Dimension: "geography"})
+		So(err, ShouldBeNil)
+		So(res.GetLabel(), ShouldEqual, "United Kingdom")
+		So(res.GetNoOfChildren(), ShouldEqual, 2)
+		So(res.GetChildren(), ShouldHaveLength, 2)
+		So(res.GetChildren()[0].GetLabel(), ShouldEqual, "England")
+		So(res.GetChildren()[0].GetLinks()["self"].GetHref(), ShouldEqual, "http://localhost:22600/hierarchies/2022/geography/E92000001")
+		So(res.GetLinks()["self"].GetHref(), ShouldEqual, "http://localhost:22600/hierarchies/2022/geography")
+		So(res.GetLinks()["code"].GetHref(), ShouldEqual, "http://localhost:22400/code-lists/admin-geography/codes/K02000001")
+	})
+
+	Convey("When getting the root with a selection of its children, only those children are returned", t, func() {
+		res, err := client.GetRoot(ctx, &hierarchypb.GetRootRequest{
+			InstanceId: "2022",
+			Dimension:  "geography",
+			Children:   &hierarchypb.ChildOptions{HasData: proto.Bool(false), Sort: hierarchypb.ChildSort_CHILD_SORT_LABEL},
+		})
+		So(err, ShouldBeNil)
+		So(res.GetTotalCount(), ShouldEqual, 1)
+		So(res.GetChildren(), ShouldHaveLength, 1)
+		So(res.GetChildren()[0].GetLabel(), ShouldEqual, "Wales")
+	})
+
+	Convey("When the selection of children is invalid, an InvalidArgument error is returned", t, func() {
+		_, err := client.GetRoot(ctx, &hierarchypb.GetRootRequest{
+			InstanceId: "2022",
+			Dimension:  "geography",
+			Children:   &hierarchypb.ChildOptions{Limit: maxChildrenLimit + 1},
+		})
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+	})
+
+	Convey("When the dimension is missing, an InvalidArgument error is returned", t, func() {
+		_, err := client.GetRoot(ctx, &hierarchypb.GetRootRequest{InstanceId: "2022"})
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+	})
+
+	Convey("When the hierarchy does not exist, a NotFound error is returned", t, func() {
+		_, err := client.GetRoot(ctx, &hierarchypb.GetRootRequest{InstanceId: "2022", Dimension: "aggregate"})
+		So(status.Code(err), ShouldEqual, codes.NotFound)
+	})
+
+	Convey("When the datastore fails, an Internal error is returned without its cause", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(context.Context, string, string) (string, error) {
+				return "", errors.New("graph error")
+			},
+		}
+
+		_, err := newClient(t, store).GetRoot(ctx, &hierarchypb.GetRootRequest{InstanceId: "2022", Dimension: "geography"})
+		So(status.Code(err), ShouldEqual, codes.Internal)
+		So(err.Error(), ShouldNotContainSubstring, "graph error")
+	})
+}
+
+func TestGetNode(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	client := newClient(t, newMemoryStore())
+
+	Convey("When getting the node for a code, it is returned with its breadcrumbs", t, func() {
+		res, err := client.GetNode(ctx, &hierarchypb.GetNodeRequest{InstanceId: "2022", Dimension: "geography", Code: "E92000001"})
+		So(err, ShouldBeNil)
+		So(res.GetId(), ShouldEqual, "E92000001")
+		So(res.GetLabel(), ShouldEqual, "England")
+		So(res.GetOrder(), ShouldEqual, 0)
+		So(res.Order, ShouldNotBeNil)
+		So(res.GetHasData(), ShouldBeTrue)
+		So(res.GetLinks()["self"].GetId(), ShouldEqual, "E92000001")
+		So(res.GetBreadcrumbs(), ShouldHaveLength, 1)
+		So(res.GetBreadcrumbs()[0].GetLinks()["self"].GetHref(), ShouldEqual, "http://localhost:22600/hierarchies/2022/geography")
+		So(res.GetChildren(), ShouldHaveLength, 1)
+	})
+
+	Convey("When the code is not in the hierarchy, a NotFound error is returned", t, func() {
+		_, err := client.GetNode(ctx, &hierarchypb.GetNodeRequest{InstanceId: "2022", Dimension: "geography", Code: "X"})
+		So(status.Code(err), ShouldEqual, codes.NotFound)
+		So(status.Convert(err).Message(), ShouldEqual, `code "X" not found in the hierarchy`)
+	})
+
+	Convey("When the code is missing, an InvalidArgument error is returned", t, func() {
+		_, err := client.GetNode(ctx, &hierarchypb.GetNodeRequest{InstanceId: "2022", Dimension: "geography"})
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+	})
+}
+
+func TestGetDescendants(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	client := newClient(t, newMemoryStore())
+
+	Convey("When getting the descendants of a code, the whole subtree is returned", t, func() {
+		res, err := client.GetDescendants(ctx, &hierarchypb.GetDescendantsRequest{InstanceId: "2022", Dimension: "geography", Code: "K02000001"})
+		So(err, ShouldBeNil)
+		So(res.GetElement().GetLabel(), ShouldEqual, "United Kingdom")
+		So(res.GetChildren(), ShouldHaveLength, 2)
+		So(res.GetChildren()[0].GetChildren()[0].GetElement().GetLabel(), ShouldEqual, "London")
+		So(res.GetChildren()[0].GetChildren()[0].GetElement().GetLinks()["code"].GetId(), ShouldEqual, "E12000007")
+	})
+
+	Convey("When getting the descendants to a depth, the subtree is cut off at that depth", t, func() {
+		res, err := client.GetDescendants(ctx, &hierarchypb.GetDescendantsRequest{InstanceId: "2022", Dimension: "geography", Code: "K02000001", Depth: 1})
+		So(err, ShouldBeNil)
+		So(res.GetChildren(), ShouldHaveLength, 2)
+		So(res.GetChildren()[0].GetChildren(), ShouldBeEmpty)
+	})
+
+	Convey("When the depth is negative, an InvalidArgument error is returned", t, func() {
+		_, err := client.GetDescendants(ctx, &hierarchypb.GetDescendantsRequest{InstanceId: "2022", Dimension: "geography", Code: "K02000001", Depth: -1})
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+	})
+
+	Convey("When the code is not in the hierarchy, a NotFound error is returned", t, func() {
+		_, err := client.GetDescendants(ctx, &hierarchypb.GetDescendantsRequest{InstanceId: "2022", Dimension: "geography", Code: "X"})
+		So(status.Code(err), ShouldEqual, codes.NotFound)
+	})
+}
+
+func TestBatchGetNodes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	client := newClient(t, newMemoryStore())
+
+	Convey("When getting a batch of nodes, each code is mapped to its node or an error code", t, func() {
+		res, err := client.BatchGetNodes(ctx, &hierarchypb.BatchGetNodesRequest{
+			InstanceId: "2022",
+			Dimension:  "geography",
+			Codes:      []string{"K02000001", "W06000015", "X", "W06000015"},
+		})
+		So(err, ShouldBeNil)
+		So(res.GetCount(), ShouldEqual, 3)
+		So(res.GetItems()["K02000001"].GetNode().GetLinks()["self"].GetHref(), ShouldEqual, "http://localhost:22600/hierarchies/2022/geography")
+		So(res.GetItems()["W06000015"].GetNode().GetLabel(), ShouldEqual, "Cardiff")
+		So(res.GetItems()["W06000015"].GetNode().GetBreadcrumbs(), ShouldHaveLength, 2)
+		So(res.GetItems()["X"].GetNode(), ShouldBeNil)
+		So(res.GetItems()["X"].GetErrorCode(), ShouldEqual, models.ErrorCodeCodeNotFound)
+	})
+
+	Convey("When a batch has no codes, an InvalidArgument error is returned", t, func() {
+		_, err := client.BatchGetNodes(ctx, &hierarchypb.BatchGetNodesRequest{InstanceId: "2022", Dimension: "geography"})
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+	})
+
+	Convey("When a batch has too many codes, an InvalidArgument error is returned", t, func() {
+		batch := make([]string, models.MaxBatchCodes+1)
+		for i := range batch {
+			batch[i] = fmt.Sprintf("code%d", i)
+		}
+
+		_, err := client.BatchGetNodes(ctx, &hierarchypb.BatchGetNodesRequest{InstanceId: "2022", Dimension: "geography", Codes: batch})
+		So(status.Convert(err).Message(), ShouldEqual, models.ErrTooManyCodes.Error())
+	})
+
+	Convey("When the datastore fails to look up the batch, an Internal error is returned", t, func() {
+		store := &datastoretest.StorerMock{
+			GetHierarchyCodelistFunc: func(context.Context, string, string) (string, error) {
+				return "admin-geography", nil
+			},
+			GetHierarchyElementsFunc: func(context.Context, string, string, []string) (map[string]*dbmodels.HierarchyResponse, error) {
+				return nil, errors.New("graph error")
+			},
+		}
+
+		_, err := newClient(t, store).BatchGetNodes(ctx, &hierarchypb.BatchGetNodesRequest{InstanceId: "2022", Dimension: "geography", Codes: []string{"a"}})
+		So(status.Code(err), ShouldEqual, codes.Internal)
+	})
+}
@@ -0,0 +1,103 @@
+package grpcapi
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	spans     *tracetest.SpanRecorder
+	spansOnce sync.Once
+)
+
+// recordSpans installs a global tracer provider that records spans in memory. The package's tracer
+// is bound to the first provider installed, so it is only installed once for all tests.
+func recordSpans() *tracetest.SpanRecorder {
+	spansOnce.Do(func() {
+		spans = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spans
+}
+
+func TestTracingInterceptor(t *testing.T) {
+	t.Parallel()
+
+	recorder := recordSpans()
+
+	findSpan := func(method string) sdktrace.ReadOnlySpan {
+		for _, span := range recorder.Ended() {
+			if span.Name() == method {
+				return span
+			}
+		}
+		return nil
+	}
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range span.Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+		return attrs
+	}
+
+	Convey("When a call is intercepted, a server span continuing the caller's trace is recorded", t, func() {
+		traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", traceParent))
+		info := &grpc.UnaryServerInfo{FullMethod: "/dp.hierarchy.v1.Hierarchy/GetNode"}
+
+		res, err := TracingInterceptor(ctx, "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "response", nil
+		})
+		So(err, ShouldBeNil)
+		So(res, ShouldEqual, "response")
+
+		span := findSpan(info.FullMethod)
+		So(span, ShouldNotBeNil)
+		So(span.Parent().TraceID().String(), ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+		So(attributes(span)["rpc.method"].AsString(), ShouldEqual, info.FullMethod)
+		So(attributes(span)["rpc.grpc.status_code"].AsInt64(), ShouldEqual, int64(grpcCodes.OK))
+		So(span.Status().Code, ShouldEqual, otelcodes.Unset)
+	})
+
+	Convey("When a call fails with an internal error, the span is marked as failed", t, func() {
+		info := &grpc.UnaryServerInfo{FullMethod: "/dp.hierarchy.v1.Hierarchy/GetRoot"}
+
+		_, err := TracingInterceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, errInternal
+		})
+		So(status.Code(err), ShouldEqual, grpcCodes.Internal)
+
+		span := findSpan(info.FullMethod)
+		So(span, ShouldNotBeNil)
+		So(span.Status().Code, ShouldEqual, otelcodes.Error)
+	})
+
+	Convey("When a call fails because the hierarchy is not found, the span is not marked as failed", t, func() {
+		info := &grpc.UnaryServerInfo{FullMethod: "/dp.hierarchy.v1.Hierarchy/GetDescendants"}
+
+		_, err := TracingInterceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, hierarchyNotFound("2022", "geography")
+		})
+		So(status.Code(err), ShouldEqual, grpcCodes.NotFound)
+
+		span := findSpan(info.FullMethod)
+		So(span, ShouldNotBeNil)
+		So(span.Status().Code, ShouldEqual, otelcodes.Unset)
+	})
+}
@@ -0,0 +1,4 @@
+// Package hierarchypb contains the protobuf messages and gRPC service generated from hierarchy.proto
+package hierarchypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative hierarchy.proto
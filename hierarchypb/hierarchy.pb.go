@@ -0,0 +1,1025 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: hierarchy.proto
+
+package hierarchypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ChildSort is the order in which the children of a node are returned
+type ChildSort int32
+
+const (
+	// CHILD_SORT_UNSPECIFIED keeps the order of the hierarchy, as CHILD_SORT_ORDER does
+	ChildSort_CHILD_SORT_UNSPECIFIED ChildSort = 0
+	ChildSort_CHILD_SORT_ORDER       ChildSort = 1
+	ChildSort_CHILD_SORT_LABEL       ChildSort = 2
+	ChildSort_CHILD_SORT_CODE        ChildSort = 3
+)
+
+// Enum value maps for ChildSort.
+var (
+	ChildSort_name = map[int32]string{
+		0: "CHILD_SORT_UNSPECIFIED",
+		1: "CHILD_SORT_ORDER",
+		2: "CHILD_SORT_LABEL",
+		3: "CHILD_SORT_CODE",
+	}
+	ChildSort_value = map[string]int32{
+		"CHILD_SORT_UNSPECIFIED": 0,
+		"CHILD_SORT_ORDER":       1,
+		"CHILD_SORT_LABEL":       2,
+		"CHILD_SORT_CODE":        3,
+	}
+)
+
+func (x ChildSort) Enum() *ChildSort {
+	p := new(ChildSort)
+	*p = x
+	return p
+}
+
+func (x ChildSort) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChildSort) Descriptor() protoreflect.EnumDescriptor {
+	return file_hierarchy_proto_enumTypes[0].Descriptor()
+}
+
+func (ChildSort) Type() protoreflect.EnumType {
+	return &file_hierarchy_proto_enumTypes[0]
+}
+
+func (x ChildSort) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChildSort.Descriptor instead.
+func (ChildSort) EnumDescriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{0}
+}
+
+// ChildOptions selects which children of a node are returned with it, as the has_data, sort, offset
+// and limit query parameters of the HTTP API do. Every child is returned when it is omitted.
+type ChildOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// has_data, when set, only selects children whose has_data flag matches
+	HasData *bool     `protobuf:"varint,1,opt,name=has_data,json=hasData,proto3,oneof" json:"has_data,omitempty"`
+	Sort    ChildSort `protobuf:"varint,2,opt,name=sort,proto3,enum=dp.hierarchy.v1.ChildSort" json:"sort,omitempty"`
+	Offset  int32     `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	// limit is the maximum number of children to return, up to 1000, or 0 for no limit
+	Limit         int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChildOptions) Reset() {
+	*x = ChildOptions{}
+	mi := &file_hierarchy_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChildOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChildOptions) ProtoMessage() {}
+
+func (x *ChildOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChildOptions.ProtoReflect.Descriptor instead.
+func (*ChildOptions) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChildOptions) GetHasData() bool {
+	if x != nil && x.HasData != nil {
+		return *x.HasData
+	}
+	return false
+}
+
+func (x *ChildOptions) GetSort() ChildSort {
+	if x != nil {
+		return x.Sort
+	}
+	return ChildSort_CHILD_SORT_UNSPECIFIED
+}
+
+func (x *ChildOptions) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ChildOptions) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetRootRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Dimension     string                 `protobuf:"bytes,2,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	Children      *ChildOptions          `protobuf:"bytes,3,opt,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRootRequest) Reset() {
+	*x = GetRootRequest{}
+	mi := &file_hierarchy_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRootRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRootRequest) ProtoMessage() {}
+
+func (x *GetRootRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRootRequest.ProtoReflect.Descriptor instead.
+func (*GetRootRequest) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetRootRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *GetRootRequest) GetDimension() string {
+	if x != nil {
+		return x.Dimension
+	}
+	return ""
+}
+
+func (x *GetRootRequest) GetChildren() *ChildOptions {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type GetNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Dimension     string                 `protobuf:"bytes,2,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	Code          string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	Children      *ChildOptions          `protobuf:"bytes,4,opt,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNodeRequest) Reset() {
+	*x = GetNodeRequest{}
+	mi := &file_hierarchy_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNodeRequest) ProtoMessage() {}
+
+func (x *GetNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNodeRequest.ProtoReflect.Descriptor instead.
+func (*GetNodeRequest) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetNodeRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *GetNodeRequest) GetDimension() string {
+	if x != nil {
+		return x.Dimension
+	}
+	return ""
+}
+
+func (x *GetNodeRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *GetNodeRequest) GetChildren() *ChildOptions {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type GetDescendantsRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Dimension  string                 `protobuf:"bytes,2,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	Code       string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// depth limits the number of levels returned below the code, or 0 for the whole subtree
+	Depth         int32 `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDescendantsRequest) Reset() {
+	*x = GetDescendantsRequest{}
+	mi := &file_hierarchy_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDescendantsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDescendantsRequest) ProtoMessage() {}
+
+func (x *GetDescendantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDescendantsRequest.ProtoReflect.Descriptor instead.
+func (*GetDescendantsRequest) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetDescendantsRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *GetDescendantsRequest) GetDimension() string {
+	if x != nil {
+		return x.Dimension
+	}
+	return ""
+}
+
+func (x *GetDescendantsRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *GetDescendantsRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type BatchGetNodesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Dimension     string                 `protobuf:"bytes,2,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	Codes         []string               `protobuf:"bytes,3,rep,name=codes,proto3" json:"codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetNodesRequest) Reset() {
+	*x = BatchGetNodesRequest{}
+	mi := &file_hierarchy_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetNodesRequest) ProtoMessage() {}
+
+func (x *BatchGetNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetNodesRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetNodesRequest) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetNodesRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *BatchGetNodesRequest) GetDimension() string {
+	if x != nil {
+		return x.Dimension
+	}
+	return ""
+}
+
+func (x *BatchGetNodesRequest) GetCodes() []string {
+	if x != nil {
+		return x.Codes
+	}
+	return nil
+}
+
+type BatchGetNodesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int32                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Items         map[string]*CodeResult `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetNodesResponse) Reset() {
+	*x = BatchGetNodesResponse{}
+	mi := &file_hierarchy_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetNodesResponse) ProtoMessage() {}
+
+func (x *BatchGetNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetNodesResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetNodesResponse) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchGetNodesResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *BatchGetNodesResponse) GetItems() map[string]*CodeResult {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// CodeResult is the node for a code in a batch, or the error code explaining why it was not returned
+type CodeResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*CodeResult_Node
+	//	*CodeResult_ErrorCode
+	Result        isCodeResult_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CodeResult) Reset() {
+	*x = CodeResult{}
+	mi := &file_hierarchy_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CodeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CodeResult) ProtoMessage() {}
+
+func (x *CodeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CodeResult.ProtoReflect.Descriptor instead.
+func (*CodeResult) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CodeResult) GetResult() isCodeResult_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *CodeResult) GetNode() *Node {
+	if x != nil {
+		if x, ok := x.Result.(*CodeResult_Node); ok {
+			return x.Node
+		}
+	}
+	return nil
+}
+
+func (x *CodeResult) GetErrorCode() string {
+	if x != nil {
+		if x, ok := x.Result.(*CodeResult_ErrorCode); ok {
+			return x.ErrorCode
+		}
+	}
+	return ""
+}
+
+type isCodeResult_Result interface {
+	isCodeResult_Result()
+}
+
+type CodeResult_Node struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node,proto3,oneof"`
+}
+
+type CodeResult_ErrorCode struct {
+	ErrorCode string `protobuf:"bytes,2,opt,name=error_code,json=errorCode,proto3,oneof"`
+}
+
+func (*CodeResult_Node) isCodeResult_Result() {}
+
+func (*CodeResult_ErrorCode) isCodeResult_Result() {}
+
+// Node is a node in a hierarchy, with its children and breadcrumbs
+type Node struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label        string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Children     []*Element             `protobuf:"bytes,3,rep,name=children,proto3" json:"children,omitempty"`
+	NoOfChildren int64                  `protobuf:"varint,4,opt,name=no_of_children,json=noOfChildren,proto3" json:"no_of_children,omitempty"`
+	// total_count is the number of children matching has_data, of which those selected by offset and limit are returned
+	TotalCount int32            `protobuf:"varint,5,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	Order      *int64           `protobuf:"varint,6,opt,name=order,proto3,oneof" json:"order,omitempty"`
+	Links      map[string]*Link `protobuf:"bytes,7,rep,name=links,proto3" json:"links,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	HasData    bool             `protobuf:"varint,8,opt,name=has_data,json=hasData,proto3" json:"has_data,omitempty"`
+	// breadcrumbs are the ancestors of the node, from its parent up to the root
+	Breadcrumbs   []*Element `protobuf:"bytes,9,rep,name=breadcrumbs,proto3" json:"breadcrumbs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_hierarchy_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Node) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Node) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Node) GetChildren() []*Element {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+func (x *Node) GetNoOfChildren() int64 {
+	if x != nil {
+		return x.NoOfChildren
+	}
+	return 0
+}
+
+func (x *Node) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *Node) GetOrder() int64 {
+	if x != nil && x.Order != nil {
+		return *x.Order
+	}
+	return 0
+}
+
+func (x *Node) GetLinks() map[string]*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Node) GetHasData() bool {
+	if x != nil {
+		return x.HasData
+	}
+	return false
+}
+
+func (x *Node) GetBreadcrumbs() []*Element {
+	if x != nil {
+		return x.Breadcrumbs
+	}
+	return nil
+}
+
+// Element is a node in a list within a Node
+type Element struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	NoOfChildren  int64                  `protobuf:"varint,3,opt,name=no_of_children,json=noOfChildren,proto3" json:"no_of_children,omitempty"`
+	Order         *int64                 `protobuf:"varint,4,opt,name=order,proto3,oneof" json:"order,omitempty"`
+	Links         map[string]*Link       `protobuf:"bytes,5,rep,name=links,proto3" json:"links,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	HasData       bool                   `protobuf:"varint,6,opt,name=has_data,json=hasData,proto3" json:"has_data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Element) Reset() {
+	*x = Element{}
+	mi := &file_hierarchy_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Element) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Element) ProtoMessage() {}
+
+func (x *Element) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Element.ProtoReflect.Descriptor instead.
+func (*Element) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Element) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Element) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Element) GetNoOfChildren() int64 {
+	if x != nil {
+		return x.NoOfChildren
+	}
+	return 0
+}
+
+func (x *Element) GetOrder() int64 {
+	if x != nil && x.Order != nil {
+		return *x.Order
+	}
+	return 0
+}
+
+func (x *Element) GetLinks() map[string]*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Element) GetHasData() bool {
+	if x != nil {
+		return x.HasData
+	}
+	return false
+}
+
+// TreeNode is an Element with its nested children
+type TreeNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Element       *Element               `protobuf:"bytes,1,opt,name=element,proto3" json:"element,omitempty"`
+	Children      []*TreeNode            `protobuf:"bytes,2,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeNode) Reset() {
+	*x = TreeNode{}
+	mi := &file_hierarchy_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeNode) ProtoMessage() {}
+
+func (x *TreeNode) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeNode.ProtoReflect.Descriptor instead.
+func (*TreeNode) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TreeNode) GetElement() *Element {
+	if x != nil {
+		return x.Element
+	}
+	return nil
+}
+
+func (x *TreeNode) GetChildren() []*TreeNode {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type Link struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Href          string                 `protobuf:"bytes,2,opt,name=href,proto3" json:"href,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_hierarchy_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_hierarchy_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_hierarchy_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Link) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Link) GetHref() string {
+	if x != nil {
+		return x.Href
+	}
+	return ""
+}
+
+var File_hierarchy_proto protoreflect.FileDescriptor
+
+const file_hierarchy_proto_rawDesc = "" +
+	"\n" +
+	"\x0fhierarchy.proto\x12\x0fdp.hierarchy.v1\"\x99\x01\n" +
+	"\fChildOptions\x12\x1e\n" +
+	"\bhas_data\x18\x01 \x01(\bH\x00R\ahasData\x88\x01\x01\x12.\n" +
+	"\x04sort\x18\x02 \x01(\x0e2\x1a.dp.hierarchy.v1.ChildSortR\x04sort\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limitB\v\n" +
+	"\t_has_data\"\x8a\x01\n" +
+	"\x0eGetRootRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1c\n" +
+	"\tdimension\x18\x02 \x01(\tR\tdimension\x129\n" +
+	"\bchildren\x18\x03 \x01(\v2\x1d.dp.hierarchy.v1.ChildOptionsR\bchildren\"\x9e\x01\n" +
+	"\x0eGetNodeRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1c\n" +
+	"\tdimension\x18\x02 \x01(\tR\tdimension\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x129\n" +
+	"\bchildren\x18\x04 \x01(\v2\x1d.dp.hierarchy.v1.ChildOptionsR\bchildren\"\x80\x01\n" +
+	"\x15GetDescendantsRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1c\n" +
+	"\tdimension\x18\x02 \x01(\tR\tdimension\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x14\n" +
+	"\x05depth\x18\x04 \x01(\x05R\x05depth\"k\n" +
+	"\x14BatchGetNodesRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1c\n" +
+	"\tdimension\x18\x02 \x01(\tR\tdimension\x12\x14\n" +
+	"\x05codes\x18\x03 \x03(\tR\x05codes\"\xcd\x01\n" +
+	"\x15BatchGetNodesResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x05R\x05count\x12G\n" +
+	"\x05items\x18\x02 \x03(\v21.dp.hierarchy.v1.BatchGetNodesResponse.ItemsEntryR\x05items\x1aU\n" +
+	"\n" +
+	"ItemsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\x05value\x18\x02 \x01(\v2\x1b.dp.hierarchy.v1.CodeResultR\x05value:\x028\x01\"d\n" +
+	"\n" +
+	"CodeResult\x12+\n" +
+	"\x04node\x18\x01 \x01(\v2\x15.dp.hierarchy.v1.NodeH\x00R\x04node\x12\x1f\n" +
+	"\n" +
+	"error_code\x18\x02 \x01(\tH\x00R\terrorCodeB\b\n" +
+	"\x06result\"\xae\x03\n" +
+	"\x04Node\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x124\n" +
+	"\bchildren\x18\x03 \x03(\v2\x18.dp.hierarchy.v1.ElementR\bchildren\x12$\n" +
+	"\x0eno_of_children\x18\x04 \x01(\x03R\fnoOfChildren\x12\x1f\n" +
+	"\vtotal_count\x18\x05 \x01(\x05R\n" +
+	"totalCount\x12\x19\n" +
+	"\x05order\x18\x06 \x01(\x03H\x00R\x05order\x88\x01\x01\x126\n" +
+	"\x05links\x18\a \x03(\v2 .dp.hierarchy.v1.Node.LinksEntryR\x05links\x12\x19\n" +
+	"\bhas_data\x18\b \x01(\bR\ahasData\x12:\n" +
+	"\vbreadcrumbs\x18\t \x03(\v2\x18.dp.hierarchy.v1.ElementR\vbreadcrumbs\x1aO\n" +
+	"\n" +
+	"LinksEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x05value\x18\x02 \x01(\v2\x15.dp.hierarchy.v1.LinkR\x05value:\x028\x01B\b\n" +
+	"\x06_order\"\xa1\x02\n" +
+	"\aElement\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12$\n" +
+	"\x0eno_of_children\x18\x03 \x01(\x03R\fnoOfChildren\x12\x19\n" +
+	"\x05order\x18\x04 \x01(\x03H\x00R\x05order\x88\x01\x01\x129\n" +
+	"\x05links\x18\x05 \x03(\v2#.dp.hierarchy.v1.Element.LinksEntryR\x05links\x12\x19\n" +
+	"\bhas_data\x18\x06 \x01(\bR\ahasData\x1aO\n" +
+	"\n" +
+	"LinksEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x05value\x18\x02 \x01(\v2\x15.dp.hierarchy.v1.LinkR\x05value:\x028\x01B\b\n" +
+	"\x06_order\"u\n" +
+	"\bTreeNode\x122\n" +
+	"\aelement\x18\x01 \x01(\v2\x18.dp.hierarchy.v1.ElementR\aelement\x125\n" +
+	"\bchildren\x18\x02 \x03(\v2\x19.dp.hierarchy.v1.TreeNodeR\bchildren\"*\n" +
+	"\x04Link\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04href\x18\x02 \x01(\tR\x04href*h\n" +
+	"\tChildSort\x12\x1a\n" +
+	"\x16CHILD_SORT_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10CHILD_SORT_ORDER\x10\x01\x12\x14\n" +
+	"\x10CHILD_SORT_LABEL\x10\x02\x12\x13\n" +
+	"\x0fCHILD_SORT_CODE\x10\x032\xc6\x02\n" +
+	"\tHierarchy\x12A\n" +
+	"\aGetRoot\x12\x1f.dp.hierarchy.v1.GetRootRequest\x1a\x15.dp.hierarchy.v1.Node\x12A\n" +
+	"\aGetNode\x12\x1f.dp.hierarchy.v1.GetNodeRequest\x1a\x15.dp.hierarchy.v1.Node\x12S\n" +
+	"\x0eGetDescendants\x12&.dp.hierarchy.v1.GetDescendantsRequest\x1a\x19.dp.hierarchy.v1.TreeNode\x12^\n" +
+	"\rBatchGetNodes\x12%.dp.hierarchy.v1.BatchGetNodesRequest\x1a&.dp.hierarchy.v1.BatchGetNodesResponseB4Z2github.com/ONSdigital/dp-hierarchy-api/hierarchypbb\x06proto3"
+
+var (
+	file_hierarchy_proto_rawDescOnce sync.Once
+	file_hierarchy_proto_rawDescData []byte
+)
+
+func file_hierarchy_proto_rawDescGZIP() []byte {
+	file_hierarchy_proto_rawDescOnce.Do(func() {
+		file_hierarchy_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_hierarchy_proto_rawDesc), len(file_hierarchy_proto_rawDesc)))
+	})
+	return file_hierarchy_proto_rawDescData
+}
+
+var file_hierarchy_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_hierarchy_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_hierarchy_proto_goTypes = []any{
+	(ChildSort)(0),                // 0: dp.hierarchy.v1.ChildSort
+	(*ChildOptions)(nil),          // 1: dp.hierarchy.v1.ChildOptions
+	(*GetRootRequest)(nil),        // 2: dp.hierarchy.v1.GetRootRequest
+	(*GetNodeRequest)(nil),        // 3: dp.hierarchy.v1.GetNodeRequest
+	(*GetDescendantsRequest)(nil), // 4: dp.hierarchy.v1.GetDescendantsRequest
+	(*BatchGetNodesRequest)(nil),  // 5: dp.hierarchy.v1.BatchGetNodesRequest
+	(*BatchGetNodesResponse)(nil), // 6: dp.hierarchy.v1.BatchGetNodesResponse
+	(*CodeResult)(nil),            // 7: dp.hierarchy.v1.CodeResult
+	(*Node)(nil),                  // 8: dp.hierarchy.v1.Node
+	(*Element)(nil),               // 9: dp.hierarchy.v1.Element
+	(*TreeNode)(nil),              // 10: dp.hierarchy.v1.TreeNode
+	(*Link)(nil),                  // 11: dp.hierarchy.v1.Link
+	nil,                           // 12: dp.hierarchy.v1.BatchGetNodesResponse.ItemsEntry
+	nil,                           // 13: dp.hierarchy.v1.Node.LinksEntry
+	nil,                           // 14: dp.hierarchy.v1.Element.LinksEntry
+}
+var file_hierarchy_proto_depIdxs = []int32{
+	0,  // 0: dp.hierarchy.v1.ChildOptions.sort:type_name -> dp.hierarchy.v1.ChildSort
+	1,  // 1: dp.hierarchy.v1.GetRootRequest.children:type_name -> dp.hierarchy.v1.ChildOptions
+	1,  // 2: dp.hierarchy.v1.GetNodeRequest.children:type_name -> dp.hierarchy.v1.ChildOptions
+	12, // 3: dp.hierarchy.v1.BatchGetNodesResponse.items:type_name -> dp.hierarchy.v1.BatchGetNodesResponse.ItemsEntry
+	8,  // 4: dp.hierarchy.v1.CodeResult.node:type_name -> dp.hierarchy.v1.Node
+	9,  // 5: dp.hierarchy.v1.Node.children:type_name -> dp.hierarchy.v1.Element
+	13, // 6: dp.hierarchy.v1.Node.links:type_name -> dp.hierarchy.v1.Node.LinksEntry
+	9,  // 7: dp.hierarchy.v1.Node.breadcrumbs:type_name -> dp.hierarchy.v1.Element
+	14, // 8: dp.hierarchy.v1.Element.links:type_name -> dp.hierarchy.v1.Element.LinksEntry
+	9,  // 9: dp.hierarchy.v1.TreeNode.element:type_name -> dp.hierarchy.v1.Element
+	10, // 10: dp.hierarchy.v1.TreeNode.children:type_name -> dp.hierarchy.v1.TreeNode
+	7,  // 11: dp.hierarchy.v1.BatchGetNodesResponse.ItemsEntry.value:type_name -> dp.hierarchy.v1.CodeResult
+	11, // 12: dp.hierarchy.v1.Node.LinksEntry.value:type_name -> dp.hierarchy.v1.Link
+	11, // 13: dp.hierarchy.v1.Element.LinksEntry.value:type_name -> dp.hierarchy.v1.Link
+	2,  // 14: dp.hierarchy.v1.Hierarchy.GetRoot:input_type -> dp.hierarchy.v1.GetRootRequest
+	3,  // 15: dp.hierarchy.v1.Hierarchy.GetNode:input_type -> dp.hierarchy.v1.GetNodeRequest
+	4,  // 16: dp.hierarchy.v1.Hierarchy.GetDescendants:input_type -> dp.hierarchy.v1.GetDescendantsRequest
+	5,  // 17: dp.hierarchy.v1.Hierarchy.BatchGetNodes:input_type -> dp.hierarchy.v1.BatchGetNodesRequest
+	8,  // 18: dp.hierarchy.v1.Hierarchy.GetRoot:output_type -> dp.hierarchy.v1.Node
+	8,  // 19: dp.hierarchy.v1.Hierarchy.GetNode:output_type -> dp.hierarchy.v1.Node
+	10, // 20: dp.hierarchy.v1.Hierarchy.GetDescendants:output_type -> dp.hierarchy.v1.TreeNode
+	6,  // 21: dp.hierarchy.v1.Hierarchy.BatchGetNodes:output_type -> dp.hierarchy.v1.BatchGetNodesResponse
+	18, // [18:22] is the sub-list for method output_type
+	14, // [14:18] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_hierarchy_proto_init() }
+func file_hierarchy_proto_init() {
+	if File_hierarchy_proto != nil {
+		return
+	}
+	file_hierarchy_proto_msgTypes[0].OneofWrappers = []any{}
+	file_hierarchy_proto_msgTypes[6].OneofWrappers = []any{
+		(*CodeResult_Node)(nil),
+		(*CodeResult_ErrorCode)(nil),
+	}
+	file_hierarchy_proto_msgTypes[7].OneofWrappers = []any{}
+	file_hierarchy_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hierarchy_proto_rawDesc), len(file_hierarchy_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_hierarchy_proto_goTypes,
+		DependencyIndexes: file_hierarchy_proto_depIdxs,
+		EnumInfos:         file_hierarchy_proto_enumTypes,
+		MessageInfos:      file_hierarchy_proto_msgTypes,
+	}.Build()
+	File_hierarchy_proto = out.File
+	file_hierarchy_proto_goTypes = nil
+	file_hierarchy_proto_depIdxs = nil
+}
@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: hierarchy.proto
+
+package hierarchypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Hierarchy_GetRoot_FullMethodName        = "/dp.hierarchy.v1.Hierarchy/GetRoot"
+	Hierarchy_GetNode_FullMethodName        = "/dp.hierarchy.v1.Hierarchy/GetNode"
+	Hierarchy_GetDescendants_FullMethodName = "/dp.hierarchy.v1.Hierarchy/GetDescendants"
+	Hierarchy_BatchGetNodes_FullMethodName  = "/dp.hierarchy.v1.Hierarchy/BatchGetNodes"
+)
+
+// HierarchyClient is the client API for Hierarchy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Hierarchy serves the same hierarchy nodes as the HTTP API, for services that look them up in bulk
+type HierarchyClient interface {
+	// GetRoot returns the root of the hierarchy of a dimension
+	GetRoot(ctx context.Context, in *GetRootRequest, opts ...grpc.CallOption) (*Node, error)
+	// GetNode returns the node for a code, with its children and breadcrumbs
+	GetNode(ctx context.Context, in *GetNodeRequest, opts ...grpc.CallOption) (*Node, error)
+	// GetDescendants returns the subtree below a code
+	GetDescendants(ctx context.Context, in *GetDescendantsRequest, opts ...grpc.CallOption) (*TreeNode, error)
+	// BatchGetNodes returns the nodes for up to 500 codes at once
+	BatchGetNodes(ctx context.Context, in *BatchGetNodesRequest, opts ...grpc.CallOption) (*BatchGetNodesResponse, error)
+}
+
+type hierarchyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHierarchyClient(cc grpc.ClientConnInterface) HierarchyClient {
+	return &hierarchyClient{cc}
+}
+
+func (c *hierarchyClient) GetRoot(ctx context.Context, in *GetRootRequest, opts ...grpc.CallOption) (*Node, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Node)
+	err := c.cc.Invoke(ctx, Hierarchy_GetRoot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hierarchyClient) GetNode(ctx context.Context, in *GetNodeRequest, opts ...grpc.CallOption) (*Node, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Node)
+	err := c.cc.Invoke(ctx, Hierarchy_GetNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hierarchyClient) GetDescendants(ctx context.Context, in *GetDescendantsRequest, opts ...grpc.CallOption) (*TreeNode, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TreeNode)
+	err := c.cc.Invoke(ctx, Hierarchy_GetDescendants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hierarchyClient) BatchGetNodes(ctx context.Context, in *BatchGetNodesRequest, opts ...grpc.CallOption) (*BatchGetNodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetNodesResponse)
+	err := c.cc.Invoke(ctx, Hierarchy_BatchGetNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HierarchyServer is the server API for Hierarchy service.
+// All implementations must embed UnimplementedHierarchyServer
+// for forward compatibility.
+//
+// Hierarchy serves the same hierarchy nodes as the HTTP API, for services that look them up in bulk
+type HierarchyServer interface {
+	// GetRoot returns the root of the hierarchy of a dimension
+	GetRoot(context.Context, *GetRootRequest) (*Node, error)
+	// GetNode returns the node for a code, with its children and breadcrumbs
+	GetNode(context.Context, *GetNodeRequest) (*Node, error)
+	// GetDescendants returns the subtree below a code
+	GetDescendants(context.Context, *GetDescendantsRequest) (*TreeNode, error)
+	// BatchGetNodes returns the nodes for up to 500 codes at once
+	BatchGetNodes(context.Context, *BatchGetNodesRequest) (*BatchGetNodesResponse, error)
+	mustEmbedUnimplementedHierarchyServer()
+}
+
+// UnimplementedHierarchyServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHierarchyServer struct{}
+
+func (UnimplementedHierarchyServer) GetRoot(context.Context, *GetRootRequest) (*Node, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoot not implemented")
+}
+func (UnimplementedHierarchyServer) GetNode(context.Context, *GetNodeRequest) (*Node, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNode not implemented")
+}
+func (UnimplementedHierarchyServer) GetDescendants(context.Context, *GetDescendantsRequest) (*TreeNode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDescendants not implemented")
+}
+func (UnimplementedHierarchyServer) BatchGetNodes(context.Context, *BatchGetNodesRequest) (*BatchGetNodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetNodes not implemented")
+}
+func (UnimplementedHierarchyServer) mustEmbedUnimplementedHierarchyServer() {}
+func (UnimplementedHierarchyServer) testEmbeddedByValue()                   {}
+
+// UnsafeHierarchyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HierarchyServer will
+// result in compilation errors.
+type UnsafeHierarchyServer interface {
+	mustEmbedUnimplementedHierarchyServer()
+}
+
+func RegisterHierarchyServer(s grpc.ServiceRegistrar, srv HierarchyServer) {
+	// If the following call pancis, it indicates UnimplementedHierarchyServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Hierarchy_ServiceDesc, srv)
+}
+
+func _Hierarchy_GetRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HierarchyServer).GetRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Hierarchy_GetRoot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HierarchyServer).GetRoot(ctx, req.(*GetRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hierarchy_GetNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HierarchyServer).GetNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Hierarchy_GetNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HierarchyServer).GetNode(ctx, req.(*GetNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hierarchy_GetDescendants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDescendantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HierarchyServer).GetDescendants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Hierarchy_GetDescendants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HierarchyServer).GetDescendants(ctx, req.(*GetDescendantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hierarchy_BatchGetNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HierarchyServer).BatchGetNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Hierarchy_BatchGetNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HierarchyServer).BatchGetNodes(ctx, req.(*BatchGetNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Hierarchy_ServiceDesc is the grpc.ServiceDesc for Hierarchy service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Hierarchy_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dp.hierarchy.v1.Hierarchy",
+	HandlerType: (*HierarchyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRoot",
+			Handler:    _Hierarchy_GetRoot_Handler,
+		},
+		{
+			MethodName: "GetNode",
+			Handler:    _Hierarchy_GetNode_Handler,
+		},
+		{
+			MethodName: "GetDescendants",
+			Handler:    _Hierarchy_GetDescendants_Handler,
+		},
+		{
+			MethodName: "BatchGetNodes",
+			Handler:    _Hierarchy_BatchGetNodes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "hierarchy.proto",
+}
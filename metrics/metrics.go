@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/cache"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "hierarchy_api"
+
+// Metrics records the service's Prometheus metrics in a registry of its own
+type Metrics struct {
+	registry          *prometheus.Registry
+	requestDuration   *prometheus.HistogramVec
+	datastoreDuration *prometheus.HistogramVec
+	datastoreErrors   *prometheus.CounterVec
+}
+
+// New creates the service's metrics, along with the standard Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle HTTP requests, by route name, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "code"}),
+		datastoreDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "datastore_request_duration_seconds",
+			Help:      "Time taken by calls to the datastore, by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		datastoreErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "datastore_errors_total",
+			Help:      "Calls to the datastore that failed, by method. Lookups of hierarchies or codes that do not exist are not counted.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.datastoreDuration,
+		m.datastoreErrors,
+	)
+
+	return m
+}
+
+// Handler returns a handler serving the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the duration and status code of each request handled by the router, labelled
+// with the name of the route that matched it, or its path template if the route has no name
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, req)
+
+		m.requestDuration.
+			WithLabelValues(routeName(req), req.Method, strconv.Itoa(rec.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveDatastore records a call to the datastore method that took the given duration and returned err
+func (m *Metrics) ObserveDatastore(method string, duration time.Duration, err error) {
+	m.datastoreDuration.WithLabelValues(method).Observe(duration.Seconds())
+	if err != nil && !errors.Is(err, driver.ErrNotFound) {
+		m.datastoreErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// RegisterCache adds metrics reporting the effectiveness of a datastore cache
+func (m *Metrics) RegisterCache(store *cache.Store) {
+	stat := func(value func(stats cache.Stats) float64) func() float64 {
+		return func() float64 { return value(store.Stats()) }
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Datastore lookups served from the cache.",
+		}, stat(func(s cache.Stats) float64 { return float64(s.Hits) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_misses_total",
+			Help:      "Datastore lookups not found in the cache.",
+		}, stat(func(s cache.Stats) float64 { return float64(s.Misses) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_evictions_total",
+			Help:      "Cached lookups evicted to make room for others.",
+		}, stat(func(s cache.Stats) float64 { return float64(s.Evictions) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cache_entries",
+			Help:      "Lookups currently held in the cache.",
+		}, stat(func(s cache.Stats) float64 { return float64(s.Entries) })),
+	)
+}
+
+func routeName(req *http.Request) string {
+	route := mux.CurrentRoute(req)
+	if route == nil {
+		return "unknown"
+	}
+
+	if name := route.GetName(); name != "" {
+		return name
+	}
+
+	if template, err := route.GetPathTemplate(); err == nil {
+		return template
+	}
+
+	return "unknown"
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer, so that streamed
+// responses can still be flushed
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/cache"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a router instrumented with the middleware", t, func() {
+		m := New()
+		router := mux.NewRouter()
+		router.Use(m.Middleware)
+		router.Path("/hierarchies/{instance}/{dimension}").Name("hierarchy_url").HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		router.Path("/unnamed/{id}").HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("ok"))
+		})
+
+		Convey("When a named route is requested, its duration is recorded with the route name and status code", func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/hierarchies/a/b", http.NoBody))
+
+			So(testutil.CollectAndCount(m.requestDuration), ShouldEqual, 1)
+			So(testutil.CollectAndCount(m.requestDuration.MustCurryWith(map[string]string{
+				"route": "hierarchy_url", "method": "GET", "code": "404",
+			})), ShouldEqual, 1)
+		})
+
+		Convey("When an unnamed route is requested, its path template is used", func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/unnamed/123", http.NoBody))
+
+			So(testutil.CollectAndCount(m.requestDuration.MustCurryWith(map[string]string{
+				"route": "/unnamed/{id}", "method": "GET", "code": "200",
+			})), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestObserveDatastore(t *testing.T) {
+	t.Parallel()
+
+	Convey("Datastore calls are timed, and failures other than not found are counted", t, func() {
+		m := New()
+		m.ObserveDatastore("GetHierarchyRoot", time.Millisecond, nil)
+		m.ObserveDatastore("GetHierarchyRoot", time.Millisecond, driver.ErrNotFound)
+		m.ObserveDatastore("GetHierarchyRoot", time.Millisecond, errors.New("timeout"))
+
+		So(testutil.CollectAndCount(m.datastoreDuration), ShouldEqual, 1)
+		So(testutil.ToFloat64(m.datastoreErrors.WithLabelValues("GetHierarchyRoot")), ShouldEqual, 1)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	Convey("The handler serves the registered metrics, including those of a cache", t, func() {
+		m := New()
+		m.RegisterCache(cache.New(&datastoretest.StorerMock{}, 10, time.Minute))
+		m.ObserveDatastore("GetHierarchyElement", time.Millisecond, nil)
+
+		w := httptest.NewRecorder()
+		m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", http.NoBody))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		body := w.Body.String()
+		So(body, ShouldContainSubstring, `hierarchy_api_datastore_request_duration_seconds_count{method="GetHierarchyElement"} 1`)
+		So(body, ShouldContainSubstring, "hierarchy_api_cache_hits_total 0")
+		So(body, ShouldContainSubstring, "hierarchy_api_cache_entries 0")
+		So(strings.Contains(body, "go_goroutines"), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxBatchCodes is the most codes that can be looked up in a single batch
+	MaxBatchCodes = 500
+	// ErrorCodeCodeNotFound is the error code of a batch result for a code that is not in the hierarchy
+	ErrorCodeCodeNotFound = "code_not_found"
+)
+
+// The errors returned for a batch of codes that cannot be looked up
+var (
+	ErrNoCodes      = errors.New("at least one code must be provided")
+	ErrEmptyCode    = errors.New("codes must not be empty")
+	ErrTooManyCodes = fmt.Errorf("no more than %d codes can be looked up at once", MaxBatchCodes)
+)
+
+// BatchCodes returns the codes requested in a batch without duplicates, in the order first requested
+func BatchCodes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, ErrNoCodes
+	}
+
+	seen := make(map[string]bool, len(requested))
+	codes := make([]string, 0, len(requested))
+	for _, code := range requested {
+		if code == "" {
+			return nil, ErrEmptyCode
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	if len(codes) > MaxBatchCodes {
+		return nil, ErrTooManyCodes
+	}
+
+	return codes, nil
+}
@@ -0,0 +1,37 @@
+package models
+
+// HierarchyDiff models the changes to the hierarchy of a dimension from one instance to another
+type HierarchyDiff struct {
+	InstanceID      string          `json:"instance_id"`
+	OtherInstanceID string          `json:"other_instance_id"`
+	Dimension       string          `json:"dimension"`
+	Count           int             `json:"count"`
+	Added           []*ExportNode   `json:"added"`
+	Removed         []*ExportNode   `json:"removed"`
+	Relabelled      []*LabelChange  `json:"relabelled"`
+	Moved           []*ParentChange `json:"moved"`
+	Reordered       []*OrderChange  `json:"reordered"`
+}
+
+// LabelChange is a node whose label changed
+type LabelChange struct {
+	Code string `json:"code"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ParentChange is a node that moved to a different parent, identified by their codes
+type ParentChange struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// OrderChange is a node whose order amongst its parent's children changed
+type OrderChange struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+	From  *int64 `json:"from"`
+	To    *int64 `json:"to"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+// The HTTP and gRPC APIs both map the datastore's nodes to these models, so that their links are built the
+// same way
+
+// MapHierarchyResponse maps a node read from the datastore, with its children and breadcrumbs
+func MapHierarchyResponse(dbResponse *dbmodels.HierarchyResponse) Response {
+	return Response{
+		ID:           dbResponse.ID,
+		Label:        dbResponse.Label,
+		Children:     MapHierarchyElements(dbResponse.Children),
+		NoOfChildren: dbResponse.NoOfChildren,
+		HasData:      dbResponse.HasData,
+		Breadcrumbs:  MapHierarchyElements(dbResponse.Breadcrumbs),
+		Order:        dbResponse.Order,
+	}
+}
+
+// MapHierarchyElements maps nodes read from the datastore without their children, giving nil for none
+func MapHierarchyElements(dbElements []*dbmodels.HierarchyElement) []*Element {
+	//nolint:prealloc // Causes unit tests to fail []*models.Element(nil){} is not equal to []*models.Element{}
+	var elements []*Element
+
+	for _, dbElement := range dbElements {
+		elements = append(elements, &Element{
+			ID:           dbElement.ID,
+			Label:        dbElement.Label,
+			NoOfChildren: dbElement.NoOfChildren,
+			HasData:      dbElement.HasData,
+			Order:        dbElement.Order,
+		})
+	}
+
+	return elements
+}
+
+// MapHierarchyNode maps a subtree read from the datastore
+func MapHierarchyNode(dbNode *datastore.HierarchyNode) *Node {
+	node := &Node{
+		Element: Element{
+			ID:           dbNode.ID,
+			Label:        dbNode.Label,
+			NoOfChildren: dbNode.NoOfChildren,
+			HasData:      dbNode.HasData,
+			Order:        dbNode.Order,
+		},
+	}
+
+	for _, child := range dbNode.Children {
+		node.Children = append(node.Children, MapHierarchyNode(child))
+	}
+
+	return node
+}
@@ -0,0 +1,15 @@
+package models
+
+// Problem is an RFC 7807 problem document describing why a request failed
+type Problem struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Instance   string `json:"instance,omitempty"`
+	ErrorCode  string `json:"error_code"`
+	InstanceID string `json:"instance_id,omitempty"`
+	Dimension  string `json:"dimension,omitempty"`
+	Code       string `json:"code,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
@@ -16,6 +16,7 @@ type Response struct {
 	Label        string          `json:"label"`
 	Children     []*Element      `json:"children,omitempty"`
 	NoOfChildren int64           `json:"no_of_children,omitempty"`
+	TotalCount   int             `json:"total_count"`
 	Order        *int64          `json:"order,omitempty"`
 	Links        map[string]Link `json:"links,omitempty"`
 	HasData      bool            `json:"has_data"`
@@ -32,6 +33,91 @@ type Element struct {
 	HasData      bool            `json:"has_data"`
 }
 
+// Node is an Element with its nested children, used to return a subtree of the hierarchy
+type Node struct {
+	Element
+	Children []*Node `json:"children,omitempty"`
+}
+
+// ExportNode is a node in an export of a whole hierarchy, which refers to its parent by code
+type ExportNode struct {
+	Code         string `json:"code"`
+	Label        string `json:"label"`
+	ParentCode   string `json:"parent_code,omitempty"`
+	Order        *int64 `json:"order,omitempty"`
+	HasData      bool   `json:"has_data"`
+	NoOfChildren int64  `json:"no_of_children,omitempty"`
+}
+
+// SearchResults models the nodes in a hierarchy with labels matching a search query
+type SearchResults struct {
+	Count int         `json:"count"`
+	Limit int         `json:"limit"`
+	Items []*Response `json:"items"`
+}
+
+// Hierarchies models the dimensions of an instance that have a hierarchy
+type Hierarchies struct {
+	Count int                 `json:"count"`
+	Items []*HierarchySummary `json:"items"`
+}
+
+// HierarchySummary describes the hierarchy of one dimension of an instance
+type HierarchySummary struct {
+	Dimension  string          `json:"dimension"`
+	CodelistID string          `json:"code_list_id"`
+	NoOfNodes  int64           `json:"no_of_nodes"`
+	Depth      int             `json:"depth"`
+	Links      map[string]Link `json:"links,omitempty"`
+}
+
+// Elements models a list of nodes related to a node in the hierarchy, such as its ancestors or siblings
+type Elements struct {
+	Count int        `json:"count"`
+	Items []*Element `json:"items"`
+}
+
+// Leaves models a page of the leaf nodes beneath a node in the hierarchy
+type Leaves struct {
+	Count      int        `json:"count"`
+	Offset     int        `json:"offset"`
+	Limit      int        `json:"limit"`
+	TotalCount int        `json:"total_count"`
+	Items      []*Element `json:"items"`
+}
+
+// CodesRequest is a batch of codes to look up in a hierarchy
+type CodesRequest struct {
+	Codes []string `json:"codes"`
+}
+
+// HierarchyRequest is a complete hierarchy to create or replace, described as a flat list of nodes
+type HierarchyRequest struct {
+	CodelistID string         `json:"code_list_id"`
+	Nodes      []*RequestNode `json:"nodes"`
+}
+
+// RequestNode is a single code in a HierarchyRequest. The root node is the only node without a parent
+type RequestNode struct {
+	Code    string `json:"code"`
+	Label   string `json:"label"`
+	Parent  string `json:"parent,omitempty"`
+	Order   *int64 `json:"order,omitempty"`
+	HasData bool   `json:"has_data"`
+}
+
+// CodesResponse maps each code in a batch to its node in the hierarchy
+type CodesResponse struct {
+	Count int                    `json:"count"`
+	Items map[string]*CodeResult `json:"items"`
+}
+
+// CodeResult is the node for a code in a batch, or the error code explaining why it was not returned
+type CodeResult struct {
+	Node      *Response `json:"node,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"`
+}
+
 // Link is a combination of ID and HRef for the object in question
 type Link struct {
 	ID   string `json:"id,omitempty"`
@@ -82,6 +168,16 @@ func (e *Element) AddLinks(host, instanceID, dimensionName, codelistID string, w
 	e.Links["code"] = *GetLinkWithID(fmt.Sprintf(codelistFormat, CodelistURL, codelistID), e.ID, e.ID)
 }
 
+// AddLinks adds links to the root of the hierarchy and to its code list
+func (h *HierarchySummary) AddLinks(host, codeListURL, instanceID string) {
+	if h.Links == nil {
+		h.Links = make(map[string]Link)
+	}
+
+	h.Links["root"] = *GetLink(fmt.Sprintf(rootFormat, host, instanceID, h.Dimension), "")
+	h.Links["code_list"] = *GetLinkWithID(codeListURL+"/code-lists", h.CodelistID, h.CodelistID)
+}
+
 // GetLink returns a Link{id,href} object for the given url/id (or just url if id is empty)
 func GetLink(baseURL, linkID string) *Link {
 	if linkID == "" {
@@ -98,6 +194,11 @@ func GetLinkWithID(baseURL, linkID, id string) *Link {
 	return &Link{HRef: baseURL + "/" + linkID, ID: id}
 }
 
+// GetCodeLink returns a link to a code in a code list
+func GetCodeLink(codeListURL, codelistID, code string) *Link {
+	return GetLinkWithID(fmt.Sprintf(codelistFormat, codeListURL, codelistID), code, code)
+}
+
 // AddLinksWithRewriting adds links (self, codelist and populates children links) when enableURLRewriting is true
 func (r *Response) AddLinksWithRewriting(host, codeListURL, instanceID, dimensionName, codelistID string, isRoot bool) {
 	if r.Links == nil {
@@ -141,3 +242,21 @@ func (e *Element) AddRewrittenLinks(host, codeListURL, instanceID, dimensionName
 
 	e.Links["code"] = *GetLinkWithID(fmt.Sprintf(codelistFormat, codeListURL, codelistID), e.ID, e.ID)
 }
+
+// AddLinks adds self and codelist links to the node and all of its descendants
+func (n *Node) AddLinks(host, instanceID, dimensionName, codelistID string) {
+	n.Element.AddLinks(host, instanceID, dimensionName, codelistID, true)
+
+	for _, child := range n.Children {
+		child.AddLinks(host, instanceID, dimensionName, codelistID)
+	}
+}
+
+// AddRewrittenLinks adds self and codelist links to the node and all of its descendants when enableURLRewriting is true
+func (n *Node) AddRewrittenLinks(host, codeListURL, instanceID, dimensionName, codelistID string) {
+	n.Element.AddRewrittenLinks(host, codeListURL, instanceID, dimensionName, codelistID, true)
+
+	for _, child := range n.Children {
+		child.AddRewrittenLinks(host, codeListURL, instanceID, dimensionName, codelistID)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+// ValidationReport models the problems found checking that the hierarchy of a dimension is a well formed tree
+type ValidationReport struct {
+	InstanceID string               `json:"instance_id"`
+	Dimension  string               `json:"dimension"`
+	Valid      bool                 `json:"valid"`
+	NoOfNodes  int                  `json:"no_of_nodes"`
+	Count      int                  `json:"count"`
+	Problems   []*ValidationProblem `json:"problems"`
+}
+
+// ValidationProblem is a single problem found in a hierarchy, at the node with the given code
+type ValidationProblem struct {
+	Type       string `json:"type"`
+	Code       string `json:"code"`
+	ParentCode string `json:"parent_code,omitempty"`
+	Detail     string `json:"detail"`
+}
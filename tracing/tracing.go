@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Supported trace exporters
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Init sets up the global tracer provider to send spans to the given exporter, and W3C trace context
+// propagation so that traces continue across services. Spans are not recorded when the exporter is
+// ExporterNone. The returned function flushes any buffered spans and stops the tracer provider.
+func Init(ctx context.Context, exporter, otlpEndpoint, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	spanExporter, err := newExporter(ctx, exporter, otlpEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, exporter, otlpEndpoint string) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case ExporterOTLP:
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(otlpEndpoint))
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q", exporter)
+	}
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInit(t *testing.T) {
+	Convey("When tracing is disabled, a no-op shutdown function is returned", t, func() {
+		shutdown, err := Init(context.Background(), ExporterNone, "", "dp-hierarchy-api")
+		So(err, ShouldBeNil)
+		So(shutdown(context.Background()), ShouldBeNil)
+	})
+
+	Convey("When the stdout exporter is selected, tracing is set up and can be shut down", t, func() {
+		shutdown, err := Init(context.Background(), ExporterStdout, "", "dp-hierarchy-api")
+		So(err, ShouldBeNil)
+		So(shutdown(context.Background()), ShouldBeNil)
+	})
+
+	Convey("When an unknown exporter is selected, an error is returned", t, func() {
+		shutdown, err := Init(context.Background(), "zipkin", "", "dp-hierarchy-api")
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, `"zipkin"`)
+		So(shutdown, ShouldBeNil)
+	})
+}
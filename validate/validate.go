@@ -0,0 +1,172 @@
+// Package validate checks that a hierarchy in a datastore is a well formed tree, so that imports can be
+// gated on it.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+)
+
+// ProblemType identifies the kind of problem found in a hierarchy
+type ProblemType string
+
+// The problems a hierarchy is checked for
+const (
+	// Cycle is a node found again below itself
+	Cycle ProblemType = "cycle"
+	// OrphanedNode is a node that cannot be reached from the root, or whose parent is not in the hierarchy
+	OrphanedNode ProblemType = "orphaned_node"
+	// DuplicateCode is a code found more than once, other than below itself
+	DuplicateCode ProblemType = "duplicate_code"
+	// ChildCountMismatch is a node whose number of children differs from the children found below it
+	ChildCountMismatch ProblemType = "child_count_mismatch"
+)
+
+// Problem is a single problem found in a hierarchy
+type Problem struct {
+	Type       ProblemType
+	Code       string
+	ParentCode string
+	Detail     string
+}
+
+// Report lists the problems found in a hierarchy, in the order they were found
+type Report struct {
+	// NoOfNodes is the number of distinct codes walked
+	NoOfNodes int
+	Problems  []*Problem
+}
+
+// IsValid reports whether no problems were found
+func (r *Report) IsValid() bool {
+	return len(r.Problems) == 0
+}
+
+// Validate reports the codes held by more than one node in the hierarchy of dimension in the instance, then
+// walks the hierarchy and reports any cycles, orphaned nodes, codes found under more than one parent and
+// nodes whose number of children does not match the children walked below them. Every node in the
+// hierarchy is then listed, and those the walk did not reach from the root are reported as orphaned.
+// driver.ErrNotFound is returned if the instance has no hierarchy for dimension.
+func Validate(ctx context.Context, store datastore.Storer, instanceID, dimension string) (*Report, error) {
+	v := &validator{
+		nodes:      make(map[string]*datastore.WalkedNode),
+		children:   make(map[string]int64),
+		duplicates: make(map[string]bool),
+	}
+
+	// nodes sharing a code are walked as one, so their children can only be counted once they are known
+	duplicates, err := store.ListDuplicateCodes(ctx, instanceID, dimension)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range duplicates {
+		v.duplicates[code] = true
+		v.problems = append(v.problems, &Problem{Type: DuplicateCode, Code: code, Detail: fmt.Sprintf("code %q is held by more than one node", code)})
+	}
+
+	if err := store.WalkHierarchy(ctx, instanceID, dimension, datastore.WalkOptions{}, v.visit); err != nil {
+		return nil, err
+	}
+
+	report := v.report()
+
+	unreached := make(map[string]bool)
+	err = store.ListHierarchyNodes(ctx, instanceID, dimension, func(node *datastore.ListedNode) error {
+		if _, ok := v.nodes[node.ID]; ok || unreached[node.ID] {
+			return nil
+		}
+		unreached[node.ID] = true
+		report.Problems = append(report.Problems, unreachable(node))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// unreachable returns the problem of a listed node that was not reached from the root
+func unreachable(node *datastore.ListedNode) *Problem {
+	if len(node.ParentIDs) == 0 {
+		return &Problem{Type: OrphanedNode, Code: node.ID, Detail: fmt.Sprintf("code %q has no parent but is not the root", node.ID)}
+	}
+
+	return &Problem{
+		Type:       OrphanedNode,
+		Code:       node.ID,
+		ParentCode: node.ParentIDs[0],
+		Detail:     fmt.Sprintf("code %q under %s cannot be reached from the root", node.ID, quoteAll(node.ParentIDs)),
+	}
+}
+
+func quoteAll(codes []string) string {
+	quoted := make([]string, len(codes))
+	for i, code := range codes {
+		quoted[i] = fmt.Sprintf("%q", code)
+	}
+	return strings.Join(quoted, " and ")
+}
+
+// validator tracks the nodes walked so far. Nodes are walked depth first, parents before their children,
+// so the ancestors of each node are the first Depth codes of path.
+type validator struct {
+	nodes    map[string]*datastore.WalkedNode
+	order    []*datastore.WalkedNode
+	children map[string]int64
+	path     []string
+	problems []*Problem
+	// duplicates are the codes held by more than one node, which are already reported
+	duplicates map[string]bool
+}
+
+func (v *validator) visit(node *datastore.WalkedNode) error {
+	ancestors := v.path[:min(node.Depth, len(v.path))]
+
+	if node.Depth > 0 {
+		if _, ok := v.nodes[node.ParentID]; !ok || node.ParentID == "" {
+			v.add(OrphanedNode, node, fmt.Sprintf("parent %q of code %q is not in the hierarchy", node.ParentID, node.ID))
+		} else {
+			v.children[node.ParentID]++
+		}
+	}
+
+	if first, ok := v.nodes[node.ID]; ok {
+		if i := slices.Index(ancestors, node.ID); i >= 0 {
+			cycle := append(slices.Clone(ancestors[i:]), node.ID)
+			v.add(Cycle, node, fmt.Sprintf("code %q is below itself: %s", node.ID, strings.Join(cycle, " > ")))
+		} else if !v.duplicates[node.ID] {
+			v.add(DuplicateCode, node, fmt.Sprintf("code %q is under both %q and %q", node.ID, first.ParentID, node.ParentID))
+		}
+	} else {
+		n := *node
+		v.nodes[n.ID] = &n
+		v.order = append(v.order, &n)
+	}
+
+	v.path = append(ancestors, node.ID)
+	return nil
+}
+
+func (v *validator) add(problemType ProblemType, node *datastore.WalkedNode, detail string) {
+	v.problems = append(v.problems, &Problem{Type: problemType, Code: node.ID, ParentCode: node.ParentID, Detail: detail})
+}
+
+// report checks the number of children of each node once every node has been walked. The children of the
+// nodes holding a duplicated code are counted together, so they are not checked.
+func (v *validator) report() *Report {
+	for _, node := range v.order {
+		if v.duplicates[node.ID] {
+			continue
+		}
+		if found := v.children[node.ID]; found != node.NoOfChildren {
+			v.add(ChildCountMismatch, node, fmt.Sprintf("code %q has %d children but %d were found", node.ID, node.NoOfChildren, found))
+		}
+	}
+
+	return &Report{NoOfNodes: len(v.order), Problems: v.problems}
+}
@@ -0,0 +1,219 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-graph/v2/graph/driver"
+	dbmodels "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/datastoretest"
+	"github.com/ONSdigital/dp-hierarchy-api/datastore/memory"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// walked is a node as visited by a walk, claiming to have noOfChildren children
+func walked(code, parent string, depth int, noOfChildren int64) *datastore.WalkedNode {
+	return &datastore.WalkedNode{
+		HierarchyElement: dbmodels.HierarchyElement{ID: code, Label: code, NoOfChildren: noOfChildren},
+		ParentID:         parent,
+		Depth:            depth,
+	}
+}
+
+// newWalkingStore returns a datastore whose walk visits the given nodes in order, and which lists only
+// the nodes walked
+func newWalkingStore(nodes ...*datastore.WalkedNode) *datastoretest.StorerMock {
+	return &datastoretest.StorerMock{
+		ListDuplicateCodesFunc: func(_ context.Context, _, _ string) ([]string, error) {
+			return nil, nil
+		},
+		WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, fn datastore.WalkFunc) error {
+			for _, node := range nodes {
+				if err := fn(node); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ListHierarchyNodesFunc: func(_ context.Context, _, _ string, fn datastore.ListFunc) error {
+			for _, node := range nodes {
+				if err := fn(&datastore.ListedNode{HierarchyElement: node.HierarchyElement, ParentIDs: []string{node.ParentID}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func types(report *Report) []ProblemType {
+	var found []ProblemType
+	for _, problem := range report.Problems {
+		found = append(found, problem.Type)
+	}
+	return found
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("A hierarchy loaded into the memory datastore is valid", t, func() {
+		store, err := memory.NewFromFile("../datastore/memory/testdata/hierarchies.json")
+		So(err, ShouldBeNil)
+
+		report, err := Validate(ctx, store, "cpih01-instance", "aggregate")
+		So(err, ShouldBeNil)
+		So(report.IsValid(), ShouldBeTrue)
+		So(report.NoOfNodes, ShouldBeGreaterThan, 1)
+	})
+
+	Convey("A tree whose child counts match is valid", t, func() {
+		report, err := Validate(ctx, newWalkingStore(
+			walked("root", "", 0, 2),
+			walked("a", "root", 1, 1),
+			walked("a.1", "a", 2, 0),
+			walked("b", "root", 1, 0),
+		), "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(report.IsValid(), ShouldBeTrue)
+		So(report.NoOfNodes, ShouldEqual, 4)
+	})
+
+	Convey("A node found again below itself is a cycle", t, func() {
+		report, err := Validate(ctx, newWalkingStore(
+			walked("root", "", 0, 1),
+			walked("a", "root", 1, 1),
+			walked("b", "a", 2, 1),
+			walked("a", "b", 3, 1),
+		), "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(types(report), ShouldResemble, []ProblemType{Cycle})
+		So(report.Problems[0].Code, ShouldEqual, "a")
+		So(report.Problems[0].ParentCode, ShouldEqual, "b")
+		So(report.Problems[0].Detail, ShouldEqual, `code "a" is below itself: a > b > a`)
+		So(report.NoOfNodes, ShouldEqual, 3)
+	})
+
+	Convey("A code found under a second parent is a duplicate", t, func() {
+		report, err := Validate(ctx, newWalkingStore(
+			walked("root", "", 0, 2),
+			walked("a", "root", 1, 1),
+			walked("x", "a", 2, 0),
+			walked("b", "root", 1, 1),
+			walked("x", "b", 2, 0),
+		), "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(types(report), ShouldResemble, []ProblemType{DuplicateCode})
+		So(report.Problems[0].Detail, ShouldEqual, `code "x" is under both "a" and "b"`)
+	})
+
+	Convey("A code held by more than one node is reported once, before the walk, and its children are not counted", t, func() {
+		store := newWalkingStore(
+			walked("root", "", 0, 2),
+			walked("a", "root", 1, 1),
+			walked("x", "a", 2, 1),
+			walked("y", "x", 3, 0),
+			walked("b", "root", 1, 1),
+			walked("x", "b", 2, 1),
+			walked("z", "x", 3, 0),
+		)
+		store.ListDuplicateCodesFunc = func(_ context.Context, _, _ string) ([]string, error) {
+			return []string{"x"}, nil
+		}
+
+		report, err := Validate(ctx, store, "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(types(report), ShouldResemble, []ProblemType{DuplicateCode})
+		So(report.Problems[0].Code, ShouldEqual, "x")
+		So(report.Problems[0].Detail, ShouldEqual, `code "x" is held by more than one node`)
+	})
+
+	Convey("When the duplicated codes cannot be listed, the error is returned", t, func() {
+		store := newWalkingStore(walked("root", "", 0, 0))
+		store.ListDuplicateCodesFunc = func(_ context.Context, _, _ string) ([]string, error) {
+			return nil, driver.ErrNotFound
+		}
+
+		_, err := Validate(ctx, store, "instance", "dimension")
+		So(errors.Is(err, driver.ErrNotFound), ShouldBeTrue)
+		So(store.WalkHierarchyCalls(), ShouldBeEmpty)
+	})
+
+	Convey("A node whose parent was not walked is orphaned", t, func() {
+		report, err := Validate(ctx, newWalkingStore(
+			walked("root", "", 0, 0),
+			walked("a", "missing", 1, 0),
+			walked("b", "", 1, 0),
+		), "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(types(report), ShouldResemble, []ProblemType{OrphanedNode, OrphanedNode})
+		So(report.Problems[0].Detail, ShouldEqual, `parent "missing" of code "a" is not in the hierarchy`)
+	})
+
+	Convey("A node in the hierarchy that the walk did not reach from the root is orphaned", t, func() {
+		store := newWalkingStore(walked("root", "", 0, 0))
+		walkedOnly := store.ListHierarchyNodesFunc
+		store.ListHierarchyNodesFunc = func(ctx context.Context, instanceID, dimension string, fn datastore.ListFunc) error {
+			for _, node := range []*datastore.ListedNode{
+				{HierarchyElement: dbmodels.HierarchyElement{ID: "a"}, ParentIDs: []string{"b"}},
+				{HierarchyElement: dbmodels.HierarchyElement{ID: "b"}, ParentIDs: []string{"a", "c"}},
+				{HierarchyElement: dbmodels.HierarchyElement{ID: "b"}, ParentIDs: []string{"root"}},
+				{HierarchyElement: dbmodels.HierarchyElement{ID: "other"}},
+			} {
+				if err := fn(node); err != nil {
+					return err
+				}
+			}
+			return walkedOnly(ctx, instanceID, dimension, fn)
+		}
+
+		report, err := Validate(ctx, store, "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(types(report), ShouldResemble, []ProblemType{OrphanedNode, OrphanedNode, OrphanedNode})
+		So(report.Problems[0], ShouldResemble, &Problem{Type: OrphanedNode, Code: "a", ParentCode: "b", Detail: `code "a" under "b" cannot be reached from the root`})
+		So(report.Problems[1].Detail, ShouldEqual, `code "b" under "a" and "c" cannot be reached from the root`)
+		So(report.Problems[2], ShouldResemble, &Problem{Type: OrphanedNode, Code: "other", Detail: `code "other" has no parent but is not the root`})
+		So(report.NoOfNodes, ShouldEqual, 1)
+	})
+
+	Convey("When the hierarchy's nodes cannot be listed, the error is returned", t, func() {
+		store := newWalkingStore(walked("root", "", 0, 0))
+		store.ListHierarchyNodesFunc = func(_ context.Context, _, _ string, _ datastore.ListFunc) error {
+			return errors.New("graph error")
+		}
+
+		_, err := Validate(ctx, store, "instance", "dimension")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("A node whose number of children differs from those walked is reported after the walk", t, func() {
+		report, err := Validate(ctx, newWalkingStore(
+			walked("root", "", 0, 3),
+			walked("a", "root", 1, 0),
+			walked("b", "root", 1, 1),
+		), "instance", "dimension")
+		So(err, ShouldBeNil)
+		So(types(report), ShouldResemble, []ProblemType{ChildCountMismatch, ChildCountMismatch})
+		So(report.Problems[0].Code, ShouldEqual, "root")
+		So(report.Problems[0].Detail, ShouldEqual, `code "root" has 3 children but 2 were found`)
+		So(report.Problems[1].Code, ShouldEqual, "b")
+	})
+
+	Convey("When the hierarchy cannot be walked, the error is returned", t, func() {
+		store := &datastoretest.StorerMock{
+			ListDuplicateCodesFunc: func(_ context.Context, _, _ string) ([]string, error) {
+				return nil, nil
+			},
+			WalkHierarchyFunc: func(_ context.Context, _, _ string, _ datastore.WalkOptions, _ datastore.WalkFunc) error {
+				return driver.ErrNotFound
+			},
+		}
+
+		_, err := Validate(ctx, store, "instance", "dimension")
+		So(errors.Is(err, driver.ErrNotFound), ShouldBeTrue)
+	})
+}